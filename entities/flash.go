@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/holiman/uint256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var (
+	ErrFlashNoLiquidity = errors.New("flash requires in-range liquidity (L)")
+	ErrFlashUnderpaid0  = errors.New("flash repayment of token0 is less than the fee (F0)")
+	ErrFlashUnderpaid1  = errors.New("flash repayment of token1 is less than the fee (F1)")
+)
+
+var feeDenominator = uint256.NewInt(1e6)
+
+// FlashFees — комиссии, которые контракт потребует за flash(amount0, amount1):
+// mulDivRoundingUp(amount, fee, 1e6) по каждому токену.
+func (p *Pool) FlashFees(amount0, amount1 *utils.Uint256, fee0, fee1 *utils.Uint256) error {
+	var fee utils.Uint256
+	fee.SetUint64(uint64(p.Fee))
+	if err := mulDivRoundingUp(amount0, &fee, feeDenominator, fee0); err != nil {
+		return err
+	}
+	return mulDivRoundingUp(amount1, &fee, feeDenominator, fee1)
+}
+
+// Flash — аналог UniswapV3Pool.flash: заём amount0/amount1 возвращён с доплатой paid0/paid1
+// (balanceAfter - balanceBefore в контракте). Доплата должна покрывать FlashFees; вся она,
+// включая излишек, распределяется как комиссия: доля протокола (1/FeeProtocolN) — в ProtocolFeesN,
+// остаток — в FeeGrowthGlobalNX128 по текущей in-range ликвидности.
+// При ошибке пул не меняется.
+func (p *Pool) Flash(amount0, amount1, paid0, paid1 *utils.Uint256) error {
+	if p.Liquidity.IsZero() {
+		return ErrFlashNoLiquidity
+	}
+
+	var fee0, fee1 utils.Uint256
+	if err := p.FlashFees(amount0, amount1, &fee0, &fee1); err != nil {
+		return err
+	}
+	if paid0.Lt(&fee0) {
+		return ErrFlashUnderpaid0
+	}
+	if paid1.Lt(&fee1) {
+		return ErrFlashUnderpaid1
+	}
+
+	var growth0, growth1, protocolFee0, protocolFee1 utils.Uint256
+	if err := p.flashFeeGrowth(paid0, p.FeeProtocol0, &protocolFee0, &growth0); err != nil {
+		return err
+	}
+	if err := p.flashFeeGrowth(paid1, p.FeeProtocol1, &protocolFee1, &growth1); err != nil {
+		return err
+	}
+
+	truncateUint128(&protocolFee0)
+	truncateUint128(&protocolFee1)
+	addUint128(&p.ProtocolFees0, &protocolFee0)
+	addUint128(&p.ProtocolFees1, &protocolFee1)
+	p.FeeGrowthGlobal0X128.Add(&p.FeeGrowthGlobal0X128, &growth0)
+	p.FeeGrowthGlobal1X128.Add(&p.FeeGrowthGlobal1X128, &growth1)
+	return nil
+}
+
+// flashFeeGrowth делит доплату paid на долю протокола и прирост fee growth LP.
+func (p *Pool) flashFeeGrowth(paid *utils.Uint256, feeProtocol uint8, protocolFee, feeGrowthX128 *utils.Uint256) error {
+	protocolFee.Clear()
+	feeGrowthX128.Clear()
+	if paid.IsZero() {
+		return nil
+	}
+	if feeProtocol != 0 {
+		protocolFee.Div(paid, protocolFee.SetUint64(uint64(feeProtocol)))
+	}
+
+	var lpFee utils.Uint256
+	lpFee.Sub(paid, protocolFee)
+	return mulDiv(&lpFee, q128, p.Liquidity, feeGrowthX128)
+}
@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+func TestPool_FlashFees(t *testing.T) {
+	pool := newBoundedTestPool(-100, 100, 0, uint256.NewInt(1e18))
+
+	var fee0, fee1 utils.Uint256
+	assert.NoError(t, pool.FlashFees(uint256.NewInt(1e18), uint256.NewInt(1), &fee0, &fee1))
+	assert.Equal(t, uint64(5e14), fee0.Uint64(), "0.05% от 1e18")
+	assert.Equal(t, uint64(1), fee1.Uint64(), "mulDivRoundingUp: даже 1 wei стоит 1 wei комиссии")
+}
+
+// TestPool_Flash: доплата целиком уходит в fee growth in-range ликвидности и начисляется позиции.
+func TestPool_Flash(t *testing.T) {
+	pool := newEmptyTestPool(0)
+	L := uint256.NewInt(1e18)
+	var amount0, amount1 utils.Uint256
+	assert.NoError(t, pool.Mint(alice, -100, 100, L, &amount0, &amount1))
+
+	borrowed := uint256.NewInt(1e18)
+	var fee0, fee1 utils.Uint256
+	assert.NoError(t, pool.FlashFees(borrowed, new(uint256.Int), &fee0, &fee1))
+	assert.True(t, fee1.IsZero())
+
+	// излишек сверх комиссии тоже становится комиссией LP
+	paid0 := new(uint256.Int).AddUint64(&fee0, 1000)
+	assert.NoError(t, pool.Flash(borrowed, new(uint256.Int), paid0, new(uint256.Int)))
+	assert.True(t, pool.FeeGrowthGlobal0X128.Eq(expectedFeeGrowth(paid0, L)))
+	assert.True(t, pool.FeeGrowthGlobal1X128.IsZero())
+
+	assert.NoError(t, pool.Burn(alice, -100, 100, new(uint256.Int), &amount0, &amount1))
+	assert.True(t, pool.GetPosition(alice, -100, 100).TokensOwed0.Eq(expectedOwed(paid0, L, L)))
+}
+
+func TestPool_Flash_ProtocolFee(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 0, L)
+	assert.NoError(t, pool.SetFeeProtocol(0, 5))
+
+	paid1 := uint256.NewInt(1001)
+	assert.NoError(t, pool.Flash(new(uint256.Int), uint256.NewInt(1000), new(uint256.Int), paid1))
+	assert.Equal(t, uint64(200), pool.ProtocolFees1.Uint64(), "1001 / 5")
+	assert.True(t, pool.FeeGrowthGlobal1X128.Eq(expectedFeeGrowth(uint256.NewInt(801), L)))
+	assert.True(t, pool.ProtocolFees0.IsZero())
+}
+
+func TestPool_Flash_Errors(t *testing.T) {
+	pool := newBoundedTestPool(-100, 100, 200, uint256.NewInt(1e18))
+	assert.ErrorIs(t, pool.Flash(uint256.NewInt(1), new(uint256.Int), uint256.NewInt(1), new(uint256.Int)), ErrFlashNoLiquidity)
+
+	pool = newBoundedTestPool(-100, 100, 0, uint256.NewInt(1e18))
+	borrowed := uint256.NewInt(1e18)
+	underpaid := uint256.NewInt(5e14 - 1)
+	assert.ErrorIs(t, pool.Flash(borrowed, borrowed, uint256.NewInt(5e14), underpaid), ErrFlashUnderpaid1)
+	assert.ErrorIs(t, pool.Flash(borrowed, new(uint256.Int), underpaid, new(uint256.Int)), ErrFlashUnderpaid0)
+	assert.True(t, pool.FeeGrowthGlobal0X128.IsZero(), "ошибка не меняет пул")
+}
@@ -0,0 +1,159 @@
+package entities
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/daoleno/uniswap-sdk-core/entities"
+)
+
+var (
+	ErrInvalidGasPrice    = errors.New("invalid gas price")
+	ErrNativePriceMissing = errors.New("native price in gas cost currency is required")
+)
+
+// Эвристика газа свапа V3 для mainnet (v3 heuristic gas model smart-order-router, который
+// оценивает свап по спискам initializedTicksCrossedList QuoterV2).
+const (
+	BaseSwapCost    = 2000
+	CostPerHop      = 80000
+	CostPerInitTick = 31000
+)
+
+// GasModel — модель стоимости свапа в газе и её перевод в токен сделки.
+// Газ маршрута: BaseSwapCost + CostPerHop*hops + CostPerInitTick*max(1, ticksCrossed),
+// где ticksCrossed — сумма CrossInitTickLoops по пулам маршрута (initializedTicksCrossedList QuoterV2).
+type GasModel struct {
+	BaseSwapCost    uint64
+	CostPerHop      uint64
+	CostPerInitTick uint64
+
+	GasPrice *big.Int // цена газа в wei
+	// NativePrice — цена wrapped native токена (база) в токене, в котором считается стоимость газа.
+	// Не нужна, если стоимость считается в самом native/WETH.
+	NativePrice *entities.Price
+}
+
+// NewGasModel — модель с mainnet-константами.
+func NewGasModel(gasPrice *big.Int, nativePrice *entities.Price) *GasModel {
+	return &GasModel{
+		BaseSwapCost:    BaseSwapCost,
+		CostPerHop:      CostPerHop,
+		CostPerInitTick: CostPerInitTick,
+		GasPrice:        gasPrice,
+		NativePrice:     nativePrice,
+	}
+}
+
+// RouteGas — газ одного маршрута из hops пулов, пересёкшего ticksCrossed инициализированных тиков.
+func (m *GasModel) RouteGas(hops, ticksCrossed int) uint64 {
+	if ticksCrossed < 1 {
+		ticksCrossed = 1
+	}
+	return m.BaseSwapCost + m.CostPerHop*uint64(hops) + m.CostPerInitTick*uint64(ticksCrossed)
+}
+
+// GasCost переводит gas в сумму currency по GasPrice и NativePrice.
+func (m *GasModel) GasCost(gas uint64, currency entities.Currency) (*entities.CurrencyAmount, error) {
+	if m.GasPrice == nil || m.GasPrice.Sign() < 0 {
+		return nil, ErrInvalidGasPrice
+	}
+	costWei := new(big.Int).Mul(new(big.Int).SetUint64(gas), m.GasPrice)
+	native := entities.WETH9[currency.ChainId()]
+	if native != nil && currency.Wrapped().Equal(native) {
+		return entities.FromRawAmount(currency, costWei), nil
+	}
+	if m.NativePrice == nil || !m.NativePrice.BaseCurrency.Wrapped().Equal(native) ||
+		!m.NativePrice.QuoteCurrency.Wrapped().Equal(currency.Wrapped()) {
+		return nil, ErrNativePriceMissing
+	}
+	cost, err := m.NativePrice.Quote(entities.FromRawAmount(m.NativePrice.BaseCurrency, costWei))
+	if err != nil {
+		return nil, err
+	}
+	return entities.FromFractionalAmount(currency, cost.Numerator, cost.Denominator), nil
+}
+
+// InitializedTicksCrossed — число пересечённых инициализированных тиков по каждому Swap сделки
+// (сумма CrossInitTickLoops по пулам маршрута). Считается повторной read-only симуляцией свапов
+// и кэшируется в сделке.
+func (t *Trade) InitializedTicksCrossed() ([]int, error) {
+	if t.initializedTicksCrossed != nil {
+		return t.initializedTicksCrossed, nil
+	}
+	ticksCrossed := make([]int, len(t.Swaps))
+	for i, swap := range t.Swaps {
+		amount := swap.InputAmount
+		if t.TradeType == entities.ExactOutput {
+			amount = swap.OutputAmount
+		}
+		_, crossed, err := quoteRoute(swap.Route, amount.Quotient(), t.TradeType)
+		if err != nil {
+			return nil, err
+		}
+		ticksCrossed[i] = crossed
+	}
+	t.initializedTicksCrossed = ticksCrossed
+	return ticksCrossed, nil
+}
+
+// EstimateGas — газ сделки по модели m: сумма RouteGas её маршрутов.
+func (t *Trade) EstimateGas(m *GasModel) (uint64, error) {
+	ticksCrossed, err := t.InitializedTicksCrossed()
+	if err != nil {
+		return 0, err
+	}
+	var gas uint64
+	for i, swap := range t.Swaps {
+		gas += m.RouteGas(len(swap.Route.Pools), ticksCrossed[i])
+	}
+	return gas, nil
+}
+
+// GasCost — стоимость газа сделки: в выходном токене для exact input, во входном для exact output.
+func (t *Trade) GasCost(m *GasModel) (*entities.CurrencyAmount, error) {
+	gas, err := t.EstimateGas(m)
+	if err != nil {
+		return nil, err
+	}
+	if t.TradeType == entities.ExactInput {
+		return m.GasCost(gas, t.OutputAmount().Currency)
+	}
+	return m.GasCost(gas, t.InputAmount().Currency)
+}
+
+// gasAdjustedTradeComparator — tradeComparator по сумме за вычетом газа: выход минус GasCost для
+// exact input, вход плюс GasCost для exact output. При равенстве — обычный tradeComparator.
+// Стоимость газа сделок должна быть уже посчитана (GasCost без ошибки), иначе паника, как в tradeComparator.
+func gasAdjustedTradeComparator(m *GasModel) func(a, b *Trade) int {
+	return func(a, b *Trade) int {
+		aCost, err := a.GasCost(m)
+		if err != nil {
+			panic(err)
+		}
+		bCost, err := b.GasCost(m)
+		if err != nil {
+			panic(err)
+		}
+		if a.TradeType == entities.ExactInput {
+			// больший чистый выход — раньше
+			aNet, bNet := a.OutputAmount().Subtract(aCost), b.OutputAmount().Subtract(bCost)
+			if !aNet.EqualTo(bNet.Fraction) {
+				if aNet.GreaterThan(bNet.Fraction) {
+					return -1
+				}
+				return 1
+			}
+		} else {
+			// меньший полный вход — раньше
+			aTotal, bTotal := a.InputAmount().Add(aCost), b.InputAmount().Add(bCost)
+			if !aTotal.EqualTo(bTotal.Fraction) {
+				if aTotal.LessThan(bTotal.Fraction) {
+					return -1
+				}
+				return 1
+			}
+		}
+		return tradeComparator(a, b)
+	}
+}
@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasModel_GasCost(t *testing.T) {
+	weth := entities.WETH9[1]
+	m := NewGasModel(big.NewInt(2e9), entities.NewPrice(weth, DAI, big.NewInt(1), big.NewInt(3000)))
+
+	assert.Equal(t, uint64(BaseSwapCost+CostPerHop+CostPerInitTick), m.RouteGas(1, 0))
+	assert.Equal(t, uint64(BaseSwapCost+2*CostPerHop+3*CostPerInitTick), m.RouteGas(2, 3))
+
+	cost, err := m.GasCost(100000, weth)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(2e14), cost.Quotient())
+
+	cost, err = m.GasCost(100000, entities.EtherOnChain(1))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(2e14), cost.Quotient())
+
+	cost, err = m.GasCost(100000, DAI)
+	require.NoError(t, err)
+	assert.True(t, cost.Currency.Equal(DAI))
+	assert.Equal(t, big.NewInt(6e17), cost.Quotient())
+
+	_, err = m.GasCost(100000, USDC)
+	assert.ErrorIs(t, err, ErrNativePriceMissing)
+}
+
+func TestTrade_EstimateGas(t *testing.T) {
+	_, direct3000, viaWeth := newSplitTestRoutes(t)
+	m := NewGasModel(big.NewInt(1), nil)
+
+	trade, err := ExactIn(direct3000, entities.FromRawAmount(USDC, big.NewInt(1e6)))
+	require.NoError(t, err)
+	gas, err := trade.EstimateGas(m)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(BaseSwapCost+CostPerHop+CostPerInitTick), gas)
+
+	trade, err = ExactOut(viaWeth, entities.FromRawAmount(DAI, big.NewInt(1e6)))
+	require.NoError(t, err)
+	gas, err = trade.EstimateGas(m)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(BaseSwapCost+2*CostPerHop+CostPerInitTick), gas)
+
+	// свап DAI -> USDC из позиции [-100, 100) переходит в [100, 200) через тик 100
+	route, err := NewRoute([]*Pool{newTwoPositionsPool(uint256.NewInt(1e18), uint256.NewInt(1e18))}, DAI, USDC)
+	require.NoError(t, err)
+	trade, err = ExactIn(route, entities.FromRawAmount(DAI, big.NewInt(7e15)))
+	require.NoError(t, err)
+	ticksCrossed, err := trade.InitializedTicksCrossed()
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, ticksCrossed)
+}
+
+func TestBestTradeExactIn_GasModel(t *testing.T) {
+	weth := entities.WETH9[1]
+	pools := []*Pool{
+		newSplitTestPool(USDC, DAI, constants.FeeMedium, OneEther),
+		newSplitTestPool(USDC, weth, constants.FeeLow, OneEther),
+		newSplitTestPool(weth, DAI, constants.FeeLow, OneEther),
+	}
+	amountIn := entities.FromRawAmount(USDC, big.NewInt(1e14))
+
+	// без газа двухшаговый маршрут через 0.05% пулы отдаёт больше, чем прямой 0.3%
+	trades, err := BestTradeExactIn(pools, amountIn, DAI, &BestTradeOptions{MaxNumResults: 3, MaxHops: 2}, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, trades, 2)
+	assert.Len(t, trades[0].Swaps[0].Route.Pools, 2)
+
+	// лишний hop стоит CostPerHop газа: 80000 * 1 gwei = 8e13 DAI при цене 1:1 больше разницы выхода
+	m := NewGasModel(big.NewInt(1e9), entities.NewPrice(weth, DAI, big.NewInt(1), big.NewInt(1)))
+	trades, err = BestTradeExactIn(pools, amountIn, DAI, &BestTradeOptions{MaxNumResults: 3, MaxHops: 2, GasModel: m}, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, trades, 2)
+	assert.Len(t, trades[0].Swaps[0].Route.Pools, 1)
+
+	// стоимость газа во входном токене нужна цена native в нём
+	_, err = BestTradeExactOut(pools, USDC, entities.FromRawAmount(DAI, big.NewInt(1e16)), &BestTradeOptions{MaxNumResults: 3, MaxHops: 2, GasModel: m}, nil, nil, nil)
+	assert.ErrorIs(t, err, ErrNativePriceMissing)
+}
@@ -0,0 +1,194 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var ErrFetchedTickOutsideWord = errors.New("fetched tick is outside of the requested word or not a multiple of tick spacing")
+
+// TickFetcher загружает инициализированные тики слова word tickBitmap пула на блоке block —
+// как TickLens.getPopulatedTicksInWord (см. ticklens.Fetcher). Порядок тиков любой.
+type TickFetcher func(block uint64, word int16) ([]Tick, error)
+
+// LazyTickProvider — read-only TickDataProvider, который ничего не загружает заранее: слово битмапа
+// запрашивается у TickFetcher, когда Swap впервые в него заходит, и дальше берётся из кэша.
+// Подходит для котирования по тысячам пулов, где держать все тики в памяти невыгодно.
+// Mint/Burn/SwapCommit с пересечением тиков не поддерживаются (ErrTicksReadOnly).
+// Не потокобезопасен.
+type LazyTickProvider struct {
+	TickSpacing int32
+
+	// Block — блок, на котором загружаются новые слова. Уже загруженные слова остаются в кэше
+	// до явной инвалидации (InvalidateBefore, InvalidateWord, InvalidateTicks, Invalidate).
+	Block uint64
+
+	// Fetches — число вызовов fetch, для статистики.
+	Fetches int
+
+	fetch TickFetcher
+	words map[int16]*lazyWord
+
+	minWord, maxWord int16
+}
+
+type lazyWord struct {
+	block uint64
+	ticks []Tick // по возрастанию Index
+}
+
+func NewLazyTickProvider(tickSpacing int32, block uint64, fetch TickFetcher) *LazyTickProvider {
+	return &LazyTickProvider{
+		TickSpacing: tickSpacing,
+		Block:       block,
+		fetch:       fetch,
+		words:       make(map[int16]*lazyWord),
+		minWord:     tickWordPosition(utils.MinTick, tickSpacing),
+		maxWord:     tickWordPosition(utils.MaxTick, tickSpacing),
+	}
+}
+
+func (l *LazyTickProvider) GetTick(tick int32) (Tick, error) {
+	ticks, err := l.word(tickWordPosition(tick, l.TickSpacing))
+	if err != nil {
+		return EmptyTick, err
+	}
+	if i, found := slices.BinarySearchFunc(ticks, tick, compareTickIndex); found {
+		return ticks[i], nil
+	}
+	return EmptyTick, ErrTickNotFound
+}
+
+// NextInitializedTickIndex ищет тик только в одном слове битмапа, как TickBitmap.nextInitializedTickWithinOneWord:
+// для lte — в слове tick, иначе — в слове следующего за tick сжатого тика. Если в слове инициализированного
+// тика нет, возвращается граница слова с initialized = false, и Swap делает до неё обычный шаг, поэтому
+// число подгрузок растёт только с расстоянием, которое реально проходит цена. За крайним словом пула —
+// ErrBelowSmallest / ErrAtOrAboveLargest, как у TicksHandler.
+func (l *LazyTickProvider) NextInitializedTickIndex(tick int32, lte bool) (int32, bool, error) {
+	if lte {
+		word := tickWordPosition(tick, l.TickSpacing)
+		if word < l.minWord {
+			return ZeroValueTickIndex, false, ErrBelowSmallest
+		}
+		ticks, err := l.word(word)
+		if err != nil {
+			return ZeroValueTickIndex, false, err
+		}
+		// последний тик <= tick
+		i, found := slices.BinarySearchFunc(ticks, tick, compareTickIndex)
+		if found {
+			return ticks[i].Index, true, nil
+		}
+		if i > 0 {
+			return ticks[i-1].Index, true, nil
+		}
+		if word == l.minWord {
+			return ZeroValueTickIndex, false, ErrBelowSmallest
+		}
+		return (int32(word) << 8) * l.TickSpacing, false, nil
+	}
+
+	word := int16((compressTick(tick, l.TickSpacing) + 1) >> 8)
+	if word > l.maxWord {
+		return ZeroValueTickIndex, false, ErrAtOrAboveLargest
+	}
+	ticks, err := l.word(word)
+	if err != nil {
+		return ZeroValueTickIndex, false, err
+	}
+	// первый тик > tick
+	i, found := slices.BinarySearchFunc(ticks, tick, compareTickIndex)
+	if found {
+		i++
+	}
+	if i < len(ticks) {
+		return ticks[i].Index, true, nil
+	}
+	if word == l.maxWord {
+		return ZeroValueTickIndex, false, ErrAtOrAboveLargest
+	}
+	return (int32(word)<<8 + 255) * l.TickSpacing, false, nil
+}
+
+// LoadedWords — число слов в кэше.
+func (l *LazyTickProvider) LoadedWords() int {
+	return len(l.words)
+}
+
+// InvalidateBefore удаляет слова, загруженные на блоке раньше block, и возвращает их число.
+func (l *LazyTickProvider) InvalidateBefore(block uint64) int {
+	removed := 0
+	for word, w := range l.words {
+		if w.block < block {
+			delete(l.words, word)
+			removed++
+		}
+	}
+	return removed
+}
+
+// InvalidateWord удаляет из кэша одно слово.
+func (l *LazyTickProvider) InvalidateWord(word int16) {
+	delete(l.words, word)
+}
+
+// InvalidateTicks удаляет слова граничных тиков позиции — после Mint/Burn в этом диапазоне.
+func (l *LazyTickProvider) InvalidateTicks(tickLower, tickUpper int32) {
+	delete(l.words, tickWordPosition(tickLower, l.TickSpacing))
+	delete(l.words, tickWordPosition(tickUpper, l.TickSpacing))
+}
+
+// Invalidate очищает кэш целиком.
+func (l *LazyTickProvider) Invalidate() {
+	clear(l.words)
+}
+
+// word возвращает тики слова из кэша или загружает их. Ошибка fetch не кэшируется.
+func (l *LazyTickProvider) word(word int16) ([]Tick, error) {
+	if w, ok := l.words[word]; ok {
+		return w.ticks, nil
+	}
+
+	l.Fetches++
+	ticks, err := l.fetch(l.Block, word)
+	if err != nil {
+		return nil, fmt.Errorf("fetch word %d at block %d: %w", word, l.Block, err)
+	}
+	slices.SortFunc(ticks, compareTicks)
+	for i := range ticks {
+		if ticks[i].Index%l.TickSpacing != 0 || tickWordPosition(ticks[i].Index, l.TickSpacing) != word {
+			return nil, fmt.Errorf("fetch word %d: tick %d: %w", word, ticks[i].Index, ErrFetchedTickOutsideWord)
+		}
+		if i > 0 && ticks[i-1].Index == ticks[i].Index {
+			return nil, fmt.Errorf("fetch word %d: tick %d: %w", word, ticks[i].Index, ErrSorted)
+		}
+	}
+
+	l.words[word] = &lazyWord{block: l.Block, ticks: ticks}
+	return ticks, nil
+}
+
+// tickWordPosition — индекс слова tickBitmap, в котором лежит tick (TickBitmap.position).
+func tickWordPosition(tick, tickSpacing int32) int16 {
+	return int16(compressTick(tick, tickSpacing) >> 8)
+}
+
+// compressTick — tick / tickSpacing с округлением к минус бесконечности, как в TickBitmap.
+func compressTick(tick, tickSpacing int32) int32 {
+	compressed := tick / tickSpacing
+	if tick < 0 && tick%tickSpacing != 0 {
+		compressed-- // round towards negative infinity
+	}
+	return compressed
+}
+
+func compareTickIndex(t Tick, index int32) int {
+	return int(t.Index) - int(index)
+}
+
+func compareTicks(a, b Tick) int {
+	return int(a.Index) - int(b.Index)
+}
@@ -0,0 +1,216 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// fakeFetcher — in-memory «блокчейн»: отдаёт тики слова из хендлера эталонного пула.
+type fakeFetcher struct {
+	handler *TicksHandler
+	spacing int32
+	calls   map[int16]int
+	blocks  []uint64
+	err     error
+}
+
+func (f *fakeFetcher) fetch(block uint64, word int16) ([]Tick, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.calls[word]++
+	f.blocks = append(f.blocks, block)
+
+	var ticks []Tick
+	for _, tick := range f.handler.snapshotTicks() {
+		if tickWordPosition(tick.Index, f.spacing) == word {
+			ticks = append(ticks, tick)
+		}
+	}
+	return ticks, nil
+}
+
+// newLazyTestPools — эталонный пул на TicksHandler и его копия на LazyTickProvider.
+func newLazyTestPools(t *testing.T) (*Pool, *Pool, *fakeFetcher) {
+	pool := newTwoPositionsTestPool(t)
+	var amount0, amount1 utils.Uint256
+	// слово 11 при tickSpacing 10
+	assert.NoError(t, pool.Mint(bob, 28200, 30000, uint256.NewInt(1e15), &amount0, &amount1))
+
+	fetcher := &fakeFetcher{handler: pool.TickDataProvider.(*TicksHandler), spacing: int32(pool.TickSpacing), calls: map[int16]int{}}
+	provider := NewLazyTickProvider(int32(pool.TickSpacing), 100, fetcher.fetch)
+	lazyPool := NewPoolV3(pool.Address, uint16(pool.Fee), pool.TickCurrent, pool.SqrtRatioX96, pool.Token0, pool.Token1, provider)
+	lazyPool.Liquidity.Set(pool.Liquidity)
+	return pool, lazyPool, fetcher
+}
+
+func TestLazyTickProvider_SwapMatchesTicksHandler(t *testing.T) {
+	pool, lazyPool, fetcher := newLazyTestPools(t)
+	provider := lazyPool.TickDataProvider.(*LazyTickProvider)
+
+	// небольшой свап: нужны только слова -1 и 0 вокруг текущей цены
+	for _, zeroForOne := range []bool{true, false} {
+		assertSameSwap(t, pool, lazyPool, zeroForOne, int256.NewInt(1e16), false)
+	}
+	assert.Equal(t, map[int16]int{-1: 1, 0: 1}, fetcher.calls)
+	assert.Equal(t, 2, provider.Fetches)
+
+	// свап до позиции в слове 11 подгружает промежуточные слова по одному разу
+	assertSameSwap(t, pool, lazyPool, false, int256.NewInt(1e18), false)
+	for word, calls := range fetcher.calls {
+		assert.Equal(t, 1, calls, "слово %d", word)
+	}
+
+	// повторный свап — целиком из кэша
+	fetches := provider.Fetches
+	assert.NoError(t, lazyPool.Swap(false, int256.NewInt(1e18), nil, &SwapResultV2{}))
+	assert.Equal(t, fetches, provider.Fetches)
+
+	tick, err := provider.GetTick(28200)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1e15), tick.LiquidityGross.Uint64())
+	_, err = provider.GetTick(28210)
+	assert.ErrorIs(t, err, ErrTickNotFound)
+}
+
+func TestLazyTickProvider_Invalidation(t *testing.T) {
+	_, lazyPool, fetcher := newLazyTestPools(t)
+	provider := lazyPool.TickDataProvider.(*LazyTickProvider)
+
+	_, err := provider.GetTick(-60)
+	assert.NoError(t, err)
+	_, err = provider.GetTick(120)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, provider.LoadedWords())
+
+	// слово 0 обновлено на блоке 105, слово -1 осталось от блока 100
+	provider.Block = 105
+	provider.InvalidateWord(0)
+	_, err = provider.GetTick(120)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{100, 100, 105}, fetcher.blocks)
+
+	assert.Equal(t, 1, provider.InvalidateBefore(105))
+	assert.Equal(t, 1, provider.LoadedWords())
+
+	provider.InvalidateTicks(-60, 120)
+	assert.Equal(t, 0, provider.LoadedWords())
+
+	_, err = provider.GetTick(0)
+	assert.ErrorIs(t, err, ErrTickNotFound)
+	provider.Invalidate()
+	assert.Equal(t, 0, provider.LoadedWords())
+}
+
+func TestLazyTickProvider_Errors(t *testing.T) {
+	_, lazyPool, fetcher := newLazyTestPools(t)
+
+	// ошибка fetch доходит до Swap и не кэшируется
+	fetcher.err = errors.New("rpc timeout")
+	err := lazyPool.Swap(true, int256.NewInt(1e16), nil, &SwapResultV2{})
+	assert.ErrorIs(t, err, fetcher.err)
+	assert.Equal(t, 0, lazyPool.TickDataProvider.(*LazyTickProvider).LoadedWords())
+
+	// в режиме bitmap — тоже: упавшее слово не считается пустым
+	lazyPool.TickBitmapStepping = true
+	err = lazyPool.Swap(true, int256.NewInt(1e16), nil, &SwapResultV2{})
+	assert.ErrorIs(t, err, fetcher.err)
+	lazyPool.TickBitmapStepping = false
+
+	fetcher.err = nil
+	assert.NoError(t, lazyPool.Swap(true, int256.NewInt(1e16), nil, &SwapResultV2{}))
+
+	// fetcher вернул тик чужого слова
+	provider := NewLazyTickProvider(10, 0, func(uint64, int16) ([]Tick, error) {
+		return []Tick{{Index: 2560, LiquidityGross: uint256.NewInt(1), LiquidityNet: int256.NewInt(1)}}, nil
+	})
+	_, err = provider.GetTick(0)
+	assert.ErrorIs(t, err, ErrFetchedTickOutsideWord)
+
+	// read-only: Mint отклоняется
+	var amount0, amount1 utils.Uint256
+	assert.ErrorIs(t, lazyPool.Mint(alice, -10, 10, uint256.NewInt(1), &amount0, &amount1), ErrTicksReadOnly)
+}
+
+func TestLazyTickProvider_EmptyPool(t *testing.T) {
+	provider := NewLazyTickProvider(60, 0, func(uint64, int16) ([]Tick, error) { return nil, nil })
+
+	// пустое слово — его граница без инициализации, как nextInitializedTickWithinOneWord
+	next, initialized, err := provider.NextInitializedTickIndex(0, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), next)
+	assert.False(t, initialized)
+	next, initialized, err = provider.NextInitializedTickIndex(0, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(255*60), next)
+	assert.False(t, initialized)
+	next, _, err = provider.NextInitializedTickIndex(-1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(-256*60), next)
+	assert.Equal(t, 2, provider.LoadedWords())
+
+	// tickSpacing 60: слова -58..57, за ними — край пула
+	_, _, err = provider.NextInitializedTickIndex(-58*256*60, true)
+	assert.ErrorIs(t, err, ErrBelowSmallest)
+	_, _, err = provider.NextInitializedTickIndex(58*256*60-1, false)
+	assert.ErrorIs(t, err, ErrAtOrAboveLargest)
+}
+
+// TestLazyTickProvider_FetchesFollowPrice: подгружаются только слова, которые проходит цена,
+// а не все слова до края пула.
+func TestLazyTickProvider_FetchesFollowPrice(t *testing.T) {
+	provider := NewLazyTickProvider(1, 0, func(uint64, int16) ([]Tick, error) { return nil, nil })
+	var sqrtP, limit utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(0, &sqrtP)
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(-10, &limit)
+	pool := NewPoolV3(common.Address{}, uint16(constants.FeeLowest), 0, &sqrtP, USDC, DAI, provider)
+	pool.Liquidity = uint256.NewInt(1e18)
+
+	result := &SwapResultV2{}
+	assert.NoError(t, pool.Swap(true, int256.NewInt(1e12), &limit, result))
+	assert.Equal(t, 2, provider.Fetches)
+	// через 3 слова вниз — ещё 3 подгрузки
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(-3*256-10, &limit)
+	assert.NoError(t, pool.Swap(true, int256.MustFromDec("1000000000000000000000"), &limit, result))
+	assert.Equal(t, int32(-3*256-10), result.CurrentTick)
+	assert.Equal(t, 5, provider.Fetches)
+}
+
+// TestLazyTickProvider_BitmapSteppingFetches: в режиме TickBitmapStepping граница слова, которую
+// вернул LazyTickProvider, завершает шаг — соседнее слово, куда цена не заходит, не запрашивается,
+// и его недоступность свап не ломает.
+func TestLazyTickProvider_BitmapSteppingFetches(t *testing.T) {
+	fetched := map[int16]bool{}
+	provider := NewLazyTickProvider(1, 0, func(_ uint64, word int16) ([]Tick, error) {
+		if word < -4 {
+			return nil, errors.New("word is out of the swap range")
+		}
+		fetched[word] = true
+		return nil, nil
+	})
+	var sqrtP, limit utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(0, &sqrtP)
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(-10, &limit)
+	pool := NewPoolV3(common.Address{}, uint16(constants.FeeLowest), 0, &sqrtP, USDC, DAI, provider)
+	pool.Liquidity = uint256.NewInt(1e18)
+	pool.TickBitmapStepping = true
+
+	result := &SwapResultV2{}
+	assert.NoError(t, pool.Swap(true, int256.NewInt(1e12), &limit, result))
+	assert.Equal(t, 2, provider.Fetches)
+	assert.Equal(t, map[int16]bool{0: true, -1: true}, fetched)
+
+	// до тика -778 (слово -4): ещё слова -2, -3, -4, слово -5 не трогается
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(-3*256-10, &limit)
+	assert.NoError(t, pool.Swap(true, int256.MustFromDec("1000000000000000000000"), &limit, result))
+	assert.Equal(t, int32(-3*256-10), result.CurrentTick)
+	assert.Equal(t, 5, provider.Fetches)
+}
@@ -0,0 +1,271 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var (
+	ErrOracleNotInitialized  = errors.New("oracle is not initialized (I)")
+	ErrObservationTooOld     = errors.New("target observation is older than the oldest one (OLD)")
+	ErrObserveLengthMismatch = errors.New("observe output slices must have the same length as secondsAgos")
+	ErrOracleAlreadyInit     = errors.New("oracle is already initialized")
+)
+
+// Observation — аналог Oracle.Observation контракта.
+// TickCumulative — int56 в контракте; int64 хватает с запасом на весь срок жизни пула.
+// SecondsPerLiquidityCumulativeX128 — uint160, арифметика по модулю 2^160.
+type Observation struct {
+	BlockTimestamp                    uint32
+	TickCumulative                    int64
+	SecondsPerLiquidityCumulativeX128 utils.Uint160
+	Initialized                       bool
+}
+
+// InitializeOracle — аналог Oracle.initialize (вызывается контрактом в initialize пула):
+// первая observation на момент time, cardinality = cardinalityNext = 1.
+// До инициализации Pool не пишет observations вовсе — replay без оракула ничего не платит.
+func (p *Pool) InitializeOracle(time uint32) error {
+	if p.ObservationCardinality != 0 {
+		return ErrOracleAlreadyInit
+	}
+	if len(p.Observations) == 0 {
+		p.Observations = make([]Observation, 1)
+	}
+	p.Observations[0] = Observation{BlockTimestamp: time, Initialized: true}
+	p.ObservationIndex = 0
+	p.ObservationCardinality = 1
+	p.ObservationCardinalityNext = 1
+	p.BlockTimestamp = time
+	return nil
+}
+
+// IncreaseObservationCardinalityNext — аналог increaseObservationCardinalityNext пула (Oracle.grow):
+// новые слоты помечаются ненулевым timestamp, а реально кольцо расширится при следующей записи.
+func (p *Pool) IncreaseObservationCardinalityNext(next uint16) error {
+	if p.ObservationCardinality == 0 {
+		return ErrOracleNotInitialized
+	}
+	if next <= p.ObservationCardinalityNext {
+		return nil
+	}
+	// слоты пишутся по индексу, как в контракте: после Snapshot.Restore за ObservationCardinalityNext
+	// могут лежать старые слоты, их тоже надо пометить
+	for i := int(p.ObservationCardinalityNext); i < int(next); i++ {
+		// store in each slot to prevent fresh SSTOREs in swaps
+		// this data will not be used because the initialized boolean is still false
+		if i < len(p.Observations) {
+			p.Observations[i].BlockTimestamp = 1
+		} else {
+			p.Observations = append(p.Observations, Observation{BlockTimestamp: 1})
+		}
+	}
+	p.ObservationCardinalityNext = next
+	return nil
+}
+
+// Observe — аналог UniswapV3Pool.observe: для каждого secondsAgos[i] пишет в tickCumulatives[i] и
+// secondsPerLiquidityCumulativeX128s[i] накопленные значения на момент BlockTimestamp - secondsAgos[i].
+// Текущим временем считается BlockTimestamp пула, как _blockTimestamp() в контракте.
+func (p *Pool) Observe(secondsAgos []uint32, tickCumulatives []int64, secondsPerLiquidityCumulativeX128s []utils.Uint160) error {
+	if len(tickCumulatives) != len(secondsAgos) || len(secondsPerLiquidityCumulativeX128s) != len(secondsAgos) {
+		return ErrObserveLengthMismatch
+	}
+	if p.ObservationCardinality == 0 {
+		return ErrOracleNotInitialized
+	}
+	for i, secondsAgo := range secondsAgos {
+		if err := p.observeSingle(p.BlockTimestamp, secondsAgo, &tickCumulatives[i], &secondsPerLiquidityCumulativeX128s[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeObservation — аналог Oracle.write: не больше одной записи на блок; при заполнении
+// последнего слота кольцо расширяется до cardinalityNext. tick и liquidity — значения
+// до изменения состояния пула (slot0.tick и liquidity на начало операции).
+func (p *Pool) writeObservation(tick int32, liquidity *utils.Uint128) {
+	if p.ObservationCardinality == 0 {
+		return
+	}
+	last := &p.Observations[p.ObservationIndex]
+	// early return if we've already written an observation this block
+	if last.BlockTimestamp == p.BlockTimestamp {
+		return
+	}
+
+	index := p.nextObservationIndex()
+	if p.ObservationCardinalityNext > p.ObservationCardinality && p.ObservationIndex == p.ObservationCardinality-1 {
+		p.ObservationCardinality = p.ObservationCardinalityNext
+	}
+	transformObservation(last, p.BlockTimestamp, tick, liquidity, &p.Observations[index])
+	p.ObservationIndex = index
+}
+
+// nextObservationIndex — слот, в который попадёт следующая запись writeObservation.
+func (p *Pool) nextObservationIndex() uint16 {
+	cardinality := p.ObservationCardinality
+	// if the conditions are right, we can bump the cardinality
+	if p.ObservationCardinalityNext > cardinality && p.ObservationIndex == cardinality-1 {
+		cardinality = p.ObservationCardinalityNext
+	}
+	return uint16((uint32(p.ObservationIndex) + 1) % uint32(cardinality))
+}
+
+// transformObservation — аналог Oracle.transform: result может совпадать с last.
+func transformObservation(last *Observation, blockTimestamp uint32, tick int32, liquidity *utils.Uint128, result *Observation) {
+	delta := blockTimestamp - last.BlockTimestamp
+
+	var secondsPerLiquidityX128 utils.Uint160
+	secondsPerLiquidityX128.SetUint64(uint64(delta))
+	secondsPerLiquidityX128.Lsh(&secondsPerLiquidityX128, 128)
+	// liquidity > 0 ? liquidity : 1
+	if !liquidity.IsZero() {
+		secondsPerLiquidityX128.Div(&secondsPerLiquidityX128, liquidity)
+	}
+
+	result.TickCumulative = last.TickCumulative + int64(tick)*int64(delta)
+	result.SecondsPerLiquidityCumulativeX128.Add(&last.SecondsPerLiquidityCumulativeX128, &secondsPerLiquidityX128)
+	truncateUint160(&result.SecondsPerLiquidityCumulativeX128)
+	result.BlockTimestamp = blockTimestamp
+	result.Initialized = true
+}
+
+// observeSingle — аналог Oracle.observeSingle при текущих тике и ликвидности пула.
+func (p *Pool) observeSingle(time, secondsAgo uint32, tickCumulative *int64, secondsPerLiquidityCumulativeX128 *utils.Uint160) error {
+	if secondsAgo == 0 {
+		last := p.Observations[p.ObservationIndex]
+		if last.BlockTimestamp != time {
+			transformObservation(&last, time, p.TickCurrent, p.Liquidity, &last)
+		}
+		*tickCumulative = last.TickCumulative
+		*secondsPerLiquidityCumulativeX128 = last.SecondsPerLiquidityCumulativeX128
+		return nil
+	}
+
+	target := time - secondsAgo
+
+	var beforeOrAt, atOrAfter Observation
+	if err := p.getSurroundingObservations(time, target, &beforeOrAt, &atOrAfter); err != nil {
+		return err
+	}
+
+	switch target {
+	case beforeOrAt.BlockTimestamp:
+		// we're at the left boundary
+		*tickCumulative = beforeOrAt.TickCumulative
+		*secondsPerLiquidityCumulativeX128 = beforeOrAt.SecondsPerLiquidityCumulativeX128
+	case atOrAfter.BlockTimestamp:
+		// we're at the right boundary
+		*tickCumulative = atOrAfter.TickCumulative
+		*secondsPerLiquidityCumulativeX128 = atOrAfter.SecondsPerLiquidityCumulativeX128
+	default:
+		// we're in the middle
+		observationTimeDelta := atOrAfter.BlockTimestamp - beforeOrAt.BlockTimestamp
+		targetDelta := target - beforeOrAt.BlockTimestamp
+		*tickCumulative = beforeOrAt.TickCumulative +
+			(atOrAfter.TickCumulative-beforeOrAt.TickCumulative)/int64(observationTimeDelta)*int64(targetDelta)
+
+		var delta, targetDeltaX utils.Uint256
+		delta.Sub(&atOrAfter.SecondsPerLiquidityCumulativeX128, &beforeOrAt.SecondsPerLiquidityCumulativeX128)
+		truncateUint160(&delta)
+		delta.Mul(&delta, targetDeltaX.SetUint64(uint64(targetDelta)))
+		delta.Div(&delta, targetDeltaX.SetUint64(uint64(observationTimeDelta)))
+		secondsPerLiquidityCumulativeX128.Add(&beforeOrAt.SecondsPerLiquidityCumulativeX128, &delta)
+		truncateUint160(secondsPerLiquidityCumulativeX128)
+	}
+	return nil
+}
+
+// getSurroundingObservations — аналог Oracle.getSurroundingObservations: observations, между
+// которыми лежит target. Если target новее последней записи, atOrAfter — её проекция на target.
+func (p *Pool) getSurroundingObservations(time, target uint32, beforeOrAt, atOrAfter *Observation) error {
+	// optimistically set before to the newest observation
+	*beforeOrAt = p.Observations[p.ObservationIndex]
+
+	// if the target is chronologically at or after the newest observation, we can early return
+	if observationLte(time, beforeOrAt.BlockTimestamp, target) {
+		if beforeOrAt.BlockTimestamp == target {
+			// if newest observation equals target, we're in the same block, so we can ignore atOrAfter
+			return nil
+		}
+		// otherwise, we need to transform
+		transformObservation(beforeOrAt, target, p.TickCurrent, p.Liquidity, atOrAfter)
+		return nil
+	}
+
+	// now, set before to the oldest observation
+	*beforeOrAt = p.Observations[(uint32(p.ObservationIndex)+1)%uint32(p.ObservationCardinality)]
+	if !beforeOrAt.Initialized {
+		*beforeOrAt = p.Observations[0]
+	}
+
+	// ensure that the target is chronologically at or after the oldest observation
+	if !observationLte(time, beforeOrAt.BlockTimestamp, target) {
+		return ErrObservationTooOld
+	}
+
+	// if we've reached this point, we have to binary search
+	p.observationBinarySearch(time, target, beforeOrAt, atOrAfter)
+	return nil
+}
+
+// observationBinarySearch — аналог Oracle.binarySearch; вызывается, только когда target
+// гарантированно лежит внутри кольца.
+func (p *Pool) observationBinarySearch(time, target uint32, beforeOrAt, atOrAfter *Observation) {
+	cardinality := uint32(p.ObservationCardinality)
+	l := (uint32(p.ObservationIndex) + 1) % cardinality // oldest observation
+	r := l + cardinality - 1                            // newest observation
+
+	for {
+		i := (l + r) / 2
+		*beforeOrAt = p.Observations[i%cardinality]
+
+		// we've landed on an uninitialized tick, keep searching higher (more recently)
+		if !beforeOrAt.Initialized {
+			l = i + 1
+			continue
+		}
+
+		*atOrAfter = p.Observations[(i+1)%cardinality]
+
+		targetAtOrAfter := observationLte(time, beforeOrAt.BlockTimestamp, target)
+
+		// check if we've found the answer!
+		if targetAtOrAfter && observationLte(time, target, atOrAfter.BlockTimestamp) {
+			return
+		}
+
+		if !targetAtOrAfter {
+			r = i - 1
+		} else {
+			l = i + 1
+		}
+	}
+}
+
+// observationLte — аналог Oracle.lte: сравнение 32-битных timestamp'ов с учётом переполнения,
+// time — текущее время, a и b лежат не позже него.
+func observationLte(time, a, b uint32) bool {
+	// if there hasn't been overflow, no need to adjust
+	if a <= time && b <= time {
+		return a <= b
+	}
+
+	aAdjusted, bAdjusted := uint64(a), uint64(b)
+	if a <= time {
+		aAdjusted += 1 << 32
+	}
+	if b <= time {
+		bAdjusted += 1 << 32
+	}
+	return aAdjusted <= bAdjusted
+}
+
+// truncateUint160 — x по модулю 2^160, как переполнение uint160 в solidity 0.7.
+func truncateUint160(x *utils.Uint160) {
+	x[2] &= 0xffffffff
+	x[3] = 0
+}
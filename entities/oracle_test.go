@@ -0,0 +1,153 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// secondsPerLiquidityX128 — (seconds << 128) / liquidity, как Oracle.transform.
+func secondsPerLiquidityX128(seconds uint64, liquidity *uint256.Int) *uint256.Int {
+	x := new(uint256.Int).Lsh(uint256.NewInt(seconds), 128)
+	return x.Div(x, liquidity)
+}
+
+func TestOracle_ObserveWithoutWrites(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 50, L)
+
+	tickCumulatives := make([]int64, 1)
+	secondsPerLiquidity := make([]utils.Uint160, 1)
+	assert.ErrorIs(t, pool.Observe([]uint32{0}, tickCumulatives, secondsPerLiquidity), ErrOracleNotInitialized)
+
+	assert.NoError(t, pool.InitializeOracle(100))
+	assert.ErrorIs(t, pool.InitializeOracle(100), ErrOracleAlreadyInit)
+
+	// между записями значения экстраполируются по текущим тику и ликвидности
+	pool.BlockTimestamp = 110
+	tickCumulatives = make([]int64, 3)
+	secondsPerLiquidity = make([]utils.Uint160, 3)
+	assert.NoError(t, pool.Observe([]uint32{0, 4, 10}, tickCumulatives, secondsPerLiquidity))
+	assert.Equal(t, []int64{500, 300, 0}, tickCumulatives)
+	assert.True(t, secondsPerLiquidity[0].Eq(secondsPerLiquidityX128(10, L)))
+	assert.True(t, secondsPerLiquidity[1].Eq(secondsPerLiquidityX128(6, L)))
+	assert.True(t, secondsPerLiquidity[2].IsZero())
+
+	assert.ErrorIs(t, pool.Observe([]uint32{11}, tickCumulatives[:1], secondsPerLiquidity[:1]), ErrObservationTooOld)
+	assert.ErrorIs(t, pool.Observe([]uint32{0}, tickCumulatives, secondsPerLiquidity[:1]), ErrObserveLengthMismatch)
+}
+
+// TestOracle_GrowOverStaleSlots: кольцо длиннее ObservationCardinalityNext (как после Snapshot.Restore) —
+// grow помечает существующие слоты по индексу, а не дописывает новые в конец.
+func TestOracle_GrowOverStaleSlots(t *testing.T) {
+	pool := newBoundedTestPool(-100, 100, 50, uint256.NewInt(1e18))
+	assert.NoError(t, pool.InitializeOracle(100))
+	pool.Observations = append(pool.Observations, make([]Observation, 3)...)
+
+	assert.NoError(t, pool.IncreaseObservationCardinalityNext(3))
+	assert.Len(t, pool.Observations, 4)
+	assert.Equal(t, uint32(1), pool.Observations[1].BlockTimestamp)
+	assert.Equal(t, uint32(1), pool.Observations[2].BlockTimestamp)
+	assert.Equal(t, uint32(0), pool.Observations[3].BlockTimestamp)
+
+	assert.NoError(t, pool.IncreaseObservationCardinalityNext(6))
+	assert.Len(t, pool.Observations, 6)
+	for i := 1; i < 6; i++ {
+		assert.Equal(t, uint32(1), pool.Observations[i].BlockTimestamp, "слот %d", i)
+		assert.False(t, pool.Observations[i].Initialized)
+	}
+}
+
+// TestOracle_SwapWritesAndInterpolates: свап, меняющий тик, пишет observation с тиком и ликвидностью
+// на начало свапа; Observe между записями интерполирует, как OracleLibrary.consult считает TWAP.
+func TestOracle_SwapWritesAndInterpolates(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 50, L)
+	assert.NoError(t, pool.InitializeOracle(100))
+	assert.NoError(t, pool.IncreaseObservationCardinalityNext(4))
+	assert.Equal(t, uint16(1), pool.ObservationCardinality, "кольцо растёт только при записи")
+
+	var limit utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(-20, &limit)
+	amountIn, _ := int256.FromDec("1000000000000000000000000")
+
+	pool.BlockTimestamp = 110
+	assert.NoError(t, pool.SwapCommit(true, amountIn, &limit, &SwapResultV2{}, nil))
+	assert.Equal(t, uint16(1), pool.ObservationIndex)
+	assert.Equal(t, uint16(4), pool.ObservationCardinality)
+	assert.Equal(t, int64(500), pool.Observations[1].TickCumulative, "записан тик на начало свапа")
+
+	// второй свап в том же блоке не пишет
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(-40, &limit)
+	assert.NoError(t, pool.SwapCommit(true, amountIn, &limit, &SwapResultV2{}, nil))
+	assert.Equal(t, uint16(1), pool.ObservationIndex)
+	tick := int64(pool.TickCurrent)
+
+	pool.BlockTimestamp = 130
+	tickCumulatives := make([]int64, 3)
+	secondsPerLiquidity := make([]utils.Uint160, 3)
+	assert.NoError(t, pool.Observe([]uint32{0, 10, 30}, tickCumulatives, secondsPerLiquidity))
+	assert.Equal(t, []int64{500 + tick*20, 500 + tick*10, 0}, tickCumulatives)
+
+	// интерполяция между двумя записанными observations (t=110 и t=120)
+	pool.BlockTimestamp = 120
+	assert.NoError(t, pool.SwapCommit(false, amountIn, nil, &SwapResultV2{}, nil))
+	assert.Equal(t, uint16(2), pool.ObservationIndex)
+	pool.BlockTimestamp = 130
+	assert.NoError(t, pool.Observe([]uint32{15}, tickCumulatives[:1], secondsPerLiquidity[:1]))
+	assert.Equal(t, 500+(tick*10)/10*5, tickCumulatives[0])
+
+	perTen := secondsPerLiquidityX128(10, L)
+	want := new(uint256.Int).Add(perTen, new(uint256.Int).Div(new(uint256.Int).Mul(perTen, uint256.NewInt(5)), uint256.NewInt(10)))
+	assert.True(t, secondsPerLiquidity[0].Eq(want), "got %s want %s", secondsPerLiquidity[0].Dec(), want.Dec())
+}
+
+func TestOracle_RollbackRestoresObservation(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 50, L)
+	assert.NoError(t, pool.InitializeOracle(100))
+	assert.NoError(t, pool.IncreaseObservationCardinalityNext(2))
+
+	var cp PoolCheckpoint
+	pool.BlockTimestamp = 110
+	assert.NoError(t, pool.SwapCommit(true, int256.NewInt(1e15), nil, &SwapResultV2{}, &cp))
+	assert.Equal(t, uint16(1), pool.ObservationIndex)
+
+	assert.NoError(t, pool.Rollback(&cp))
+	assert.Equal(t, uint16(0), pool.ObservationIndex)
+	assert.Equal(t, uint16(1), pool.ObservationCardinality)
+	assert.False(t, pool.Observations[1].Initialized)
+	assert.Equal(t, uint32(1), pool.Observations[1].BlockTimestamp)
+}
+
+// TestOracle_MintInRangeWrites: изменение in-range ликвидности пишет observation по старой ликвидности.
+func TestOracle_MintInRangeWrites(t *testing.T) {
+	pool := newEmptyTestPool(0)
+	assert.NoError(t, pool.InitializeOracle(100))
+	assert.NoError(t, pool.IncreaseObservationCardinalityNext(2))
+
+	L := uint256.NewInt(1e18)
+	var amount0, amount1 utils.Uint256
+	pool.BlockTimestamp = 110
+	assert.NoError(t, pool.Mint(alice, -100, 100, L, &amount0, &amount1))
+	assert.Equal(t, uint16(1), pool.ObservationIndex)
+	// до mint ликвидность была 0 — делим на 1
+	assert.True(t, pool.Observations[1].SecondsPerLiquidityCumulativeX128.Eq(secondsPerLiquidityX128(10, uint256.NewInt(1))))
+
+	// mint вне диапазона оракул не трогает
+	pool.BlockTimestamp = 120
+	assert.NoError(t, pool.Mint(alice, 200, 300, L, &amount0, &amount1))
+	assert.Equal(t, uint16(1), pool.ObservationIndex)
+}
+
+func TestObservationLte(t *testing.T) {
+	assert.True(t, observationLte(100, 50, 60))
+	assert.False(t, observationLte(100, 60, 50))
+	// a записан до переполнения uint32, b — после
+	assert.True(t, observationLte(10, 0xfffffff0, 5))
+	assert.False(t, observationLte(10, 5, 0xfffffff0))
+}
@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"math/big"
 
-	"github.com/vuquang23/int256"
 	"github.com/daoleno/uniswap-sdk-core/entities"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/holiman/uint256"
+	"github.com/vuquang23/int256"
 
 	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
 	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
@@ -22,8 +22,11 @@ var (
 	ErrTokenNotInvolved         = errors.New("token not involved in pool")
 	ErrSqrtPriceLimitX96TooLow  = errors.New("SqrtPriceLimitX96 too low")
 	ErrSqrtPriceLimitX96TooHigh = errors.New("SqrtPriceLimitX96 too high")
+	ErrTicksReadOnly            = errors.New("tick data provider does not support mutations")
 	ErrMaxCrossInitTickLoops    = fmt.Errorf("max cross init tick loops %d reached", MAX_CROSS_INIT_TICK_LOOPS)
 
+	q128 = new(uint256.Int).Lsh(uint256.NewInt(1), 128)
+
 	sqrtPriceLimitX96Upper = new(uint256.Int).AddUint64(utils.MinSqrtRatioU256, 1)
 	sqrtPriceLimitX96Lower = new(uint256.Int).SubUint64(utils.MaxSqrtRatioU256, 1)
 )
@@ -36,6 +39,8 @@ type StepComputations struct {
 	amountIn          utils.Uint256
 	amountOut         utils.Uint256
 	feeAmount         utils.Uint256
+	lpFee             utils.Uint256
+	protocolFee       utils.Uint256
 }
 
 // Represents a V3 pool
@@ -48,35 +53,57 @@ type Pool struct {
 	Liquidity        *utils.Uint128
 	TickCurrent      int32
 	TickSpacing      uint16
-	TickDataProvider *TicksHandler
+	TickDataProvider TickDataProvider
+
+	// TickBitmapStepping включает пошаговую совместимость с tickBitmap контракта: шаг свапа
+	// заканчивается на границе 256-битного слова, даже если инициализированного тика там нет.
+	// Без него Swap прыгает сразу к следующему инициализированному тику — быстрее, но в
+	// разреженных пулах округление по шагам может расходиться с контрактом на единицы wei.
+	TickBitmapStepping bool
+
+	// накопленные комиссии на единицу ликвидности (Q128.128), как feeGrowthGlobal{0,1}X128 контракта.
+	// Продвигаются только committing-свапом (SwapCommit); read-only Swap их не трогает.
+	FeeGrowthGlobal0X128 utils.Uint256
+	FeeGrowthGlobal1X128 utils.Uint256
+
+	// доля протокола в комиссиях: 1/FeeProtocolN комиссии токена N (0 — выключено), как slot0.feeProtocol.
+	// Накопленное — в ProtocolFeesN (protocolFees контракта), продвигается только SwapCommit.
+	FeeProtocol0  uint8
+	FeeProtocol1  uint8
+	ProtocolFees0 utils.Uint128
+	ProtocolFees1 utils.Uint128
+
+	// оракул (observations контракта); пишется только после InitializeOracle.
+	// BlockTimestamp — время текущего блока, задаётся вызывающим перед SwapCommit/Mint/Burn/Observe.
+	BlockTimestamp             uint32
+	Observations               []Observation
+	ObservationIndex           uint16
+	ObservationCardinality     uint16
+	ObservationCardinalityNext uint16
+
+	// позиции пула (positions контракта), ведутся Mint/Burn/Collect; создаётся лениво.
+	Positions map[PositionKey]*PositionInfo
 
 	// calculators
 	TickCalculator      *utils.TickCalculator
 	LiquidityCalculator *utils.MaxLiquidityForAmountsCalculator
 	SqrtPriceCalculator *utils.SqrtPriceCalculator
 	IntTypes            *utils.IntTypes
-	swapStepCalculator  *utils.SwapStepCalculator
+	fullMath            *utils.FullMath
 
 	// cache
 	token0Price *entities.Price
 	token1Price *entities.Price
 
-	// tmp vars
-	lastState             State
-	liquidityNet          *utils.Int128
-	amountInPlusFee       *utils.Uint256
-	amountInPlusFeeSigned *utils.Int256
-	nxtSqrtPriceX96       *utils.Uint160
-	targetValue           *utils.Uint160
-	amountOutSigned       *utils.Int256
-	step                  StepComputations
-	tmpUint256            *uint256.Int
-	tmpUint256_1          *uint256.Int
-	tmpUint256_2          *uint256.Int
-	tmpUint256_3          *uint256.Int
-	tmpUint256_4          *uint256.Int
-	tmpUint256_5          *uint256.Int
-	tmpUint256_6          *uint256.Int
+	// scratch Swap/SwapCommit; создаётся лениво (см. swapContext)
+	swapCtx *SwapContext
+}
+
+// tickCrossing — отложенный Tick.cross: глобальные fee growth на момент пересечения тика.
+type tickCrossing struct {
+	tick                 int32
+	feeGrowthGlobal0X128 utils.Uint256
+	feeGrowthGlobal1X128 utils.Uint256
 }
 
 type SwapResult struct {
@@ -111,7 +138,7 @@ func GetAddress(tokenA, tokenB *entities.Token, fee constants.FeeAmount,
 
 // deprecated
 func NewPool(tokenA, tokenB *entities.Token, fee constants.FeeAmount, sqrtRatioX96 *big.Int, liquidity *big.Int,
-	tickCurrent int32, ticks *TicksHandler) (*Pool, error) {
+	tickCurrent int32, ticks TickDataProvider) (*Pool, error) {
 	return NewPoolV2(
 		tokenA, tokenB, fee,
 		uint256.MustFromBig(sqrtRatioX96),
@@ -132,7 +159,7 @@ func NewPool(tokenA, tokenB *entities.Token, fee constants.FeeAmount, sqrtRatioX
  * @param ticks The current state of the pool ticks or a data provider that can return tick data
  */
 func NewPoolV2(tokenA, tokenB *entities.Token, fee constants.FeeAmount, sqrtRatioX96 *utils.Uint160,
-	liquidity *utils.Uint128, tickCurrent int32, ticks *TicksHandler) (*Pool, error) {
+	liquidity *utils.Uint128, tickCurrent int32, ticks TickDataProvider) (*Pool, error) {
 	if fee >= constants.FeeMax {
 		return nil, ErrFeeTooHigh
 	}
@@ -158,13 +185,19 @@ func NewPoolV2(tokenA, tokenB *entities.Token, fee constants.FeeAmount, sqrtRati
 	}
 
 	return &Pool{
-		Token0:           token0,
-		Token1:           token1,
-		Fee:              fee,
-		SqrtRatioX96:     sqrtRatioX96,
-		Liquidity:        liquidity,
-		TickCurrent:      tickCurrent,
-		TickDataProvider: ticks,
+		Token0:              token0,
+		Token1:              token1,
+		Fee:                 fee,
+		TickSpacing:         constants.TickSpacings[fee],
+		SqrtRatioX96:        sqrtRatioX96,
+		Liquidity:           liquidity,
+		TickCurrent:         tickCurrent,
+		TickDataProvider:    nilIfNilHandler(ticks),
+		fullMath:            utils.NewFullMath(),
+		TickCalculator:      tickCalculator,
+		SqrtPriceCalculator: utils.NewSqrtPriceCalculator(),
+		LiquidityCalculator: utils.NewMaxLiquidityForAmountsCalculator(),
+		IntTypes:            utils.NewIntTypes(),
 	}, nil
 }
 
@@ -174,48 +207,41 @@ func NewPoolV3(
 	initTick int32,
 	initSqrtPriceX96 *utils.Uint160,
 	token0, token1 *entities.Token,
-	ticksHandler *TicksHandler,
+	ticksHandler TickDataProvider,
 ) *Pool {
 	return &Pool{
-		Address:          address,
-		Fee:              constants.FeeAmount(fee),
-		TickSpacing:      constants.TickSpacings[constants.FeeAmount(fee)],
-		TickDataProvider: ticksHandler,
-		TickCurrent:      initTick,
-		SqrtRatioX96:     initSqrtPriceX96.Clone(),
-		Liquidity:        new(utils.Uint128),
-		Token0:           token0,
-		Token1:           token1,
-		lastState: State{
-			amountSpecifiedRemaining: new(utils.Int256),
-			amountCalculated:         new(utils.Int256),
-			sqrtPriceX96:             new(utils.Uint160),
-			liquidity:                new(utils.Uint128),
-		},
-		step: StepComputations{
-			// sqrtPriceNextX96: new(utils.Uint160),
-		},
-		liquidityNet:        new(utils.Int128),
-		swapStepCalculator:  utils.NewSwapStepCalculator(),
+		Address:             address,
+		Fee:                 constants.FeeAmount(fee),
+		TickSpacing:         constants.TickSpacings[constants.FeeAmount(fee)],
+		TickDataProvider:    nilIfNilHandler(ticksHandler),
+		TickCurrent:         initTick,
+		SqrtRatioX96:        initSqrtPriceX96.Clone(),
+		Liquidity:           new(utils.Uint128),
+		Token0:              token0,
+		Token1:              token1,
+		fullMath:            utils.NewFullMath(),
 		TickCalculator:      utils.NewTickCalculator(),
 		SqrtPriceCalculator: utils.NewSqrtPriceCalculator(),
 		LiquidityCalculator: utils.NewMaxLiquidityForAmountsCalculator(),
 		IntTypes:            utils.NewIntTypes(),
+	}
+}
 
-		amountInPlusFee:       new(utils.Uint256),
-		amountInPlusFeeSigned: new(utils.Int256),
-		nxtSqrtPriceX96:       new(utils.Uint160),
-		targetValue:           new(utils.Uint160),
-		amountOutSigned:       new(utils.Int256),
+// nilIfNilHandler: nil *TicksHandler, переданный как TickDataProvider, даёт ненулевой интерфейс,
+// и проверка p.TickDataProvider == nil перестала бы срабатывать.
+func nilIfNilHandler(ticks TickDataProvider) TickDataProvider {
+	if h, ok := ticks.(*TicksHandler); ok && h == nil {
+		return nil
+	}
+	return ticks
+}
 
-		tmpUint256:   new(uint256.Int),
-		tmpUint256_1: new(uint256.Int),
-		tmpUint256_2: new(uint256.Int),
-		tmpUint256_3: new(uint256.Int),
-		tmpUint256_4: new(uint256.Int),
-		tmpUint256_5: new(uint256.Int),
-		tmpUint256_6: new(uint256.Int),
+// tickMutator — хуки изменения тиков провайдера или ErrTicksReadOnly.
+func (p *Pool) tickMutator() (TickMutator, error) {
+	if mutator, ok := p.TickDataProvider.(TickMutator); ok {
+		return mutator, nil
 	}
+	return nil, ErrTicksReadOnly
 }
 
 /**
@@ -298,34 +324,50 @@ func (p *Pool) GetOutputAmount(inputAmount *entities.CurrencyAmount,
 	} else {
 		outputToken = p.Token0
 	}
-	// pool, err := NewPoolV2(
-	// 	p.Token0,
-	// 	p.Token1,
-	// 	p.Fee,
-	// 	swapResult.SqrtRatioX96,
-	// 	swapResult.Liquidity,
-	// 	swapResult.CurrentTick,
-	// 	p.TickDataProvider,
-	// )
+	pool := p.postSwapPool(swapResult)
+	return &GetAmountResult{
+		ReturnedAmount:     entities.FromRawAmount(outputToken, new(utils.Int256).Neg(swapResult.AmountCalculated).ToBig()),
+		RemainingAmountIn:  entities.FromRawAmount(inputAmount.Currency, swapResult.RemainingAmountIn.ToBig()),
+		NewPoolState:       pool,
+		CrossInitTickLoops: swapResult.CrossInitTickLoops,
+	}, nil
+}
 
+// postSwapPool — пул в состоянии после read-only свапа: цена, ликвидность и тик из swapResult.
+// Тики *TicksHandler берутся через copy-on-write TicksOverlay (overlay — через Clone), поэтому
+// SwapCommit/Mint/Burn на снимке тики p не меняют; read-only провайдеры остаются общими.
+// Fee growth и protocol fees копируются как есть — продвигает их только SwapCommit; оракул
+// и позиции в снимок не переносятся.
+func (p *Pool) postSwapPool(swapResult *SwapResultV2) *Pool {
+	ticks := p.TickDataProvider
+	switch t := ticks.(type) {
+	case *TicksHandler:
+		if t != nil {
+			ticks = NewTicksOverlay(t)
+		}
+	case *TicksOverlay:
+		ticks = t.Clone()
+	}
 	pool := NewPoolV3(
 		p.Address,
 		uint16(p.Fee),
-		p.TickCurrent,
-		p.SqrtRatioX96,
+		swapResult.CurrentTick,
+		swapResult.SqrtRatioX96,
 		p.Token0,
 		p.Token1,
-		p.TickDataProvider,
+		ticks,
 	)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	return &GetAmountResult{
-		ReturnedAmount:     entities.FromRawAmount(outputToken, new(utils.Int256).Neg(swapResult.AmountCalculated).ToBig()),
-		RemainingAmountIn:  entities.FromRawAmount(inputAmount.Currency, swapResult.RemainingAmountIn.ToBig()),
-		NewPoolState:       pool,
-		CrossInitTickLoops: swapResult.CrossInitTickLoops,
-	}, nil
+	pool.TickSpacing = p.TickSpacing
+	pool.Liquidity = swapResult.Liquidity.Clone()
+	pool.TickBitmapStepping = p.TickBitmapStepping
+	pool.FeeGrowthGlobal0X128 = p.FeeGrowthGlobal0X128
+	pool.FeeGrowthGlobal1X128 = p.FeeGrowthGlobal1X128
+	pool.FeeProtocol0 = p.FeeProtocol0
+	pool.FeeProtocol1 = p.FeeProtocol1
+	pool.ProtocolFees0 = p.ProtocolFees0
+	pool.ProtocolFees1 = p.ProtocolFees1
+	pool.BlockTimestamp = p.BlockTimestamp
+	return pool
 }
 
 func (p *Pool) GetOutputAmountV2(inputAmount *utils.Int256, zeroForOne bool,
@@ -374,28 +416,7 @@ func (p *Pool) GetInputAmount(outputAmount *entities.CurrencyAmount,
 	} else {
 		inputToken = p.Token1
 	}
-	// pool, err := NewPoolV3(
-	// 	p.Token0,
-	// 	p.Token1,
-	// 	p.Fee,
-	// 	swapResult.sqrtRatioX96,
-	// 	swapResult.liquidity,
-	// 	swapResult.currentTick,
-	// 	p.TickDataProvider,
-	// )
-
-	pool := NewPoolV3(
-		p.Address,
-		uint16(p.Fee),
-		p.TickCurrent,
-		p.SqrtRatioX96,
-		p.Token0,
-		p.Token1,
-		p.TickDataProvider,
-	)
-	// if err != nil {
-	// 	return nil, nil, err
-	// }
+	pool := p.postSwapPool(swapResult)
 	return entities.FromRawAmount(inputToken, swapResult.AmountCalculated.ToBig()), pool, nil
 }
 
@@ -572,15 +593,19 @@ func (p *Pool) swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLim
 }
 
 type StepFeeResult struct {
-	ZeroForOne         bool
-	Tick               int32
+	ZeroForOne bool
+	Tick       int32
+	// FeeAmount — вся комиссия шага; она делится на LPFeeAmount и ProtocolFeeAmount (fee / feeProtocol).
 	FeeAmount          utils.Uint256
+	LPFeeAmount        utils.Uint256
+	ProtocolFeeAmount  utils.Uint256
 	Liquidity          utils.Uint128
 	OurLiquidityInTick utils.Uint128
 }
 
 // FeeStepFunc — callback для обработки fee-шагов inline во время Swap.
 // Если задан в SwapResultV2.FeeStepCallback, вызывается вместо append в StepsFee.
+// feeAmount — вся комиссия шага, как StepFeeResult.FeeAmount.
 type FeeStepFunc func(tick int32, feeAmount *utils.Uint256, zeroForOne bool, liquidity *utils.Uint128)
 
 type SwapResultV2 struct {
@@ -592,6 +617,14 @@ type SwapResultV2 struct {
 	CrossInitTickLoops int
 	StepsFee           []StepFeeResult
 	FeeStepCallback    FeeStepFunc
+	// ProtocolFee — суммарная доля протокола за свап во входном токене.
+	ProtocolFee utils.Uint256
+
+	// трассировка шагов (см. SwapStepTrace), по умолчанию выключена: при CollectTrace шаги
+	// копятся в Trace (буфер переиспользуется), StepTraceCallback вызывается на каждом шаге.
+	CollectTrace      bool
+	Trace             []SwapStepTrace
+	StepTraceCallback SwapStepTraceFunc
 }
 
 type State struct {
@@ -600,12 +633,222 @@ type State struct {
 	sqrtPriceX96             *utils.Uint160
 	tick                     int32
 	liquidity                *utils.Uint128
+	feeGrowthGlobalX128      utils.Uint256 // fee growth входного токена, считается только при commit
+	protocolFee              utils.Uint128 // доля протокола за свап во входном токене
+}
+
+// PoolCheckpoint — снимок изменяемого состояния пула, достаточный для отката SwapCommit.
+// Хранится по значению (без указателей), поэтому один и тот же checkpoint можно
+// переиспользовать между событиями без аллокаций.
+type PoolCheckpoint struct {
+	SqrtRatioX96         utils.Uint160
+	Liquidity            utils.Uint128
+	TickCurrent          int32
+	FeeGrowthGlobal0X128 utils.Uint256
+	FeeGrowthGlobal1X128 utils.Uint256
+	ProtocolFees0        utils.Uint128
+	ProtocolFees1        utils.Uint128
+
+	// оракул: индекс/cardinality и слот, который перезапишет следующая writeObservation
+	observationIndex       uint16
+	observationCardinality uint16
+	observation            Observation
+
+	// тики, пересечённые SwapCommit после снятия checkpoint'а; при Rollback их
+	// feeGrowthOutside переворачиваются обратно в обратном порядке.
+	crossings []tickCrossing
+}
+
+// Checkpoint сохраняет текущее состояние пула в cp.
+func (p *Pool) Checkpoint(cp *PoolCheckpoint) {
+	cp.SqrtRatioX96 = *p.SqrtRatioX96
+	cp.Liquidity = *p.Liquidity
+	cp.TickCurrent = p.TickCurrent
+	cp.FeeGrowthGlobal0X128 = p.FeeGrowthGlobal0X128
+	cp.FeeGrowthGlobal1X128 = p.FeeGrowthGlobal1X128
+	cp.ProtocolFees0 = p.ProtocolFees0
+	cp.ProtocolFees1 = p.ProtocolFees1
+	cp.observationIndex = p.ObservationIndex
+	cp.observationCardinality = p.ObservationCardinality
+	if p.ObservationCardinality != 0 {
+		cp.observation = p.Observations[p.nextObservationIndex()]
+	}
+	cp.crossings = cp.crossings[:0]
+}
+
+// Rollback возвращает пул в состояние, ранее сохранённое через Checkpoint (или SwapCommit).
+// feeGrowthOutside тиков восстанавливаются только для пересечений, записанных SwapCommit в этот
+// checkpoint, поэтому откатывать нужно сразу, до Mint/Burn по тем же тикам. По той же причине
+// восстанавливается только одна (последняя) запись оракула.
+func (p *Pool) Rollback(cp *PoolCheckpoint) error {
+	if len(cp.crossings) != 0 {
+		mutator, err := p.tickMutator()
+		if err != nil {
+			return err
+		}
+		for i := len(cp.crossings) - 1; i >= 0; i-- {
+			c := &cp.crossings[i]
+			if err := mutator.CrossTick(c.tick, &c.feeGrowthGlobal0X128, &c.feeGrowthGlobal1X128); err != nil {
+				return err
+			}
+		}
+	}
+	cp.crossings = cp.crossings[:0]
+
+	p.setState(&cp.SqrtRatioX96, &cp.Liquidity, cp.TickCurrent)
+	p.FeeGrowthGlobal0X128 = cp.FeeGrowthGlobal0X128
+	p.FeeGrowthGlobal1X128 = cp.FeeGrowthGlobal1X128
+	p.ProtocolFees0 = cp.ProtocolFees0
+	p.ProtocolFees1 = cp.ProtocolFees1
+	if cp.observationCardinality != 0 {
+		p.ObservationIndex = cp.observationIndex
+		p.ObservationCardinality = cp.observationCardinality
+		p.Observations[p.nextObservationIndex()] = cp.observation
+	}
+	return nil
+}
+
+// SwapCommit — committing-вариант Swap: считает свап так же, как Swap, и в случае успеха
+// атомарно переносит итоговые цену, тик и in-range ликвидность в сам пул.
+// Дополнительно продвигает FeeGrowthGlobal{0,1}X128 и переворачивает feeGrowthOutside
+// пересечённых инициализированных тиков — ровно как UniswapV3Pool.swap.
+// При ошибке состояние пула и тиков не меняется.
+// Если rollback != nil, в него сохраняется состояние пула до свапа (см. Rollback).
+func (p *Pool) SwapCommit(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLimitX96 *utils.Uint160, swapResult *SwapResultV2, rollback *PoolCheckpoint) error {
+	if rollback != nil {
+		p.Checkpoint(rollback)
+	}
+
+	ctx := p.swapContext()
+	if err := p.execSwap(ctx, zeroForOne, amountSpecified, sqrtPriceLimitX96, swapResult, true); err != nil {
+		return err
+	}
+
+	if len(ctx.crossings) != 0 {
+		mutator, err := p.tickMutator()
+		if err != nil {
+			return err
+		}
+		for i := range ctx.crossings {
+			c := &ctx.crossings[i]
+			if err := mutator.CrossTick(c.tick, &c.feeGrowthGlobal0X128, &c.feeGrowthGlobal1X128); err != nil {
+				// откатываем уже перевёрнутые тики, чтобы не оставить пул в промежуточном состоянии
+				for j := i - 1; j >= 0; j-- {
+					mutator.CrossTick(ctx.crossings[j].tick, &ctx.crossings[j].feeGrowthGlobal0X128, &ctx.crossings[j].feeGrowthGlobal1X128)
+				}
+				return err
+			}
+		}
+	}
+	if rollback != nil {
+		rollback.crossings = append(rollback.crossings, ctx.crossings...)
+	}
+
+	// update tick and write an oracle entry if the tick change
+	state := &ctx.state
+	if state.tick != p.TickCurrent {
+		p.writeObservation(p.TickCurrent, p.Liquidity)
+	}
+	if zeroForOne {
+		p.FeeGrowthGlobal0X128 = state.feeGrowthGlobalX128
+		addUint128(&p.ProtocolFees0, &state.protocolFee)
+	} else {
+		p.FeeGrowthGlobal1X128 = state.feeGrowthGlobalX128
+		addUint128(&p.ProtocolFees1, &state.protocolFee)
+	}
+	p.setState(state.sqrtPriceX96, state.liquidity, state.tick)
+	return nil
+}
+
+// GetFeeGrowthInside — аналог Tick.getFeeGrowthInside контракта: fee growth на единицу
+// ликвидности внутри диапазона [tickLower, tickUpper) при текущих тике и глобальных значениях пула.
+// Отсутствующий в TickDataProvider тик трактуется как неинициализированный (outside = 0),
+// как и чтение пустого storage-слота в контракте. Вся арифметика — по модулю 2^256.
+func (p *Pool) GetFeeGrowthInside(tickLower, tickUpper int32, feeGrowthInside0X128, feeGrowthInside1X128 *utils.Uint256) {
+	var lower, upper Tick
+	if p.TickDataProvider != nil {
+		lower, _ = p.TickDataProvider.GetTick(tickLower)
+		upper, _ = p.TickDataProvider.GetTick(tickUpper)
+	}
+
+	// calculate fee growth below
+	var below0, below1 utils.Uint256
+	if p.TickCurrent >= tickLower {
+		below0 = lower.FeeGrowthOutside0X128
+		below1 = lower.FeeGrowthOutside1X128
+	} else {
+		below0.Sub(&p.FeeGrowthGlobal0X128, &lower.FeeGrowthOutside0X128)
+		below1.Sub(&p.FeeGrowthGlobal1X128, &lower.FeeGrowthOutside1X128)
+	}
+
+	// calculate fee growth above
+	var above0, above1 utils.Uint256
+	if p.TickCurrent < tickUpper {
+		above0 = upper.FeeGrowthOutside0X128
+		above1 = upper.FeeGrowthOutside1X128
+	} else {
+		above0.Sub(&p.FeeGrowthGlobal0X128, &upper.FeeGrowthOutside0X128)
+		above1.Sub(&p.FeeGrowthGlobal1X128, &upper.FeeGrowthOutside1X128)
+	}
+
+	feeGrowthInside0X128.Sub(&p.FeeGrowthGlobal0X128, &below0)
+	feeGrowthInside0X128.Sub(feeGrowthInside0X128, &above0)
+	feeGrowthInside1X128.Sub(&p.FeeGrowthGlobal1X128, &below1)
+	feeGrowthInside1X128.Sub(feeGrowthInside1X128, &above1)
+}
+
+// setState записывает цену, ликвидность и тик в пул и сбрасывает кэш цен токенов.
+// Значения копируются в уже существующие SqrtRatioX96/Liquidity, а не подменяют указатели:
+// swapResult ссылается на состояние SwapContext и не должен разделять с пулом память.
+func (p *Pool) setState(sqrtRatioX96 *utils.Uint160, liquidity *utils.Uint128, tick int32) {
+	if p.SqrtRatioX96 == nil {
+		p.SqrtRatioX96 = new(utils.Uint160)
+	}
+	if p.Liquidity == nil {
+		p.Liquidity = new(utils.Uint128)
+	}
+
+	*p.SqrtRatioX96 = *sqrtRatioX96
+	*p.Liquidity = *liquidity
+	p.TickCurrent = tick
+
+	p.token0Price = nil
+	p.token1Price = nil
 }
 
 // var swapResultTmp = new(SwapResultV2)
 
+// Swap — read-only расчёт свапа: состояние пула не меняется, итог пишется в swapResult.
+// Для продвижения состояния пула используйте SwapCommit.
+// Scratch берётся из собственного SwapContext пула, поэтому Swap нельзя вызывать из нескольких
+// горутин одновременно — для этого есть SwapWithContext.
 func (p *Pool) Swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLimitX96 *utils.Uint160, swapResult *SwapResultV2) error {
+	return p.execSwap(p.swapContext(), zeroForOne, amountSpecified, sqrtPriceLimitX96, swapResult, false)
+}
+
+// SwapWithContext — read-only Swap со scratch из ctx. Пул и его *TicksHandler при этом только читаются
+// (sequential hint поиска тиков хранится в ctx), поэтому один пул можно котировать из нескольких
+// горутин, каждая со своим SwapContext. Другие TickDataProvider должны сами допускать конкурентное
+// чтение (TicksOverlay и LazyTickProvider — нет). Менять пул (SwapCommit, Mint, Burn) во время таких
+// котировок нельзя. Указатели в swapResult ссылаются на ctx и валидны до следующего свапа с ним.
+func (p *Pool) SwapWithContext(ctx *SwapContext, zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLimitX96 *utils.Uint160, swapResult *SwapResultV2) error {
+	return p.execSwap(ctx, zeroForOne, amountSpecified, sqrtPriceLimitX96, swapResult, false)
+}
+
+// swapContext возвращает собственный SwapContext пула, создавая его при первом свапе.
+func (p *Pool) swapContext() *SwapContext {
+	if p.swapCtx == nil {
+		p.swapCtx = NewSwapContext()
+	}
+	return p.swapCtx
+}
+
+// execSwap — общий цикл свапа. При commit=true дополнительно считает fee growth входного
+// токена и копит пересечения тиков в ctx.crossings (см. SwapCommit); read-only путь
+// остаётся без лишней mulDiv на каждом шаге.
+func (p *Pool) execSwap(ctx *SwapContext, zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLimitX96 *utils.Uint160, swapResult *SwapResultV2, commit bool) error {
 	var err error
+	state, step := &ctx.state, &ctx.step
 
 	if sqrtPriceLimitX96 == nil {
 		if zeroForOne {
@@ -617,12 +860,27 @@ func (p *Pool) Swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLim
 
 	exactInput := amountSpecified.Sign() >= 0
 
+	feeProtocol := p.FeeProtocol1
+	if zeroForOne {
+		feeProtocol = p.FeeProtocol0
+	}
+
 	// keep track of swap state
-	p.lastState.amountSpecifiedRemaining.Set(amountSpecified)
-	p.lastState.amountCalculated.Clear()
-	p.lastState.sqrtPriceX96.Set(p.SqrtRatioX96)
-	p.lastState.tick = p.TickCurrent
-	p.lastState.liquidity.Set(p.Liquidity)
+	state.amountSpecifiedRemaining.Set(amountSpecified)
+	state.amountCalculated.Clear()
+	state.sqrtPriceX96.Set(p.SqrtRatioX96)
+	state.tick = p.TickCurrent
+	state.liquidity.Set(p.Liquidity)
+	state.protocolFee.Clear()
+	step.protocolFee.Clear()
+	if commit {
+		if zeroForOne {
+			state.feeGrowthGlobalX128 = p.FeeGrowthGlobal0X128
+		} else {
+			state.feeGrowthGlobalX128 = p.FeeGrowthGlobal1X128
+		}
+		ctx.crossings = ctx.crossings[:0]
+	}
 
 	// StepsFee buffer: семантика по правилу "nil = не собирать, slice = собирать".
 	// Когда задан FeeStepCallback, потребитель обрабатывает шаги inline и StepsFee
@@ -641,70 +899,144 @@ func (p *Pool) Swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLim
 	}
 	swapResult.CrossInitTickLoops = 0
 
+	tracing := swapResult.CollectTrace || swapResult.StepTraceCallback != nil
+	if swapResult.CollectTrace {
+		swapResult.Trace = swapResult.Trace[:0]
+	}
+
+	// fast path: *TicksHandler вызывается напрямую, без интерфейсной диспетчеризации на каждом шаге.
+	// Собственный контекст пула пишет sequential hint в сам хендлер, как NextInitializedTickIndex;
+	// внешний — в ctx, чтобы хендлер только читался (hint от прошлого свапа мог устареть после Mint/Burn).
+	handler, _ := p.TickDataProvider.(*TicksHandler)
+	if handler != nil {
+		ctx.ticks.h = handler
+		if ctx == p.swapCtx {
+			ctx.ticks.hint = &handler.lastResultIdx
+		} else {
+			ctx.tickHint = -1
+			ctx.ticks.hint = &ctx.tickHint
+		}
+	}
+
 	// start swap while loop
-	for !p.lastState.amountSpecifiedRemaining.IsZero() && !p.lastState.sqrtPriceX96.Eq(sqrtPriceLimitX96) {
-		p.step.sqrtPriceStartX96 = *p.lastState.sqrtPriceX96
+	for !state.amountSpecifiedRemaining.IsZero() && !state.sqrtPriceX96.Eq(sqrtPriceLimitX96) {
+		step.sqrtPriceStartX96 = *state.sqrtPriceX96
+		if tracing {
+			ctx.traceStep.ZeroForOne = zeroForOne
+			ctx.traceStep.SqrtPriceStartX96 = step.sqrtPriceStartX96
+			ctx.traceStep.TickStart = state.tick
+			ctx.traceStep.LiquidityBefore = *state.liquidity
+		}
 
 		// because each iteration of the while loop rounds, we can't optimize this code (relative to the smart contract)
 		// by simply traversing to the next available tick, we instead need to exactly replicate
 		// tickBitmap.nextInitializedTickWithinOneWord
-		if p.step.tickNext, p.step.initialized, err = p.TickDataProvider.NextInitializedTickIndex(p.lastState.tick, zeroForOne); err != nil {
-			// Свап вышел за крайний инициализированный тик — моделируем поведение
-			// реального bitmap-а: шаг до границы (Min/MaxTick) в пустой зоне.
-			// Если амаунт ещё не доел, цикл добьёт цену до sqrtPriceLimitX96 и выйдет.
-			if errors.Is(err, ErrAtOrAboveLargest) {
-				p.step.tickNext = utils.MaxTick
-				p.step.initialized = false
-			} else if errors.Is(err, ErrBelowSmallest) {
-				p.step.tickNext = utils.MinTick
-				p.step.initialized = false
+		if p.TickBitmapStepping {
+			if handler != nil {
+				step.tickNext, step.initialized, err = nextInitializedTickWithinOneWord(&ctx.ticks, state.tick, zeroForOne, int32(p.TickSpacing))
 			} else {
+				step.tickNext, step.initialized, err = nextInitializedTickWithinOneWord(p.TickDataProvider, state.tick, zeroForOne, int32(p.TickSpacing))
+			}
+			if err != nil {
 				return err
 			}
+
+			// ensure that we do not overshoot the min/max tick, as the tick bitmap is not aware of these bounds
+			if step.tickNext < utils.MinTick {
+				step.tickNext = utils.MinTick
+			} else if step.tickNext > utils.MaxTick {
+				step.tickNext = utils.MaxTick
+			}
+		} else {
+			if handler != nil {
+				step.tickNext, step.initialized, err = handler.nextInitializedTickIndex(state.tick, zeroForOne, ctx.ticks.hint)
+			} else {
+				step.tickNext, step.initialized, err = p.TickDataProvider.NextInitializedTickIndex(state.tick, zeroForOne)
+			}
+			if err != nil {
+				// Свап вышел за крайний инициализированный тик — моделируем поведение
+				// реального bitmap-а: шаг до границы (Min/MaxTick) в пустой зоне.
+				// Если амаунт ещё не доел, цикл добьёт цену до sqrtPriceLimitX96 и выйдет.
+				if errors.Is(err, ErrAtOrAboveLargest) {
+					step.tickNext = utils.MaxTick
+					step.initialized = false
+				} else if errors.Is(err, ErrBelowSmallest) {
+					step.tickNext = utils.MinTick
+					step.initialized = false
+				} else {
+					return err
+				}
+			}
 		}
 
-		p.TickCalculator.GetSqrtRatioAtTickV2(p.step.tickNext, &p.step.sqrtPriceNextX96)
+		p.TickCalculator.GetSqrtRatioAtTickV2(step.tickNext, &step.sqrtPriceNextX96)
 
 		// targetValue передаём указателем вместо 32-байтового memcopy:
 		// ComputeSwapStep читает sqrtRatioTargetX96 read-only.
 		var targetValue *utils.Uint160
-		if (zeroForOne && p.step.sqrtPriceNextX96.Lt(sqrtPriceLimitX96)) || (!zeroForOne && p.step.sqrtPriceNextX96.Gt(sqrtPriceLimitX96)) {
+		if (zeroForOne && step.sqrtPriceNextX96.Lt(sqrtPriceLimitX96)) || (!zeroForOne && step.sqrtPriceNextX96.Gt(sqrtPriceLimitX96)) {
 			targetValue = sqrtPriceLimitX96
 		} else {
-			targetValue = &p.step.sqrtPriceNextX96
+			targetValue = &step.sqrtPriceNextX96
 		}
 
-		p.swapStepCalculator.ComputeSwapStep(p.lastState.sqrtPriceX96, targetValue, p.lastState.liquidity, p.lastState.amountSpecifiedRemaining, uint64(p.Fee), p.nxtSqrtPriceX96, &p.step.amountIn, &p.step.amountOut, &p.step.feeAmount, zeroForOne, exactInput)
-		*p.lastState.sqrtPriceX96 = *p.nxtSqrtPriceX96
+		ctx.swapStepCalculator.ComputeSwapStep(state.sqrtPriceX96, targetValue, state.liquidity, state.amountSpecifiedRemaining, uint64(p.Fee), &ctx.nxtSqrtPriceX96, &step.amountIn, &step.amountOut, &step.feeAmount, zeroForOne, exactInput)
+		*state.sqrtPriceX96 = ctx.nxtSqrtPriceX96
 
-		p.amountInPlusFee.Add(&p.step.amountIn, &p.step.feeAmount)
+		ctx.amountInPlusFee.Add(&step.amountIn, &step.feeAmount)
 
 		if exactInput {
-			p.lastState.amountSpecifiedRemaining.Sub(p.lastState.amountSpecifiedRemaining, (*utils.Int256)(p.amountInPlusFee))
-			p.lastState.amountCalculated.Sub(p.lastState.amountCalculated, (*utils.Int256)(&p.step.amountOut))
+			state.amountSpecifiedRemaining.Sub(state.amountSpecifiedRemaining, (*utils.Int256)(&ctx.amountInPlusFee))
+			state.amountCalculated.Sub(state.amountCalculated, (*utils.Int256)(&step.amountOut))
 		} else {
-			p.lastState.amountSpecifiedRemaining.Add(p.lastState.amountSpecifiedRemaining, (*utils.Int256)(&p.step.amountOut))
-			p.lastState.amountCalculated.Add(p.lastState.amountCalculated, (*utils.Int256)(p.amountInPlusFee))
+			state.amountSpecifiedRemaining.Add(state.amountSpecifiedRemaining, (*utils.Int256)(&step.amountOut))
+			state.amountCalculated.Add(state.amountCalculated, (*utils.Int256)(&ctx.amountInPlusFee))
+		}
+
+		// if the protocol fee is on, calculate how much is owed, decrement feeAmount, and increment protocolFee
+		if feeProtocol > 0 {
+			step.protocolFee.Div(&step.feeAmount, step.protocolFee.SetUint64(uint64(feeProtocol)))
+			step.lpFee.Sub(&step.feeAmount, &step.protocolFee)
+			state.protocolFee.Add(&state.protocolFee, &step.protocolFee)
+		} else {
+			step.lpFee = step.feeAmount
+		}
+
+		// update global fee tracker
+		if commit && !state.liquidity.IsZero() {
+			if err = ctx.fullMath.MulDivV2(&step.lpFee, q128, state.liquidity, &ctx.feeGrowthDelta, nil); err != nil {
+				return err
+			}
+			state.feeGrowthGlobalX128.Add(&state.feeGrowthGlobalX128, &ctx.feeGrowthDelta)
 		}
 
 		// hasCallback закэширован выше — избегаем повторной проверки nil-функции
 		// в hot-loop (interface/func compare медленнее обычного bool).
 		if hasCallback {
-			swapResult.FeeStepCallback(p.lastState.tick, &p.step.feeAmount, zeroForOne, p.lastState.liquidity)
+			swapResult.FeeStepCallback(state.tick, &step.feeAmount, zeroForOne, state.liquidity)
 		} else if swapResult.StepsFee != nil {
 			swapResult.StepsFee = append(swapResult.StepsFee, StepFeeResult{
-				Tick:       p.lastState.tick,
-				FeeAmount:  p.step.feeAmount,
-				ZeroForOne: zeroForOne,
-				Liquidity:  *p.lastState.liquidity,
+				Tick:              state.tick,
+				FeeAmount:         step.feeAmount,
+				LPFeeAmount:       step.lpFee,
+				ProtocolFeeAmount: step.protocolFee,
+				ZeroForOne:        zeroForOne,
+				Liquidity:         *state.liquidity,
 			})
 		}
 
+		crossed := state.sqrtPriceX96.Eq(&step.sqrtPriceNextX96)
+
 		// TODO
-		if p.lastState.sqrtPriceX96.Eq(&p.step.sqrtPriceNextX96) {
+		if crossed {
 			// if the tick is initialized, run the tick transition
-			if p.step.initialized {
-				tick, err := p.TickDataProvider.GetTick(p.step.tickNext)
+			if step.initialized {
+				var tick Tick
+				if handler != nil {
+					tick, err = handler.getTick(step.tickNext, *ctx.ticks.hint)
+				} else {
+					tick, err = p.TickDataProvider.GetTick(step.tickNext)
+				}
 				if err != nil {
 					return err
 				}
@@ -712,11 +1044,21 @@ func (p *Pool) Swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLim
 				// if we're moving leftward, we interpret liquidityNet as the opposite sign
 				// safe because liquidityNet cannot be type(int128).min
 				if zeroForOne {
-					// p.liquidityNet.Neg(tick.LiquidityNet)
-					p.lastState.liquidity.Add(p.lastState.liquidity, (*utils.Uint128)(p.liquidityNet.Neg(tick.LiquidityNet)))
+					state.liquidity.Add(state.liquidity, (*utils.Uint128)(ctx.liquidityNet.Neg(tick.LiquidityNet)))
 				} else {
-					// p.liquidityNet = tick.LiquidityNet
-					p.lastState.liquidity.Add(p.lastState.liquidity, (*utils.Uint128)(tick.LiquidityNet))
+					state.liquidity.Add(state.liquidity, (*utils.Uint128)(tick.LiquidityNet))
+				}
+
+				if commit {
+					c := tickCrossing{tick: step.tickNext}
+					if zeroForOne {
+						c.feeGrowthGlobal0X128 = state.feeGrowthGlobalX128
+						c.feeGrowthGlobal1X128 = p.FeeGrowthGlobal1X128
+					} else {
+						c.feeGrowthGlobal0X128 = p.FeeGrowthGlobal0X128
+						c.feeGrowthGlobal1X128 = state.feeGrowthGlobalX128
+					}
+					ctx.crossings = append(ctx.crossings, c)
 				}
 
 				swapResult.CrossInitTickLoops++
@@ -726,23 +1068,45 @@ func (p *Pool) Swap(zeroForOne bool, amountSpecified *utils.Int256, sqrtPriceLim
 			}
 
 			if zeroForOne {
-				p.lastState.tick = p.step.tickNext - 1
+				state.tick = step.tickNext - 1
 			} else {
-				p.lastState.tick = p.step.tickNext
+				state.tick = step.tickNext
 			}
-		} else if !p.lastState.sqrtPriceX96.Eq(&p.step.sqrtPriceStartX96) {
+		} else if !state.sqrtPriceX96.Eq(&step.sqrtPriceStartX96) {
 			// recompute unless we're on a lower tick boundary (i.e. already transitioned ticks), and haven't moved
-			if p.lastState.tick, err = p.TickCalculator.GetTickAtSqrtRatioV2(p.lastState.sqrtPriceX96); err != nil {
+			if state.tick, err = p.TickCalculator.GetTickAtSqrtRatioV2(state.sqrtPriceX96); err != nil {
 				return err
 			}
 		}
+
+		if tracing {
+			ctx.traceStep.SqrtPriceNextX96 = step.sqrtPriceNextX96
+			ctx.traceStep.SqrtPriceEndX96 = *state.sqrtPriceX96
+			ctx.traceStep.TickNext = step.tickNext
+			ctx.traceStep.TickEnd = state.tick
+			ctx.traceStep.Initialized = step.initialized
+			ctx.traceStep.Crossed = crossed
+			ctx.traceStep.AmountIn = step.amountIn
+			ctx.traceStep.AmountOut = step.amountOut
+			ctx.traceStep.FeeAmount = step.feeAmount
+			ctx.traceStep.LPFeeAmount = step.lpFee
+			ctx.traceStep.ProtocolFeeAmount = step.protocolFee
+			ctx.traceStep.LiquidityAfter = *state.liquidity
+			if swapResult.StepTraceCallback != nil {
+				swapResult.StepTraceCallback(&ctx.traceStep)
+			}
+			if swapResult.CollectTrace {
+				swapResult.Trace = append(swapResult.Trace, ctx.traceStep)
+			}
+		}
 	}
 
-	swapResult.AmountCalculated = p.lastState.amountCalculated
-	swapResult.SqrtRatioX96 = p.lastState.sqrtPriceX96
-	swapResult.Liquidity = p.lastState.liquidity
-	swapResult.CurrentTick = p.lastState.tick
-	swapResult.RemainingAmountIn = p.lastState.amountSpecifiedRemaining
+	swapResult.AmountCalculated = state.amountCalculated
+	swapResult.SqrtRatioX96 = state.sqrtPriceX96
+	swapResult.Liquidity = state.liquidity
+	swapResult.CurrentTick = state.tick
+	swapResult.RemainingAmountIn = state.amountSpecifiedRemaining
+	swapResult.ProtocolFee = state.protocolFee
 
 	return nil
 }
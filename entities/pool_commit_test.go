@@ -0,0 +1,111 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestSwapCommit_AdvancesPoolState: после SwapCommit пул должен находиться ровно
+// в том состоянии, которое вернул свап, и следующий свап должен стартовать оттуда.
+func TestSwapCommit_AdvancesPoolState(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 0, L)
+
+	amountIn := int256.NewInt(1e15)
+
+	sr := &SwapResultV2{}
+	err := pool.SwapCommit(true, amountIn, nil, sr, nil)
+	assert.NoError(t, err)
+
+	assert.True(t, pool.SqrtRatioX96.Eq(sr.SqrtRatioX96), "цена пула должна совпадать с результатом свапа")
+	assert.True(t, pool.Liquidity.Eq(sr.Liquidity), "ликвидность пула должна совпадать с результатом свапа")
+	assert.Equal(t, sr.CurrentTick, pool.TickCurrent)
+	assert.True(t, pool.TickCurrent < 0, "zeroForOne свап должен опустить тик")
+
+	// пул не должен разделять память с результатом: следующий свап перезапишет lastState
+	committedPrice := pool.SqrtRatioX96.Clone()
+	err = pool.Swap(true, amountIn, nil, &SwapResultV2{})
+	assert.NoError(t, err)
+	assert.True(t, pool.SqrtRatioX96.Eq(committedPrice), "read-only Swap не должен менять пул")
+
+	// два последовательных коммита == один свап на суммарный объём (с точностью до округления по шагам)
+	sr2 := &SwapResultV2{}
+	err = pool.SwapCommit(true, amountIn, nil, sr2, nil)
+	assert.NoError(t, err)
+	assert.True(t, pool.SqrtRatioX96.Lt(committedPrice), "второй коммит должен продолжить движение цены вниз")
+}
+
+// TestSwapCommit_CrossesTickAndUpdatesLiquidity: коммит свапа, пересекающего
+// последний тик, должен обнулить in-range ликвидность пула.
+func TestSwapCommit_CrossesTickAndUpdatesLiquidity(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 0, L)
+
+	var limit utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(200, &limit)
+
+	amountIn, _ := int256.FromDec("1000000000000000000000000")
+
+	err := pool.SwapCommit(false, amountIn, &limit, &SwapResultV2{}, nil)
+	assert.NoError(t, err)
+	assert.True(t, pool.SqrtRatioX96.Eq(&limit))
+	assert.True(t, pool.Liquidity.IsZero(), "после выхода из диапазона позиции ликвидность должна быть 0")
+	assert.Equal(t, int32(200), pool.TickCurrent)
+}
+
+// TestSwapCommit_Rollback: checkpoint, снятый SwapCommit, возвращает пул в исходное состояние.
+func TestSwapCommit_Rollback(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 0, L)
+
+	before := pool.SqrtRatioX96.Clone()
+	token0Price := pool.Token0Price()
+
+	var cp PoolCheckpoint
+	amountIn, _ := int256.FromDec("1000000000000000000000000")
+	err := pool.SwapCommit(true, amountIn, nil, &SwapResultV2{}, &cp)
+	assert.NoError(t, err)
+	assert.False(t, pool.SqrtRatioX96.Eq(before))
+	assert.NotSame(t, token0Price, pool.Token0Price(), "кэш цены должен сбрасываться после коммита")
+
+	pool.Rollback(&cp)
+	assert.True(t, pool.SqrtRatioX96.Eq(before))
+	assert.True(t, pool.Liquidity.Eq(L))
+	assert.Equal(t, int32(0), pool.TickCurrent)
+}
+
+// TestSwapCommit_ErrorLeavesPoolUntouched: если свап упал (здесь — ErrMaxCrossInitTickLoops),
+// пул не меняется, хотя цикл свапа успел пройти тысячи шагов.
+func TestSwapCommit_ErrorLeavesPoolUntouched(t *testing.T) {
+	one := uint256.NewInt(1)
+	ticks := make([]Tick, 0, MAX_CROSS_INIT_TICK_LOOPS+2)
+	for i := int32(1); i <= MAX_CROSS_INIT_TICK_LOOPS+2; i++ {
+		net := int256.NewInt(1)
+		if i%2 == 0 {
+			net.Neg(net)
+		}
+		ticks = append(ticks, Tick{Index: i * 10, LiquidityGross: one.Clone(), LiquidityNet: net})
+	}
+	th := NewTicksHandler()
+	th.SetTicks(ticks)
+
+	var sqrtP utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(0, &sqrtP)
+	pool := NewPoolV3(common.Address{}, uint16(constants.FeeLow), 0, &sqrtP, USDC, DAI, th)
+
+	before := pool.SqrtRatioX96.Clone()
+
+	amountIn, _ := int256.FromDec("1000000000000000000000000")
+	err := pool.SwapCommit(false, amountIn, nil, &SwapResultV2{}, nil)
+	assert.ErrorIs(t, err, ErrMaxCrossInitTickLoops)
+	assert.True(t, pool.SqrtRatioX96.Eq(before))
+	assert.True(t, pool.Liquidity.IsZero())
+	assert.Equal(t, int32(0), pool.TickCurrent)
+}
@@ -0,0 +1,291 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/holiman/uint256"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var (
+	ErrNegativeLiquidity = errors.New("active liquidity becomes negative: ticks are inconsistent with pool liquidity")
+	ErrInvalidDepthBps   = errors.New("depth must be in (0, 10000) basis points")
+)
+
+// LiquiditySegment — участок цен [TickLower, TickUpper) с постоянной активной ликвидностью.
+type LiquiditySegment struct {
+	TickLower int32
+	TickUpper int32
+	Liquidity utils.Uint128
+}
+
+// DepthLevel — суммарная ликвидность в пределах ±Bps от текущей цены: Amount0 лежит выше цены
+// (продаётся при росте цены на Bps), Amount1 — ниже (продаётся при падении на Bps).
+type DepthLevel struct {
+	Bps     uint32
+	Amount0 utils.Uint256
+	Amount1 utils.Uint256
+}
+
+// LiquidityProfile — профиль активной ликвидности: отрезки между соседними инициализированными тиками
+// по возрастанию, начиная с текущего ликвидность восстанавливается через liquidityNet, как при свапе.
+// Пустые участки внутри сохраняются, нулевые крайние (до первого и после последнего тика) — нет.
+// Результат дописывается в segments[:0].
+func (p *Pool) LiquidityProfile(segments []LiquiditySegment) ([]LiquiditySegment, error) {
+	segments = segments[:0]
+	err := p.walkLiquidity(false, func(tickLower, tickUpper int32, liquidity *utils.Uint128) (bool, error) {
+		segments = append(segments, LiquiditySegment{TickLower: tickLower, TickUpper: tickUpper, Liquidity: *liquidity})
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(segments)
+
+	first := true
+	err = p.walkLiquidity(true, func(tickLower, tickUpper int32, liquidity *utils.Uint128) (bool, error) {
+		// текущий отрезок уже добавлен проходом вниз
+		if !first {
+			segments = append(segments, LiquiditySegment{TickLower: tickLower, TickUpper: tickUpper, Liquidity: *liquidity})
+		}
+		first = false
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for len(segments) != 0 && segments[0].Liquidity.IsZero() && segments[0].TickLower == utils.MinTick {
+		segments = segments[1:]
+	}
+	for n := len(segments); n != 0 && segments[n-1].Liquidity.IsZero() && segments[n-1].TickUpper == utils.MaxTick; n-- {
+		segments = segments[:n-1]
+	}
+	return segments, nil
+}
+
+// AmountsLocked считает token0 и token1, лежащие в пуле между ценами sqrtPriceAX96 и sqrtPriceBX96
+// (порядок любой), с округлением вниз. Выше текущей цены ликвидность хранится в token0, ниже — в token1.
+// Scratch пула (TickCalculator, SqrtPriceCalculator, fullMath) не используется: AmountsLocked и Depth
+// можно вызывать параллельно друг с другом, пока пул не меняется.
+func (p *Pool) AmountsLocked(sqrtPriceAX96, sqrtPriceBX96 *utils.Uint160, amount0, amount1 *utils.Uint256) error {
+	tickCalculator := utils.NewTickCalculator()
+	sqrtPriceCalculator := utils.NewSqrtPriceCalculator()
+	amount0.Clear()
+	amount1.Clear()
+	if sqrtPriceAX96.Gt(sqrtPriceBX96) {
+		sqrtPriceAX96, sqrtPriceBX96 = sqrtPriceBX96, sqrtPriceAX96
+	}
+
+	var sqrtLowerX96, sqrtUpperX96, lo, hi utils.Uint160
+	var delta utils.Uint256
+	up := false
+	segment := func(tickLower, tickUpper int32, liquidity *utils.Uint128) (bool, error) {
+		tickCalculator.GetSqrtRatioAtTickV2(tickLower, &sqrtLowerX96)
+		tickCalculator.GetSqrtRatioAtTickV2(tickUpper, &sqrtUpperX96)
+		above := !sqrtLowerX96.Lt(sqrtPriceBX96)
+		below := !sqrtUpperX96.Gt(sqrtPriceAX96)
+		if above || below {
+			// дальше в направлении обхода диапазон уже не встретится
+			return up == below, nil
+		}
+		if liquidity.IsZero() {
+			return true, nil
+		}
+
+		lo.Set(&sqrtLowerX96)
+		if sqrtPriceAX96.Gt(&lo) {
+			lo.Set(sqrtPriceAX96)
+		}
+		hi.Set(&sqrtUpperX96)
+		if sqrtPriceBX96.Lt(&hi) {
+			hi.Set(sqrtPriceBX96)
+		}
+		if p.SqrtRatioX96.Lt(&hi) {
+			from := &lo
+			if p.SqrtRatioX96.Gt(&lo) {
+				from = p.SqrtRatioX96
+			}
+			if err := sqrtPriceCalculator.GetAmount0DeltaV2(from, &hi, liquidity, false, &delta); err != nil {
+				return false, err
+			}
+			amount0.Add(amount0, &delta)
+		}
+		if p.SqrtRatioX96.Gt(&lo) {
+			to := &hi
+			if p.SqrtRatioX96.Lt(&hi) {
+				to = p.SqrtRatioX96
+			}
+			if err := sqrtPriceCalculator.GetAmount1DeltaV2(&lo, to, liquidity, false, &delta); err != nil {
+				return false, err
+			}
+			amount1.Add(amount1, &delta)
+		}
+		return true, nil
+	}
+
+	if err := p.walkLiquidity(false, segment); err != nil {
+		return err
+	}
+	first := true
+	up = true
+	return p.walkLiquidity(true, func(tickLower, tickUpper int32, liquidity *utils.Uint128) (bool, error) {
+		if first {
+			first = false
+			return true, nil
+		}
+		return segment(tickLower, tickUpper, liquidity)
+	})
+}
+
+// Depth — AmountsLocked между ценами P·(1-bps/10000) и P·(1+bps/10000), где P — текущая цена:
+// сколько token0 и token1 пул отдаст, пока цена не сдвинется на bps вверх и вниз соответственно.
+func (p *Pool) Depth(bps uint32, amount0, amount1 *utils.Uint256) error {
+	if bps == 0 || bps >= 10000 {
+		return ErrInvalidDepthBps
+	}
+	var sqrtDownX96, sqrtUpX96 utils.Uint160
+	if err := p.scaleSqrtPrice(10000-bps, &sqrtDownX96); err != nil {
+		return err
+	}
+	if err := p.scaleSqrtPrice(10000+bps, &sqrtUpX96); err != nil {
+		return err
+	}
+	return p.AmountsLocked(&sqrtDownX96, &sqrtUpX96, amount0, amount1)
+}
+
+// DepthLevels — Depth для каждого уровня bps; уровни кумулятивны (±2% включают ±1%).
+func (p *Pool) DepthLevels(bps []uint32) ([]DepthLevel, error) {
+	levels := make([]DepthLevel, len(bps))
+	for i, b := range bps {
+		levels[i].Bps = b
+		if err := p.Depth(b, &levels[i].Amount0, &levels[i].Amount1); err != nil {
+			return nil, fmt.Errorf("depth %d bps: %w", b, err)
+		}
+	}
+	return levels, nil
+}
+
+// scaleSqrtPrice: result = sqrtPriceX96 · sqrt(ratioBps/10000), в пределах [MinSqrtRatio, MaxSqrtRatio].
+func (p *Pool) scaleSqrtPrice(ratioBps uint32, result *utils.Uint160) error {
+	// sqrt(ratio) в Q96: sqrt(ratio · 2^192)
+	var factorX96 uint256.Int
+	factorX96.SetUint64(uint64(ratioBps))
+	factorX96.Lsh(&factorX96, 192)
+	factorX96.Div(&factorX96, uint256.NewInt(10000))
+	factorX96.Sqrt(&factorX96)
+
+	if err := mulDiv(p.SqrtRatioX96, &factorX96, constants.Q96U256, result); err != nil {
+		return err
+	}
+	if result.Lt(utils.MinSqrtRatioU256) {
+		result.Set(utils.MinSqrtRatioU256)
+	} else if result.Gt(utils.MaxSqrtRatioU256) {
+		result.Set(utils.MaxSqrtRatioU256)
+	}
+	return nil
+}
+
+// walkLiquidity обходит отрезки между инициализированными тиками от текущего вверх (up) или вниз,
+// пересчитывая активную ликвидность через liquidityNet. Первый отрезок содержит TickCurrent
+// и имеет ликвидность пула. Обход заканчивается на MinTick/MaxTick или когда fn вернул false.
+// *TicksHandler читается через hintedTicks с локальным hint, как в SwapWithContext, — без записи в хендлер.
+func (p *Pool) walkLiquidity(up bool, fn func(tickLower, tickUpper int32, liquidity *utils.Uint128) (bool, error)) error {
+	ticks := p.TickDataProvider
+	if handler, ok := ticks.(*TicksHandler); ok {
+		hint := -1
+		ticks = &hintedTicks{h: handler, hint: &hint}
+	}
+
+	liquidity := *p.Liquidity
+	lower, err := nextLiquidityTick(ticks, p.TickCurrent, true)
+	if err != nil {
+		return err
+	}
+	upper, err := nextLiquidityTick(ticks, p.TickCurrent, false)
+	if err != nil {
+		return err
+	}
+
+	l := (*int256.Int)(&liquidity)
+	for {
+		if cont, err := fn(lower, upper, &liquidity); err != nil || !cont {
+			return err
+		}
+
+		// пересекаемый тик: вверх liquidityNet прибавляется, вниз — вычитается
+		crossed := lower
+		if up {
+			crossed = upper
+		}
+		if crossed == utils.MinTick || crossed == utils.MaxTick {
+			return nil
+		}
+		net, err := tickLiquidityNet(ticks, crossed)
+		if err != nil {
+			return err
+		}
+		if up {
+			l.Add(l, net)
+		} else {
+			l.Sub(l, net)
+		}
+		if l.IsNegative() {
+			return fmt.Errorf("crossing tick %d: %w", crossed, ErrNegativeLiquidity)
+		}
+
+		if up {
+			lower = upper
+			if upper, err = nextLiquidityTick(ticks, upper, false); err != nil {
+				return err
+			}
+		} else {
+			upper = lower
+			if lower, err = nextLiquidityTick(ticks, lower-1, true); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// nextLiquidityTick — следующий инициализированный (gross > 0) тик в направлении lte;
+// за крайним тиком — MinTick/MaxTick.
+func nextLiquidityTick(ticks TickDataProvider, tick int32, lte bool) (int32, error) {
+	if ticks == nil {
+		if lte {
+			return utils.MinTick, nil
+		}
+		return utils.MaxTick, nil
+	}
+	for {
+		next, initialized, err := ticks.NextInitializedTickIndex(tick, lte)
+		switch {
+		case errors.Is(err, ErrBelowSmallest):
+			return utils.MinTick, nil
+		case errors.Is(err, ErrAtOrAboveLargest):
+			return utils.MaxTick, nil
+		case err != nil:
+			return 0, err
+		case initialized:
+			return next, nil
+		}
+		if lte {
+			tick = next - 1
+		} else {
+			tick = next
+		}
+	}
+}
+
+func tickLiquidityNet(ticks TickDataProvider, tick int32) (*utils.Int128, error) {
+	t, err := ticks.GetTick(tick)
+	if err != nil {
+		return nil, fmt.Errorf("tick %d: %w", tick, err)
+	}
+	return t.LiquidityNet, nil
+}
@@ -0,0 +1,129 @@
+package entities
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+func TestPool_LiquidityProfile(t *testing.T) {
+	pool := newTwoPositionsTestPool(t)
+
+	segments, err := pool.LiquidityProfile(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []LiquiditySegment{
+		{TickLower: -600, TickUpper: -60, Liquidity: *uint256.NewInt(1e18)},
+		{TickLower: -60, TickUpper: 120, Liquidity: *uint256.NewInt(15e17)},
+		{TickLower: 120, TickUpper: 600, Liquidity: *uint256.NewInt(1e18)},
+	}, segments)
+
+	// цена вне позиций: профиль тот же, крайний нулевой отрезок с текущей ценой отбрасывается
+	var cp PoolCheckpoint
+	sr := &SwapResultV2{}
+	assert.NoError(t, pool.SwapCommit(false, int256.MustFromDec("100000000000000000000"), nil, sr, &cp))
+	assert.Greater(t, pool.TickCurrent, int32(600))
+	again, err := pool.LiquidityProfile(segments)
+	assert.NoError(t, err)
+	assert.Len(t, again, 3)
+	assert.Equal(t, int32(600), again[2].TickUpper)
+
+	// пустой пул
+	segments, err = newEmptyTestPool(0).LiquidityProfile(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, segments)
+}
+
+func TestPool_AmountsLocked(t *testing.T) {
+	pool := newTwoPositionsTestPool(t)
+
+	// весь диапазон цен — сумма того, что вернул бы burn всех позиций
+	var amount0, amount1, want0, want1, position0, position1 utils.Uint256
+	assert.NoError(t, pool.AmountsLocked(utils.MaxSqrtRatioU256, utils.MinSqrtRatioU256, &amount0, &amount1))
+	for _, pos := range []struct {
+		lower, upper int32
+		liquidity    uint64
+	}{{-600, 600, 1e18}, {-60, 120, 5e17}} {
+		assert.NoError(t, pool.liquidityAmounts(pos.lower, pos.upper, uint256.NewInt(pos.liquidity), false, &position0, &position1))
+		want0.Add(&want0, &position0)
+		want1.Add(&want1, &position1)
+	}
+	assert.InDelta(t, want0.Uint64(), amount0.Uint64(), 3)
+	assert.InDelta(t, want1.Uint64(), amount1.Uint64(), 3)
+
+	// диапазон целиком выше цены — только token0, и только отрезок [120, 600)
+	var sqrtA, sqrtB utils.Uint160
+	pool.TickCalculator.GetSqrtRatioAtTickV2(120, &sqrtA)
+	pool.TickCalculator.GetSqrtRatioAtTickV2(600, &sqrtB)
+	assert.NoError(t, pool.AmountsLocked(&sqrtA, &sqrtB, &amount0, &amount1))
+	assert.NoError(t, pool.SqrtPriceCalculator.GetAmount0DeltaV2(&sqrtA, &sqrtB, uint256.NewInt(1e18), false, &want0))
+	assert.Equal(t, want0.Dec(), amount0.Dec())
+	assert.True(t, amount1.IsZero())
+
+	// диапазон целиком ниже цены — только token1
+	pool.TickCalculator.GetSqrtRatioAtTickV2(-900, &sqrtA)
+	pool.TickCalculator.GetSqrtRatioAtTickV2(-300, &sqrtB)
+	assert.NoError(t, pool.AmountsLocked(&sqrtA, &sqrtB, &amount0, &amount1))
+	pool.TickCalculator.GetSqrtRatioAtTickV2(-600, &sqrtA)
+	assert.NoError(t, pool.SqrtPriceCalculator.GetAmount1DeltaV2(&sqrtA, &sqrtB, uint256.NewInt(1e18), false, &want1))
+	assert.True(t, amount0.IsZero())
+	assert.Equal(t, want1.Dec(), amount1.Dec())
+}
+
+func TestPool_Depth(t *testing.T) {
+	pool := newTwoPositionsTestPool(t)
+
+	levels, err := pool.DepthLevels([]uint32{10, 100, 1000})
+	assert.NoError(t, err)
+	assert.Len(t, levels, 3)
+	for i := 1; i < len(levels); i++ {
+		assert.True(t, levels[i].Amount0.Gt(&levels[i-1].Amount0), "глубина кумулятивна")
+		assert.True(t, levels[i].Amount1.Gt(&levels[i-1].Amount1))
+	}
+
+	// ±10% покрывает все позиции (±600 тиков ≈ ±6%)
+	var all0, all1 utils.Uint256
+	assert.NoError(t, pool.AmountsLocked(utils.MinSqrtRatioU256, utils.MaxSqrtRatioU256, &all0, &all1))
+	assert.Equal(t, all0.Dec(), levels[2].Amount0.Dec())
+	assert.Equal(t, all1.Dec(), levels[2].Amount1.Dec())
+
+	var amount0, amount1 utils.Uint256
+	assert.ErrorIs(t, pool.Depth(0, &amount0, &amount1), ErrInvalidDepthBps)
+	assert.ErrorIs(t, pool.Depth(10000, &amount0, &amount1), ErrInvalidDepthBps)
+
+	// ликвидность пула не согласована с тиками
+	pool.Liquidity.SetUint64(1)
+	assert.ErrorIs(t, pool.Depth(100, &amount0, &amount1), ErrNegativeLiquidity)
+	_, err = pool.LiquidityProfile(nil)
+	assert.ErrorIs(t, err, ErrNegativeLiquidity)
+}
+
+// TestPool_DepthConcurrent: Depth не пишет в scratch пула, поэтому параллельные вызовы дают тот же
+// результат, что и последовательный, а пул без fullMath (собранный не через конструктор) не паникует.
+func TestPool_DepthConcurrent(t *testing.T) {
+	pool := newTwoPositionsTestPool(t)
+	bps := []uint32{10, 100, 1000}
+	want, err := pool.DepthLevels(bps)
+	assert.NoError(t, err)
+
+	pool.fullMath = nil
+	var wg sync.WaitGroup
+	results := make([][]DepthLevel, 8)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = pool.DepthLevels(bps)
+		}(i)
+	}
+	wg.Wait()
+	for i := range results {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, want, results[i])
+	}
+}
@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// expectedFeeGrowth считает mulDiv(fee, Q128, liquidity) через math/big — эталон для одного шага.
+func expectedFeeGrowth(fee, liquidity *uint256.Int) *uint256.Int {
+	q := fee.ToBig()
+	q.Lsh(q, 128)
+	q.Quo(q, liquidity.ToBig())
+	return uint256.MustFromBig(q)
+}
+
+// TestSwapCommit_FeeGrowthGlobalSingleStep: свап внутри одного диапазона — один шаг,
+// fee growth входного токена растёт ровно на fee*2^128/L, второго токена — не меняется.
+func TestSwapCommit_FeeGrowthGlobalSingleStep(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 0, L)
+
+	var fee utils.Uint256
+	sr := &SwapResultV2{StepsFee: []StepFeeResult{}}
+	err := pool.SwapCommit(true, int256.NewInt(1e12), nil, sr, nil)
+	assert.NoError(t, err)
+	assert.Len(t, sr.StepsFee, 1)
+	fee = sr.StepsFee[0].FeeAmount
+
+	assert.True(t, pool.FeeGrowthGlobal0X128.Eq(expectedFeeGrowth(&fee, L)),
+		"got %s", pool.FeeGrowthGlobal0X128.Dec())
+	assert.True(t, pool.FeeGrowthGlobal1X128.IsZero(), "fee growth выходного токена не должен меняться")
+
+	// read-only Swap fee growth не трогает
+	before := pool.FeeGrowthGlobal0X128
+	assert.NoError(t, pool.Swap(true, int256.NewInt(1e12), nil, &SwapResultV2{}))
+	assert.True(t, pool.FeeGrowthGlobal0X128.Eq(&before))
+}
+
+// TestSwapCommit_FlipsFeeGrowthOutside: при пересечении инициализированного тика его
+// feeGrowthOutside переворачиваются относительно глобальных значений на момент пересечения.
+func TestSwapCommit_FlipsFeeGrowthOutside(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 0, L)
+
+	// тик 100 лежит выше текущего: по конвенции контракта его outside = 0 (рост «сверху» не учтён)
+	var limit utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(200, &limit)
+	amountIn, _ := int256.FromDec("1000000000000000000000000")
+
+	var cp PoolCheckpoint
+	sr := &SwapResultV2{}
+	err := pool.SwapCommit(false, amountIn, &limit, sr, &cp)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sr.CrossInitTickLoops)
+	assert.False(t, pool.FeeGrowthGlobal1X128.IsZero())
+
+	upper, err := pool.TickDataProvider.GetTick(100)
+	assert.NoError(t, err)
+	assert.True(t, upper.FeeGrowthOutside1X128.Eq(&pool.FeeGrowthGlobal1X128),
+		"после пересечения весь накопленный рост оказывается «снаружи» (ниже) тика 100")
+	assert.True(t, upper.FeeGrowthOutside0X128.IsZero())
+
+	lower, err := pool.TickDataProvider.GetTick(-100)
+	assert.NoError(t, err)
+	assert.True(t, lower.FeeGrowthOutside1X128.IsZero(), "непересечённый тик не меняется")
+
+	// rollback возвращает и глобальные значения, и feeGrowthOutside тиков
+	assert.NoError(t, pool.Rollback(&cp))
+	assert.True(t, pool.FeeGrowthGlobal1X128.IsZero())
+	upper, _ = pool.TickDataProvider.GetTick(100)
+	assert.True(t, upper.FeeGrowthOutside1X128.IsZero())
+	assert.Equal(t, int32(0), pool.TickCurrent)
+}
+
+// TestSwapCommit_RoundTripFlipsBack: свап вверх через тик и обратно вниз через него же
+// должен оставить feeGrowthOutside = накопленному росту выше тика (inside-интервал снова снаружи).
+func TestSwapCommit_RoundTripFlipsBack(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 0, L)
+
+	tc := utils.NewTickCalculator()
+	var up, down utils.Uint160
+	tc.GetSqrtRatioAtTickV2(150, &up)
+	tc.GetSqrtRatioAtTickV2(50, &down)
+	amountIn, _ := int256.FromDec("1000000000000000000000000")
+
+	assert.NoError(t, pool.SwapCommit(false, amountIn, &up, &SwapResultV2{}, nil))
+	g1AtCross := pool.FeeGrowthGlobal1X128
+
+	sr := &SwapResultV2{}
+	assert.NoError(t, pool.SwapCommit(true, amountIn, &down, sr, nil))
+	assert.Equal(t, 1, sr.CrossInitTickLoops)
+
+	// между пересечениями ликвидности над тиком 100 не было — рост token1 не менялся,
+	// а token0 начал расти только после возврата в диапазон
+	upper, _ := pool.TickDataProvider.GetTick(100)
+	assert.True(t, pool.FeeGrowthGlobal1X128.Eq(&g1AtCross))
+	assert.True(t, upper.FeeGrowthOutside1X128.IsZero(), "g1 - (g1 - 0) = 0")
+	assert.True(t, upper.FeeGrowthOutside0X128.IsZero(), "token0 рос только ниже тика после второго пересечения")
+	assert.False(t, pool.FeeGrowthGlobal0X128.IsZero())
+}
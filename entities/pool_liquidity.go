@@ -0,0 +1,334 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var (
+	ErrZeroMintAmount       = errors.New("mint amount must be greater than 0")
+	ErrMaxLiquidityPerTick  = errors.New("liquidity gross exceeds max liquidity per tick (LO)")
+	ErrNoPositionLiquidity  = errors.New("position has no liquidity (NP)")
+	ErrBurnExceedsLiquidity = errors.New("burn amount exceeds position liquidity (LS)")
+)
+
+// PositionKey — ключ позиции в пуле, как PositionKey.compute(owner, tickLower, tickUpper) контракта.
+type PositionKey struct {
+	Owner     common.Address
+	TickLower int32
+	TickUpper int32
+}
+
+// PositionInfo — состояние позиции на уровне пула (Position.Info контракта).
+// Не путать с Position — позицией NFT-менеджера поверх конкретного Pool.
+type PositionInfo struct {
+	Liquidity                utils.Uint128
+	FeeGrowthInside0LastX128 utils.Uint256
+	FeeGrowthInside1LastX128 utils.Uint256
+	TokensOwed0              utils.Uint128
+	TokensOwed1              utils.Uint128
+}
+
+// GetPosition возвращает запись позиции пула или nil, если такой нет.
+func (p *Pool) GetPosition(owner common.Address, tickLower, tickUpper int32) *PositionInfo {
+	return p.Positions[PositionKey{Owner: owner, TickLower: tickLower, TickUpper: tickUpper}]
+}
+
+// Mint — аналог UniswapV3Pool.mint: добавляет amount ликвидности позиции owner в диапазоне
+// [tickLower, tickUpper), обновляет тики (инициализируя feeGrowthOutside новых тиков), при попадании
+// TickCurrent в диапазон — активную ликвидность пула, и пишет в amount0/amount1 суммы токенов,
+// которые нужно внести (округление вверх, как SqrtPriceMath.getAmount{0,1}Delta для liquidity > 0).
+// При ошибке пул не меняется.
+func (p *Pool) Mint(owner common.Address, tickLower, tickUpper int32, amount *utils.Uint128, amount0, amount1 *utils.Uint256) error {
+	if amount.IsZero() {
+		return ErrZeroMintAmount
+	}
+	return p.modifyPosition(owner, tickLower, tickUpper, amount, false, amount0, amount1)
+}
+
+// Burn — аналог UniswapV3Pool.burn: снимает amount ликвидности позиции owner, пишет в amount0/amount1
+// освобождённые суммы токенов (округление вниз) и зачисляет их в TokensOwed позиции вместе
+// с накопленными комиссиями. amount = 0 — «poke»: только начисление комиссий.
+func (p *Pool) Burn(owner common.Address, tickLower, tickUpper int32, amount *utils.Uint128, amount0, amount1 *utils.Uint256) error {
+	if err := p.modifyPosition(owner, tickLower, tickUpper, amount, true, amount0, amount1); err != nil {
+		return err
+	}
+
+	if !amount0.IsZero() || !amount1.IsZero() {
+		position := p.GetPosition(owner, tickLower, tickUpper)
+		addUint128(&position.TokensOwed0, amount0)
+		addUint128(&position.TokensOwed1, amount1)
+	}
+	return nil
+}
+
+// Collect — аналог UniswapV3Pool.collect: выводит min(requested, tokensOwed) по каждому токену.
+// Полностью опустевшая позиция (нет ни ликвидности, ни долга) удаляется из Positions —
+// для расчётов это эквивалентно нулевой записи в storage контракта.
+func (p *Pool) Collect(owner common.Address, tickLower, tickUpper int32, amount0Requested, amount1Requested *utils.Uint128, amount0, amount1 *utils.Uint128) {
+	key := PositionKey{Owner: owner, TickLower: tickLower, TickUpper: tickUpper}
+	position := p.Positions[key]
+	if position == nil {
+		amount0.Clear()
+		amount1.Clear()
+		return
+	}
+
+	if amount0Requested.Gt(&position.TokensOwed0) {
+		amount0.Set(&position.TokensOwed0)
+	} else {
+		amount0.Set(amount0Requested)
+	}
+	if amount1Requested.Gt(&position.TokensOwed1) {
+		amount1.Set(&position.TokensOwed1)
+	} else {
+		amount1.Set(amount1Requested)
+	}
+
+	position.TokensOwed0.Sub(&position.TokensOwed0, amount0)
+	position.TokensOwed1.Sub(&position.TokensOwed1, amount1)
+
+	if position.Liquidity.IsZero() && position.TokensOwed0.IsZero() && position.TokensOwed1.IsZero() {
+		delete(p.Positions, key)
+	}
+}
+
+// modifyPosition — аналог _modifyPosition/_updatePosition контракта. Вся валидация и расчёт сумм
+// выполняются до первой мутации, поэтому ошибка не оставляет пул в промежуточном состоянии.
+func (p *Pool) modifyPosition(owner common.Address, tickLower, tickUpper int32, liquidity *utils.Uint128, burn bool, amount0, amount1 *utils.Uint256) error {
+	if err := p.checkTicks(tickLower, tickUpper); err != nil {
+		return err
+	}
+	if p.TickDataProvider == nil {
+		p.TickDataProvider = NewTicksHandler()
+	}
+	mutator, err := p.tickMutator()
+	if err != nil {
+		return err
+	}
+
+	key := PositionKey{Owner: owner, TickLower: tickLower, TickUpper: tickUpper}
+	position := p.Positions[key]
+
+	switch {
+	case liquidity.IsZero():
+		// disallow pokes for 0 liquidity positions
+		if position == nil || position.Liquidity.IsZero() {
+			return ErrNoPositionLiquidity
+		}
+	case burn:
+		if position == nil || position.Liquidity.Lt(liquidity) {
+			return ErrBurnExceedsLiquidity
+		}
+	default:
+		if err := p.checkMaxLiquidityPerTick(tickLower, tickUpper, liquidity); err != nil {
+			return err
+		}
+	}
+
+	if checker, ok := mutator.(TickMutationChecker); ok && !liquidity.IsZero() {
+		if burn {
+			err = checker.CheckBurn(tickLower, tickUpper, liquidity)
+		} else {
+			err = checker.CheckMint(tickLower, tickUpper, liquidity)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := p.liquidityAmounts(tickLower, tickUpper, liquidity, !burn, amount0, amount1); err != nil {
+		return err
+	}
+
+	var feeGrowthInside0X128, feeGrowthInside1X128 utils.Uint256
+	switch {
+	case liquidity.IsZero():
+		p.GetFeeGrowthInside(tickLower, tickUpper, &feeGrowthInside0X128, &feeGrowthInside1X128)
+	case burn:
+		// feeGrowthInside считаем до удаления опустевших тиков (ticks.clear в контракте идёт после)
+		p.GetFeeGrowthInside(tickLower, tickUpper, &feeGrowthInside0X128, &feeGrowthInside1X128)
+		mutator.UpdateTicksAfterBurn(tickLower, tickUpper, liquidity)
+	default:
+		flippedLower := !p.isTickInitialized(tickLower)
+		flippedUpper := !p.isTickInitialized(tickUpper)
+		mutator.UpdateTicksAfterMint(tickLower, tickUpper, liquidity)
+		// by convention, we assume that all growth before a tick was initialized happened _below_ the tick
+		if flippedLower && tickLower <= p.TickCurrent {
+			p.initFeeGrowthOutside(mutator, tickLower)
+		}
+		if flippedUpper && tickUpper <= p.TickCurrent {
+			p.initFeeGrowthOutside(mutator, tickUpper)
+		}
+		p.GetFeeGrowthInside(tickLower, tickUpper, &feeGrowthInside0X128, &feeGrowthInside1X128)
+	}
+
+	if position == nil {
+		if p.Positions == nil {
+			p.Positions = make(map[PositionKey]*PositionInfo)
+		}
+		position = &PositionInfo{}
+		p.Positions[key] = position
+	}
+	if err := p.updatePosition(position, liquidity, burn, &feeGrowthInside0X128, &feeGrowthInside1X128); err != nil {
+		return err
+	}
+
+	if !liquidity.IsZero() && p.TickCurrent >= tickLower && p.TickCurrent < tickUpper {
+		// write an oracle entry
+		p.writeObservation(p.TickCurrent, p.Liquidity)
+
+		if burn {
+			p.Liquidity.Sub(p.Liquidity, liquidity)
+		} else {
+			p.Liquidity.Add(p.Liquidity, liquidity)
+		}
+	}
+	return nil
+}
+
+// updatePosition — аналог Position.update: начисляет комиссии по старой ликвидности позиции,
+// затем применяет дельту и переносит checkpoint feeGrowthInside.
+func (p *Pool) updatePosition(position *PositionInfo, liquidity *utils.Uint128, burn bool, feeGrowthInside0X128, feeGrowthInside1X128 *utils.Uint256) error {
+	var tokensOwed0, tokensOwed1 utils.Uint128
+	if err := accruedFees(feeGrowthInside0X128, &position.FeeGrowthInside0LastX128, &position.Liquidity, &tokensOwed0); err != nil {
+		return err
+	}
+	if err := accruedFees(feeGrowthInside1X128, &position.FeeGrowthInside1LastX128, &position.Liquidity, &tokensOwed1); err != nil {
+		return err
+	}
+
+	if burn {
+		position.Liquidity.Sub(&position.Liquidity, liquidity)
+	} else {
+		position.Liquidity.Add(&position.Liquidity, liquidity)
+	}
+	position.FeeGrowthInside0LastX128 = *feeGrowthInside0X128
+	position.FeeGrowthInside1LastX128 = *feeGrowthInside1X128
+
+	addUint128(&position.TokensOwed0, &tokensOwed0)
+	addUint128(&position.TokensOwed1, &tokensOwed1)
+	return nil
+}
+
+// liquidityAmounts считает суммы токенов для изменения ликвидности в диапазоне при текущей цене пула.
+func (p *Pool) liquidityAmounts(tickLower, tickUpper int32, liquidity *utils.Uint128, roundUp bool, amount0, amount1 *utils.Uint256) error {
+	amount0.Clear()
+	amount1.Clear()
+	if liquidity.IsZero() {
+		return nil
+	}
+
+	var sqrtRatioLowerX96, sqrtRatioUpperX96 utils.Uint160
+	p.TickCalculator.GetSqrtRatioAtTickV2(tickLower, &sqrtRatioLowerX96)
+	p.TickCalculator.GetSqrtRatioAtTickV2(tickUpper, &sqrtRatioUpperX96)
+
+	if p.TickCurrent < tickLower {
+		// current tick is below the passed range; liquidity can only become in range by crossing from left to
+		// right, when we'll need _more_ token0 (it's becoming more valuable) so user must provide it
+		return p.SqrtPriceCalculator.GetAmount0DeltaV2(&sqrtRatioLowerX96, &sqrtRatioUpperX96, liquidity, roundUp, amount0)
+	}
+	if p.TickCurrent < tickUpper {
+		// current tick is inside the passed range
+		if err := p.SqrtPriceCalculator.GetAmount0DeltaV2(p.SqrtRatioX96, &sqrtRatioUpperX96, liquidity, roundUp, amount0); err != nil {
+			return err
+		}
+		return p.SqrtPriceCalculator.GetAmount1DeltaV2(&sqrtRatioLowerX96, p.SqrtRatioX96, liquidity, roundUp, amount1)
+	}
+	// current tick is above the passed range; liquidity can only become in range by crossing from right to
+	// left, when we'll need _more_ token1 (it's becoming more valuable) so user must provide it
+	return p.SqrtPriceCalculator.GetAmount1DeltaV2(&sqrtRatioLowerX96, &sqrtRatioUpperX96, liquidity, roundUp, amount1)
+}
+
+// checkTicks — аналог checkTicks контракта плюс требование кратности tickSpacing из tickBitmap.flipTick.
+func (p *Pool) checkTicks(tickLower, tickUpper int32) error {
+	if tickLower >= tickUpper {
+		return ErrTickOrder
+	}
+	if tickLower < utils.MinTick {
+		return ErrTickLowerToLow
+	}
+	if tickUpper > utils.MaxTick {
+		return ErrTickUpperToHigh
+	}
+	if p.TickSpacing != 0 {
+		if tickLower%int32(p.TickSpacing) != 0 {
+			return ErrTickLowerTickSpacing
+		}
+		if tickUpper%int32(p.TickSpacing) != 0 {
+			return ErrTickUpperTickSpacing
+		}
+	}
+	return nil
+}
+
+// checkMaxLiquidityPerTick — require(liquidityGrossAfter <= maxLiquidity, 'LO') из Tick.update для обоих тиков.
+func (p *Pool) checkMaxLiquidityPerTick(tickLower, tickUpper int32, liquidity *utils.Uint128) error {
+	if p.TickSpacing == 0 {
+		return nil
+	}
+
+	var maxLiquidity utils.Uint128
+	TickSpacingToMaxLiquidityPerTick(p.TickSpacing, &maxLiquidity)
+	if liquidity.Gt(&maxLiquidity) {
+		return ErrMaxLiquidityPerTick
+	}
+
+	var grossAfter utils.Uint128
+	for _, tick := range [2]int32{tickLower, tickUpper} {
+		if gross := p.tickLiquidityGross(tick); gross != nil {
+			if grossAfter.Add(gross, liquidity).Gt(&maxLiquidity) {
+				return ErrMaxLiquidityPerTick
+			}
+		}
+	}
+	return nil
+}
+
+// TickSpacingToMaxLiquidityPerTick — аналог Tick.tickSpacingToMaxLiquidityPerTick:
+// type(uint128).max / количество usable-тиков при данном tickSpacing.
+func TickSpacingToMaxLiquidityPerTick(tickSpacing uint16, result *utils.Uint128) {
+	spacing := int32(tickSpacing)
+	minTick := (utils.MinTick / spacing) * spacing
+	maxTick := (utils.MaxTick / spacing) * spacing
+	numTicks := uint64((maxTick-minTick)/spacing) + 1
+
+	result.Div(utils.Uint128Max, result.SetUint64(numTicks))
+}
+
+// tickLiquidityGross — liquidityGross тика или nil, если тика нет. Для *TicksHandler идёт через
+// LRU-кэш tickWithSliceKey: Mint/Burn смотрят одни и те же граничные тики по нескольку раз.
+func (p *Pool) tickLiquidityGross(tick int32) *utils.Uint128 {
+	if h, ok := p.TickDataProvider.(*TicksHandler); ok {
+		if t, _, exist := h.tickWithSliceKey(tick); exist {
+			return t.LiquidityGross
+		}
+		return nil
+	}
+	if t, err := p.TickDataProvider.GetTick(tick); err == nil {
+		return t.LiquidityGross
+	}
+	return nil
+}
+
+func (p *Pool) isTickInitialized(tick int32) bool {
+	gross := p.tickLiquidityGross(tick)
+	return gross != nil && !gross.IsZero()
+}
+
+// initFeeGrowthOutside — инициализация feeGrowthOutside нового тика не выше текущего (Tick.update).
+// У только что созданного тика outside = 0, поэтому для прочих провайдеров это CrossTick
+// с текущими глобальными значениями: global - 0 = global.
+func (p *Pool) initFeeGrowthOutside(mutator TickMutator, tick int32) {
+	if h, ok := mutator.(*TicksHandler); ok {
+		if t, _, exist := h.tickWithSliceKey(tick); exist {
+			t.FeeGrowthOutside0X128 = p.FeeGrowthGlobal0X128
+			t.FeeGrowthOutside1X128 = p.FeeGrowthGlobal1X128
+		}
+		return
+	}
+	mutator.CrossTick(tick, &p.FeeGrowthGlobal0X128, &p.FeeGrowthGlobal1X128)
+}
@@ -0,0 +1,205 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var (
+	alice = common.HexToAddress("0x00000000000000000000000000000000000a11ce")
+	bob   = common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+)
+
+// newEmptyTestPool — пул без тиков и ликвидности на тике currentTick.
+func newEmptyTestPool(currentTick int32) *Pool {
+	var sqrtP utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(currentTick, &sqrtP)
+	return NewPoolV3(common.Address{}, uint16(constants.FeeLow), currentTick, &sqrtP, USDC, DAI, NewTicksHandler())
+}
+
+// newTwoPositionsTestPool — newEmptyTestPool(0) с позициями alice [-600, 600) 1e18 и bob [-60, 120) 5e17.
+func newTwoPositionsTestPool(t *testing.T) *Pool {
+	pool := newEmptyTestPool(0)
+	var amount0, amount1 utils.Uint256
+	assert.NoError(t, pool.Mint(alice, -600, 600, uint256.NewInt(1e18), &amount0, &amount1))
+	assert.NoError(t, pool.Mint(bob, -60, 120, uint256.NewInt(5e17), &amount0, &amount1))
+	return pool
+}
+
+// assertSameSwap выполняет один свап на обоих пулах (SwapCommit при commit) и сверяет результаты.
+func assertSameSwap(t *testing.T, expected, actual *Pool, zeroForOne bool, amountSpecified *int256.Int, commit bool) *SwapResultV2 {
+	want, got := &SwapResultV2{}, &SwapResultV2{}
+	if commit {
+		assert.NoError(t, expected.SwapCommit(zeroForOne, amountSpecified, nil, want, nil))
+		assert.NoError(t, actual.SwapCommit(zeroForOne, amountSpecified, nil, got, nil))
+	} else {
+		assert.NoError(t, expected.Swap(zeroForOne, amountSpecified, nil, want))
+		assert.NoError(t, actual.Swap(zeroForOne, amountSpecified, nil, got))
+	}
+	assert.Equal(t, want.AmountCalculated.Dec(), got.AmountCalculated.Dec())
+	assert.Equal(t, want.CurrentTick, got.CurrentTick)
+	assert.Equal(t, want.CrossInitTickLoops, got.CrossInitTickLoops)
+	return got
+}
+
+// TestPool_MintBurnCollect_InRange: mint округляет суммы вверх, burn — вниз; после burn и collect
+// пул возвращается к пустому состоянию, а позиция удаляется.
+func TestPool_MintBurnCollect_InRange(t *testing.T) {
+	pool := newEmptyTestPool(0)
+	L := uint256.NewInt(1e18)
+
+	var mint0, mint1 utils.Uint256
+	assert.NoError(t, pool.Mint(alice, -100, 100, L, &mint0, &mint1))
+
+	sc := utils.NewSqrtPriceCalculator()
+	tc := utils.NewTickCalculator()
+	var sqrtLower, sqrtUpper, want0, want1 utils.Uint256
+	tc.GetSqrtRatioAtTickV2(-100, &sqrtLower)
+	tc.GetSqrtRatioAtTickV2(100, &sqrtUpper)
+	assert.NoError(t, sc.GetAmount0DeltaV2(pool.SqrtRatioX96, &sqrtUpper, L, true, &want0))
+	assert.NoError(t, sc.GetAmount1DeltaV2(&sqrtLower, pool.SqrtRatioX96, L, true, &want1))
+	assert.True(t, mint0.Eq(&want0), "amount0: got %s want %s", mint0.Dec(), want0.Dec())
+	assert.True(t, mint1.Eq(&want1), "amount1: got %s want %s", mint1.Dec(), want1.Dec())
+
+	assert.True(t, pool.Liquidity.Eq(L), "тик 0 внутри диапазона — ликвидность активна")
+	assert.Equal(t, int32(-100), pool.TickDataProvider.(*TicksHandler).SmallestTickIdx)
+	assert.Equal(t, int32(100), pool.TickDataProvider.(*TicksHandler).LargestTickIdx)
+	assert.True(t, pool.GetPosition(alice, -100, 100).Liquidity.Eq(L))
+
+	var burn0, burn1 utils.Uint256
+	assert.NoError(t, pool.Burn(alice, -100, 100, L, &burn0, &burn1))
+	assert.True(t, new(uint256.Int).Sub(&mint0, &burn0).Eq(uint256.NewInt(1)), "burn округляет вниз")
+	assert.True(t, new(uint256.Int).Sub(&mint1, &burn1).Eq(uint256.NewInt(1)))
+	assert.True(t, pool.Liquidity.IsZero())
+	assert.Equal(t, 0, pool.TickDataProvider.(*TicksHandler).TicksLen)
+
+	position := pool.GetPosition(alice, -100, 100)
+	assert.True(t, position.TokensOwed0.Eq(&burn0), "burn зачисляет суммы в tokensOwed")
+	assert.True(t, position.TokensOwed1.Eq(&burn1))
+
+	var got0, got1 utils.Uint128
+	pool.Collect(alice, -100, 100, utils.Uint128Max, uint256.NewInt(10), &got0, &got1)
+	assert.True(t, got0.Eq(&burn0))
+	assert.True(t, got1.Eq(uint256.NewInt(10)), "collect не выводит больше запрошенного")
+	assert.NotNil(t, pool.GetPosition(alice, -100, 100), "остаток долга держит позицию")
+
+	pool.Collect(alice, -100, 100, utils.Uint128Max, utils.Uint128Max, &got0, &got1)
+	assert.True(t, got0.IsZero())
+	assert.True(t, got1.Eq(new(uint256.Int).SubUint64(&burn1, 10)))
+	assert.Nil(t, pool.GetPosition(alice, -100, 100))
+}
+
+// TestPool_Mint_OutOfRange: диапазон выше цены требует только token0, ниже — только token1,
+// активная ликвидность пула не меняется.
+func TestPool_Mint_OutOfRange(t *testing.T) {
+	pool := newEmptyTestPool(0)
+	L := uint256.NewInt(1e18)
+
+	var amount0, amount1 utils.Uint256
+	assert.NoError(t, pool.Mint(alice, 10, 20, L, &amount0, &amount1))
+	assert.False(t, amount0.IsZero())
+	assert.True(t, amount1.IsZero())
+
+	assert.NoError(t, pool.Mint(alice, -20, -10, L, &amount0, &amount1))
+	assert.True(t, amount0.IsZero())
+	assert.False(t, amount1.IsZero())
+
+	assert.True(t, pool.Liquidity.IsZero())
+	assert.Equal(t, int32(-20), pool.TickDataProvider.(*TicksHandler).SmallestTickIdx)
+	assert.Equal(t, int32(20), pool.TickDataProvider.(*TicksHandler).LargestTickIdx)
+
+	// свап вверх от пустой зоны доходит до диапазона [10, 20) заминченной ликвидности
+	var limit utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(15, &limit)
+	sr := &SwapResultV2{}
+	assert.NoError(t, pool.Swap(false, int256.NewInt(1e18), &limit, sr))
+	assert.True(t, sr.Liquidity.Eq(L))
+}
+
+// TestPool_Burn_PokeAccruesFees: poke (burn 0) начисляет позиции ровно те комиссии, что
+// посчитала бы Position над тем же пулом.
+func TestPool_Burn_PokeAccruesFees(t *testing.T) {
+	pool := newEmptyTestPool(0)
+	L := uint256.NewInt(1e18)
+
+	var amount0, amount1 utils.Uint256
+	assert.NoError(t, pool.Mint(alice, -100, 100, L, &amount0, &amount1))
+
+	sr := &SwapResultV2{StepsFee: []StepFeeResult{}}
+	assert.NoError(t, pool.SwapCommit(true, int256.NewInt(1e12), nil, sr, nil))
+	assert.Len(t, sr.StepsFee, 1)
+
+	assert.NoError(t, pool.Burn(alice, -100, 100, new(uint256.Int), &amount0, &amount1))
+	assert.True(t, amount0.IsZero() && amount1.IsZero(), "poke не снимает ликвидность")
+
+	position := pool.GetPosition(alice, -100, 100)
+	assert.True(t, position.TokensOwed0.Eq(expectedOwed(&sr.StepsFee[0].FeeAmount, L, L)),
+		"got %s", position.TokensOwed0.Dec())
+	assert.True(t, position.TokensOwed1.IsZero())
+
+	// повторный poke без свапов ничего не добавляет
+	owed := position.TokensOwed0
+	assert.NoError(t, pool.Burn(alice, -100, 100, new(uint256.Int), &amount0, &amount1))
+	assert.True(t, position.TokensOwed0.Eq(&owed))
+}
+
+// TestPool_Mint_InitializesFeeGrowthOutside: тик не выше текущего при инициализации получает
+// feeGrowthOutside = глобальным значениям, поэтому новая позиция не «наследует» старые комиссии.
+func TestPool_Mint_InitializesFeeGrowthOutside(t *testing.T) {
+	pool := newEmptyTestPool(0)
+	L := uint256.NewInt(1e18)
+
+	var amount0, amount1 utils.Uint256
+	assert.NoError(t, pool.Mint(alice, -100, 100, L, &amount0, &amount1))
+	assert.NoError(t, pool.SwapCommit(true, int256.NewInt(1e12), nil, &SwapResultV2{}, nil))
+	assert.False(t, pool.FeeGrowthGlobal0X128.IsZero())
+
+	assert.NoError(t, pool.Mint(bob, -50, 50, L, &amount0, &amount1))
+	lower, err := pool.TickDataProvider.GetTick(-50)
+	assert.NoError(t, err)
+	assert.True(t, lower.FeeGrowthOutside0X128.Eq(&pool.FeeGrowthGlobal0X128))
+	upper, err := pool.TickDataProvider.GetTick(50)
+	assert.NoError(t, err)
+	assert.True(t, upper.FeeGrowthOutside0X128.IsZero(), "тик выше текущего инициализируется нулём")
+
+	assert.NoError(t, pool.Burn(bob, -50, 50, new(uint256.Int), &amount0, &amount1))
+	assert.True(t, pool.GetPosition(bob, -50, 50).TokensOwed0.IsZero())
+}
+
+func TestPool_ModifyPosition_Errors(t *testing.T) {
+	pool := newEmptyTestPool(0)
+	L := uint256.NewInt(1e18)
+	var amount0, amount1 utils.Uint256
+
+	assert.ErrorIs(t, pool.Mint(alice, -100, 100, new(uint256.Int), &amount0, &amount1), ErrZeroMintAmount)
+	assert.ErrorIs(t, pool.Mint(alice, 100, -100, L, &amount0, &amount1), ErrTickOrder)
+	assert.ErrorIs(t, pool.Mint(alice, -105, 100, L, &amount0, &amount1), ErrTickLowerTickSpacing)
+	assert.ErrorIs(t, pool.Burn(alice, -100, 100, new(uint256.Int), &amount0, &amount1), ErrNoPositionLiquidity)
+
+	var maxLiquidity utils.Uint128
+	TickSpacingToMaxLiquidityPerTick(pool.TickSpacing, &maxLiquidity)
+	assert.NoError(t, pool.Mint(alice, -100, 100, &maxLiquidity, &amount0, &amount1))
+	assert.ErrorIs(t, pool.Mint(bob, -100, 200, uint256.NewInt(1), &amount0, &amount1), ErrMaxLiquidityPerTick)
+	assert.Nil(t, pool.GetPosition(bob, -100, 200), "ошибка не создаёт позицию")
+
+	assert.ErrorIs(t, pool.Burn(alice, -100, 100, new(uint256.Int).AddUint64(&maxLiquidity, 1), &amount0, &amount1), ErrBurnExceedsLiquidity)
+	assert.True(t, pool.Liquidity.Eq(&maxLiquidity))
+}
+
+func TestTickSpacingToMaxLiquidityPerTick(t *testing.T) {
+	// значения из тестов Tick.spec.ts контракта
+	var result utils.Uint128
+	TickSpacingToMaxLiquidityPerTick(10, &result)
+	assert.Equal(t, "1917569901783203986719870431555990", result.Dec())
+	TickSpacingToMaxLiquidityPerTick(60, &result)
+	assert.Equal(t, "11505743598341114571880798222544994", result.Dec())
+	TickSpacingToMaxLiquidityPerTick(200, &result)
+	assert.Equal(t, "38350317471085141830651933667504588", result.Dec())
+}
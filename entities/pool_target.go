@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"github.com/holiman/uint256"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// maxSwapAmount — «бесконечный» exact input: свап до цели останавливается только на sqrtPriceLimitX96.
+// Не 2^255-1: ComputeSwapStep умножает amountRemaining на (1e6 - fee) без 512-битного mulDiv.
+// Исчерпать его до цели нельзя: на отрезке с ликвидностью L < 2^128 вход без комиссии не больше
+// L·2^96/sqrtPrice (token0) или L·sqrtPrice/2^96 (token1), а отрезки не пересекаются, поэтому на весь
+// диапазон [MinSqrtRatio, MaxSqrtRatio] нужно меньше 2^192. Комиссия amountIn·fee/(1e6-fee) при
+// fee < 1e6 добавляет меньше 2^20 раз столько же, итого < 2^212 против ≈2^236 здесь.
+var maxSwapAmount = (*int256.Int)(new(uint256.Int).Div(new(uint256.Int).SetAllOne(), uint256.NewInt(utils.MaxFeeInt)))
+
+// SwapToPriceResult — свап, переводящий цену пула в заданную.
+type SwapToPriceResult struct {
+	ZeroForOne bool
+
+	// AmountIn — вход с комиссией, AmountOut — выход. Exact input на AmountIn и exact output
+	// на AmountOut с sqrtPriceLimitX96 = цель доводят цену ровно до цели; без лимита цена может
+	// разойтись с целью на единицу округления.
+	AmountIn  utils.Uint256
+	AmountOut utils.Uint256
+
+	// FeeAmount — вся комиссия во входном токене, ProtocolFee — её доля протокола.
+	FeeAmount   utils.Uint256
+	ProtocolFee utils.Uint256
+
+	SqrtRatioX96       utils.Uint160
+	Liquidity          utils.Uint128
+	CurrentTick        int32
+	CrossInitTickLoops int
+}
+
+// SwapToPrice — обратная задача к Swap: сколько нужно внести и сколько будет получено, чтобы цена
+// пула стала sqrtPriceTargetX96. Направление определяется целью. Инициализированные тики по пути
+// пересекаются так же, как в Swap, округления — как в контракте: на каждом шаге цена доходит до
+// границы, поэтому amountIn и комиссия округляются вверх, amountOut — вниз, одинаково для exact input
+// и exact output. Состояние пула не меняется; цель, равная текущей цене, даёт нулевой результат.
+func (p *Pool) SwapToPrice(sqrtPriceTargetX96 *utils.Uint160, result *SwapToPriceResult) error {
+	*result = SwapToPriceResult{
+		SqrtRatioX96: *p.SqrtRatioX96,
+		Liquidity:    *p.Liquidity,
+		CurrentTick:  p.TickCurrent,
+	}
+	if sqrtPriceTargetX96.Eq(p.SqrtRatioX96) {
+		return nil
+	}
+
+	// как require в UniswapV3Pool.swap: цель строго внутри (MIN_SQRT_RATIO, MAX_SQRT_RATIO)
+	zeroForOne := sqrtPriceTargetX96.Lt(p.SqrtRatioX96)
+	if zeroForOne && !sqrtPriceTargetX96.Gt(utils.MinSqrtRatioU256) {
+		return ErrSqrtPriceLimitX96TooLow
+	}
+	if !zeroForOne && !sqrtPriceTargetX96.Lt(utils.MaxSqrtRatioU256) {
+		return ErrSqrtPriceLimitX96TooHigh
+	}
+
+	var feeAmount utils.Uint256
+	swapResult := SwapResultV2{
+		FeeStepCallback: func(_ int32, stepFee *utils.Uint256, _ bool, _ *utils.Uint128) {
+			feeAmount.Add(&feeAmount, stepFee)
+		},
+	}
+	if err := p.Swap(zeroForOne, maxSwapAmount, sqrtPriceTargetX96, &swapResult); err != nil {
+		return err
+	}
+
+	result.ZeroForOne = zeroForOne
+	result.AmountIn.Sub((*uint256.Int)(maxSwapAmount), (*uint256.Int)(swapResult.RemainingAmountIn))
+	result.AmountOut.Neg((*uint256.Int)(swapResult.AmountCalculated))
+	result.ProtocolFee = swapResult.ProtocolFee
+	result.FeeAmount = feeAmount
+	result.SqrtRatioX96 = *swapResult.SqrtRatioX96
+	result.Liquidity = *swapResult.Liquidity
+	result.CurrentTick = swapResult.CurrentTick
+	result.CrossInitTickLoops = swapResult.CrossInitTickLoops
+	return nil
+}
+
+// SwapToTick — SwapToPrice до цены тика tick. При движении вниз пул, как и контракт, оказывается
+// на тике tick-1: цена ровно на границе принадлежит нижнему тику только при движении вверх.
+func (p *Pool) SwapToTick(tick int32, result *SwapToPriceResult) error {
+	if tick < utils.MinTick || tick > utils.MaxTick {
+		return ErrInvalidTickIndex
+	}
+	var sqrtPriceX96 utils.Uint160
+	p.TickCalculator.GetSqrtRatioAtTickV2(tick, &sqrtPriceX96)
+	return p.SwapToPrice(&sqrtPriceX96, result)
+}
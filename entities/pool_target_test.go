@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+func TestPool_SwapToPrice(t *testing.T) {
+	pool := newTwoPositionsTestPool(t)
+	assert.NoError(t, pool.SetFeeProtocol(4, 5))
+
+	for _, target := range []int32{-300, 450} {
+		var res SwapToPriceResult
+		assert.NoError(t, pool.SwapToTick(target, &res))
+		assert.Equal(t, target < 0, res.ZeroForOne)
+		assert.Equal(t, 1, res.CrossInitTickLoops, "пересекается один тик позиции bob")
+
+		var sqrtTarget utils.Uint160
+		pool.TickCalculator.GetSqrtRatioAtTickV2(target, &sqrtTarget)
+		assert.True(t, res.SqrtRatioX96.Eq(&sqrtTarget))
+		assert.Equal(t, uint64(1e18), res.Liquidity.Uint64())
+
+		// с sqrtPriceLimitX96 = цель exact input на AmountIn и exact output на AmountOut
+		// расходуются полностью и доводят цену ровно до цели
+		sr := &SwapResultV2{}
+		assert.NoError(t, pool.Swap(res.ZeroForOne, (*int256.Int)(res.AmountIn.Clone()), &sqrtTarget, sr))
+		assert.True(t, sr.SqrtRatioX96.Eq(&sqrtTarget))
+		assert.True(t, sr.RemainingAmountIn.IsZero())
+		assert.Equal(t, "-"+res.AmountOut.Dec(), sr.AmountCalculated.Dec())
+		assert.Equal(t, res.ProtocolFee, sr.ProtocolFee)
+
+		out := new(int256.Int).Neg((*int256.Int)(&res.AmountOut))
+		assert.NoError(t, pool.Swap(res.ZeroForOne, out, &sqrtTarget, sr))
+		assert.True(t, sr.SqrtRatioX96.Eq(&sqrtTarget))
+		assert.True(t, sr.RemainingAmountIn.IsZero())
+		assert.Equal(t, res.AmountIn.Dec(), sr.AmountCalculated.Dec())
+
+		// комиссия 0.05% от входа, округления вверх — на единицы wei
+		var fee utils.Uint256
+		fee.Mul(&res.AmountIn, fee.SetUint64(500))
+		fee.Div(&fee, uint256.NewInt(1e6))
+		assert.InDelta(t, fee.Uint64(), res.FeeAmount.Uint64(), 2)
+		assert.False(t, res.ProtocolFee.IsZero())
+	}
+
+	// пул не изменился, цель = текущая цена — пустой результат
+	assert.Equal(t, int32(0), pool.TickCurrent)
+	var res SwapToPriceResult
+	assert.NoError(t, pool.SwapToPrice(pool.SqrtRatioX96, &res))
+	assert.True(t, res.AmountIn.IsZero())
+	assert.True(t, res.AmountOut.IsZero())
+
+	assert.ErrorIs(t, pool.SwapToPrice(utils.MinSqrtRatioU256, &res), ErrSqrtPriceLimitX96TooLow)
+	assert.ErrorIs(t, pool.SwapToPrice(utils.MaxSqrtRatioU256, &res), ErrSqrtPriceLimitX96TooHigh)
+	assert.ErrorIs(t, pool.SwapToTick(utils.MaxTick+1, &res), ErrInvalidTickIndex)
+}
+
+// TestPool_SwapToPrice_MaxLiquidity: даже при максимальной ликвидности на всём диапазоне цен
+// maxSwapAmount не исчерпывается раньше цели — свап доходит до крайних цен с запасом.
+func TestPool_SwapToPrice_MaxLiquidity(t *testing.T) {
+	maxLiquidity := new(uint256.Int).Sub(new(uint256.Int).Lsh(uint256.NewInt(1), 128), uint256.NewInt(1))
+	lower := NearestUsableTick(utils.MinTick, 10)
+	upper := NearestUsableTick(utils.MaxTick, 10)
+	pool := newBoundedTestPool(lower, upper, 0, maxLiquidity)
+
+	var bound utils.Uint256
+	bound.Lsh(uint256.NewInt(1), 212)
+	for _, target := range []int32{lower, upper} {
+		var res SwapToPriceResult
+		assert.NoError(t, pool.SwapToTick(target, &res))
+
+		var sqrtTarget utils.Uint160
+		pool.TickCalculator.GetSqrtRatioAtTickV2(target, &sqrtTarget)
+		assert.True(t, res.SqrtRatioX96.Eq(&sqrtTarget), "цель %d достигнута", target)
+		assert.True(t, res.AmountIn.Lt(&bound), "amountIn %s", res.AmountIn.Dec())
+	}
+}
@@ -33,6 +33,13 @@ type Position struct {
 	PriceUpper  *utils.Uint160
 	Liquidity   *utils.Uint128
 
+	// fee-учёт позиции как в NonfungiblePositionManager.Position:
+	// feeGrowthInside на момент последнего обновления и уже начисленные, но не собранные комиссии.
+	FeeGrowthInside0LastX128 utils.Uint256
+	FeeGrowthInside1LastX128 utils.Uint256
+	TokensOwed0              utils.Uint128
+	TokensOwed1              utils.Uint128
+
 	// static cache
 	token0Amount           *entities.CurrencyAmount
 	token1Amount           *entities.CurrencyAmount
@@ -171,6 +178,94 @@ func (p *Position) CalcAmounts() (*utils.Uint256, *utils.Uint256) {
 	return Zero, p.amount2Tmp
 }
 
+// CheckpointFeeGrowthInside запоминает текущий feeGrowthInside диапазона позиции без начисления
+// комиссий — так NonfungiblePositionManager.mint инициализирует новую позицию.
+func (p *Position) CheckpointFeeGrowthInside() {
+	p.Pool.GetFeeGrowthInside(p.TickLower, p.TickUpper, &p.FeeGrowthInside0LastX128, &p.FeeGrowthInside1LastX128)
+}
+
+// CalcTokensOwed возвращает tokensOwed0/1 позиции с учётом комиссий, накопленных с последнего
+// checkpoint'а, не меняя саму позицию. Результат бит-в-бит совпадает с тем, что
+// NonfungiblePositionManager.collect насчитал бы после burn(0) при текущем состоянии пула:
+// uint128(mulDiv(feeGrowthInside - feeGrowthInsideLast, liquidity, Q128)), суммы — по модулю 2^128.
+func (p *Position) CalcTokensOwed(tokensOwed0, tokensOwed1 *utils.Uint128) error {
+	var feeGrowthInside0X128, feeGrowthInside1X128 utils.Uint256
+	p.Pool.GetFeeGrowthInside(p.TickLower, p.TickUpper, &feeGrowthInside0X128, &feeGrowthInside1X128)
+
+	if err := accruedFees(&feeGrowthInside0X128, &p.FeeGrowthInside0LastX128, p.Liquidity, tokensOwed0); err != nil {
+		return err
+	}
+	if err := accruedFees(&feeGrowthInside1X128, &p.FeeGrowthInside1LastX128, p.Liquidity, tokensOwed1); err != nil {
+		return err
+	}
+
+	addUint128(tokensOwed0, &p.TokensOwed0)
+	addUint128(tokensOwed1, &p.TokensOwed1)
+	return nil
+}
+
+// UpdateTokensOwed начисляет накопленные комиссии в TokensOwed0/1 и переносит checkpoint
+// feeGrowthInside на текущее значение — как increaseLiquidity/decreaseLiquidity/collect в NPM.
+func (p *Position) UpdateTokensOwed() error {
+	var tokensOwed0, tokensOwed1 utils.Uint128
+	if err := p.CalcTokensOwed(&tokensOwed0, &tokensOwed1); err != nil {
+		return err
+	}
+
+	p.TokensOwed0 = tokensOwed0
+	p.TokensOwed1 = tokensOwed1
+	p.CheckpointFeeGrowthInside()
+	return nil
+}
+
+// accruedFees считает uint128(mulDiv(feeGrowthInside - feeGrowthInsideLast, liquidity, Q128)) —
+// общая формула Position.update ядра и NonfungiblePositionManager.
+func accruedFees(feeGrowthInsideX128, feeGrowthInsideLastX128 *utils.Uint256, liquidity *utils.Uint128, result *utils.Uint128) error {
+	var delta utils.Uint256
+	delta.Sub(feeGrowthInsideX128, feeGrowthInsideLastX128)
+
+	if err := mulDiv(&delta, liquidity, q128, result); err != nil {
+		return err
+	}
+	truncateUint128(result)
+	return nil
+}
+
+// mulDiv — result = floor(x·y/denominator) без scratch пула (Pool.fullMath), поэтому безопасен
+// в read-only методах, которые могут вызываться параллельно со Swap и друг с другом.
+func mulDiv(x, y, denominator, result *utils.Uint256) error {
+	if _, overflow := result.MulDivOverflow(x, y, denominator); overflow {
+		return utils.ErrMulDivOverflow
+	}
+	return nil
+}
+
+// mulDivRoundingUp — ceil(x·y/denominator), как mulDiv.
+func mulDivRoundingUp(x, y, denominator, result *utils.Uint256) error {
+	var remainder utils.Uint256
+	remainder.MulMod(x, y, denominator)
+	if err := mulDiv(x, y, denominator, result); err != nil {
+		return err
+	}
+	if !remainder.IsZero() {
+		if result.Eq(utils.MaxUint256) {
+			return utils.ErrInvariant
+		}
+		result.AddUint64(result, 1)
+	}
+	return nil
+}
+
+// addUint128 — x += y с переполнением по модулю 2^128, как uint128 += в solidity 0.7.
+func addUint128(x, y *utils.Uint128) {
+	x.Add(x, y)
+	truncateUint128(x)
+}
+
+func truncateUint128(x *utils.Uint128) {
+	x[2], x[3] = 0, 0
+}
+
 /**
  * Returns the lower and upper sqrt ratios if the price 'slips' up to slippage tolerance percentage
  * @param slippageTolerance The amount by which the price can 'slip' before the transaction will revert
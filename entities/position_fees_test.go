@@ -0,0 +1,142 @@
+package entities
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// expectedOwed — эталон NPM через math/big: mulDiv(mulDiv(fee, Q128, L), liquidity, Q128).
+func expectedOwed(fee, poolLiquidity, positionLiquidity *uint256.Int) *uint256.Int {
+	q128 := new(big.Int).Lsh(big.NewInt(1), 128)
+	g := new(big.Int).Mul(fee.ToBig(), q128)
+	g.Quo(g, poolLiquidity.ToBig())
+	g.Mul(g, positionLiquidity.ToBig())
+	g.Quo(g, q128)
+	return uint256.MustFromBig(g)
+}
+
+// newTwoPositionsPool: позиция A = [-100, 100) с L1 и позиция B = [100, 200) с L2, текущий тик 0.
+func newTwoPositionsPool(L1, L2 *uint256.Int) *Pool {
+	l1 := int256.MustFromDec(L1.Dec())
+	l2 := int256.MustFromDec(L2.Dec())
+
+	th := NewTicksHandler()
+	th.SetTicks([]Tick{
+		{Index: -100, LiquidityGross: L1.Clone(), LiquidityNet: l1.Clone()},
+		{Index: 100, LiquidityGross: new(uint256.Int).Add(L1, L2), LiquidityNet: new(int256.Int).Sub(l2, l1)},
+		{Index: 200, LiquidityGross: L2.Clone(), LiquidityNet: new(int256.Int).Neg(l2)},
+	})
+
+	var sqrtP utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(0, &sqrtP)
+	p := NewPoolV3(common.Address{}, uint16(constants.FeeLow), 0, &sqrtP, USDC, DAI, th)
+	p.Liquidity = L1.Clone()
+	return p
+}
+
+// TestPosition_CalcTokensOwed_InRange: вся ликвидность пула — одна позиция, свап внутри диапазона.
+func TestPosition_CalcTokensOwed_InRange(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 0, L)
+
+	position, err := NewPosition(pool, L.Clone(), -100, 100)
+	assert.NoError(t, err)
+	position.CheckpointFeeGrowthInside()
+
+	outside, err := NewPosition(pool, L.Clone(), 200, 300)
+	assert.NoError(t, err)
+	outside.CheckpointFeeGrowthInside()
+
+	sr := &SwapResultV2{StepsFee: []StepFeeResult{}}
+	assert.NoError(t, pool.SwapCommit(true, int256.NewInt(1e12), nil, sr, nil))
+	assert.Len(t, sr.StepsFee, 1)
+
+	var owed0, owed1 utils.Uint128
+	assert.NoError(t, position.CalcTokensOwed(&owed0, &owed1))
+	assert.True(t, owed0.Eq(expectedOwed(&sr.StepsFee[0].FeeAmount, L, L)), "got %s", owed0.Dec())
+	assert.True(t, owed1.IsZero())
+	assert.True(t, position.TokensOwed0.IsZero(), "CalcTokensOwed не меняет позицию")
+
+	assert.NoError(t, outside.CalcTokensOwed(&owed0, &owed1))
+	assert.True(t, owed0.IsZero(), "позиция вне диапазона свапа комиссий не получает")
+	assert.True(t, owed1.IsZero())
+}
+
+// TestPosition_CalcTokensOwed_Concurrent: CalcTokensOwed и FlashFees не пишут в scratch пула —
+// параллельные вызовы на одном пуле дают один и тот же результат.
+func TestPosition_CalcTokensOwed_Concurrent(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	pool := newBoundedTestPool(-100, 100, 0, L)
+	position, err := NewPosition(pool, L.Clone(), -100, 100)
+	assert.NoError(t, err)
+	position.CheckpointFeeGrowthInside()
+	assert.NoError(t, pool.SwapCommit(true, int256.NewInt(1e12), nil, &SwapResultV2{}, nil))
+
+	var want0, want1 utils.Uint128
+	assert.NoError(t, position.CalcTokensOwed(&want0, &want1))
+	assert.False(t, want0.IsZero())
+
+	pool.fullMath = nil
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var owed0, owed1, fee0, fee1 utils.Uint256
+			assert.NoError(t, position.CalcTokensOwed(&owed0, &owed1))
+			assert.Equal(t, want0, owed0)
+			assert.Equal(t, want1, owed1)
+			assert.NoError(t, pool.FlashFees(uint256.NewInt(1e18), uint256.NewInt(1), &fee0, &fee1))
+			assert.Equal(t, uint64(5e14), fee0.Uint64())
+		}()
+	}
+	wg.Wait()
+}
+
+// TestPosition_CalcTokensOwed_AcrossTick: свап пересекает тик 100, каждая позиция получает
+// комиссии только за свой шаг — ровно как посчитал бы NPM.
+func TestPosition_CalcTokensOwed_AcrossTick(t *testing.T) {
+	L1 := uint256.NewInt(1e18)
+	L2 := uint256.NewInt(5e17)
+	pool := newTwoPositionsPool(L1, L2)
+
+	a, err := NewPosition(pool, L1.Clone(), -100, 100)
+	assert.NoError(t, err)
+	a.CheckpointFeeGrowthInside()
+	b, err := NewPosition(pool, L2.Clone(), 100, 200)
+	assert.NoError(t, err)
+	b.CheckpointFeeGrowthInside()
+
+	var limit utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(150, &limit)
+	amountIn, _ := int256.FromDec("1000000000000000000000000")
+
+	sr := &SwapResultV2{StepsFee: []StepFeeResult{}}
+	assert.NoError(t, pool.SwapCommit(false, amountIn, &limit, sr, nil))
+	assert.Equal(t, 1, sr.CrossInitTickLoops)
+	assert.Len(t, sr.StepsFee, 2)
+
+	var owed0, owed1 utils.Uint128
+	assert.NoError(t, a.CalcTokensOwed(&owed0, &owed1))
+	assert.True(t, owed0.IsZero())
+	assert.True(t, owed1.Eq(expectedOwed(&sr.StepsFee[0].FeeAmount, L1, L1)), "A: got %s", owed1.Dec())
+
+	assert.NoError(t, b.CalcTokensOwed(&owed0, &owed1))
+	assert.True(t, owed1.Eq(expectedOwed(&sr.StepsFee[1].FeeAmount, L2, L2)), "B: got %s", owed1.Dec())
+
+	// UpdateTokensOwed начисляет и переносит checkpoint: повторный расчёт без новых свапов не меняет сумму
+	assert.NoError(t, b.UpdateTokensOwed())
+	assert.True(t, b.TokensOwed1.Eq(&owed1))
+	var again0, again1 utils.Uint128
+	assert.NoError(t, b.CalcTokensOwed(&again0, &again1))
+	assert.True(t, again1.Eq(&owed1))
+}
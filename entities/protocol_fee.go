@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var ErrInvalidFeeProtocol = errors.New("fee protocol must be 0 or in [4, 10]")
+
+// SetFeeProtocol — аналог UniswapV3Pool.setFeeProtocol: протокол забирает 1/feeProtocolN
+// комиссии каждого шага свапа в токене N; 0 выключает долю протокола.
+func (p *Pool) SetFeeProtocol(feeProtocol0, feeProtocol1 uint8) error {
+	if !isValidFeeProtocol(feeProtocol0) || !isValidFeeProtocol(feeProtocol1) {
+		return ErrInvalidFeeProtocol
+	}
+	p.FeeProtocol0 = feeProtocol0
+	p.FeeProtocol1 = feeProtocol1
+	return nil
+}
+
+// SetFeeProtocolPacked принимает slot0.feeProtocol в упакованном виде, как он читается из
+// контракта: младшие 4 бита — токен 0, старшие — токен 1.
+func (p *Pool) SetFeeProtocolPacked(feeProtocol uint8) error {
+	return p.SetFeeProtocol(feeProtocol%16, feeProtocol>>4)
+}
+
+// CollectProtocol — аналог UniswapV3Pool.collectProtocol: выводит min(requested, ProtocolFeesN),
+// оставляя в пуле 1 wei при полном выводе, как контракт (экономия газа на SSTORE).
+func (p *Pool) CollectProtocol(amount0Requested, amount1Requested *utils.Uint128, amount0, amount1 *utils.Uint128) {
+	collectProtocolFee(&p.ProtocolFees0, amount0Requested, amount0)
+	collectProtocolFee(&p.ProtocolFees1, amount1Requested, amount1)
+}
+
+func collectProtocolFee(protocolFees, requested, amount *utils.Uint128) {
+	if requested.Gt(protocolFees) {
+		amount.Set(protocolFees)
+	} else {
+		amount.Set(requested)
+	}
+	if amount.IsZero() {
+		return
+	}
+	// ensure that the slot is not cleared, for gas savings
+	if amount.Eq(protocolFees) {
+		amount.SubUint64(amount, 1)
+	}
+	protocolFees.Sub(protocolFees, amount)
+}
+
+func isValidFeeProtocol(feeProtocol uint8) bool {
+	return feeProtocol == 0 || (feeProtocol >= 4 && feeProtocol <= 10)
+}
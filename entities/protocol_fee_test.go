@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+func TestPool_SetFeeProtocol(t *testing.T) {
+	pool := newBoundedTestPool(-100, 100, 0, uint256.NewInt(1e18))
+
+	assert.ErrorIs(t, pool.SetFeeProtocol(3, 0), ErrInvalidFeeProtocol)
+	assert.ErrorIs(t, pool.SetFeeProtocol(0, 11), ErrInvalidFeeProtocol)
+	assert.NoError(t, pool.SetFeeProtocol(4, 10))
+
+	// 0x64: токен 0 — 4, токен 1 — 6
+	assert.NoError(t, pool.SetFeeProtocolPacked(0x64))
+	assert.Equal(t, uint8(4), pool.FeeProtocol0)
+	assert.Equal(t, uint8(6), pool.FeeProtocol1)
+}
+
+// TestSwap_ProtocolFeeSplit: протокол забирает fee/feeProtocol каждого шага, LP-часть — остаток,
+// FeeAmount и суммы свапа не меняются; fee growth считается только по LP-части.
+func TestSwap_ProtocolFeeSplit(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	amountIn := int256.NewInt(1e12)
+
+	reference := newBoundedTestPool(-100, 100, 0, L)
+	refResult := &SwapResultV2{StepsFee: []StepFeeResult{}}
+	assert.NoError(t, reference.Swap(true, amountIn, nil, refResult))
+	assert.True(t, refResult.ProtocolFee.IsZero())
+	assert.True(t, refResult.StepsFee[0].ProtocolFeeAmount.IsZero())
+	totalFee := refResult.StepsFee[0].FeeAmount
+	assert.True(t, refResult.StepsFee[0].LPFeeAmount.Eq(&totalFee))
+	refAmount := refResult.AmountCalculated.Clone()
+
+	pool := newBoundedTestPool(-100, 100, 0, L)
+	assert.NoError(t, pool.SetFeeProtocol(4, 0))
+
+	sr := &SwapResultV2{StepsFee: []StepFeeResult{}}
+	assert.NoError(t, pool.SwapCommit(true, amountIn, nil, sr, nil))
+	assert.True(t, sr.AmountCalculated.Eq(refAmount), "доля протокола не влияет на суммы свапа")
+	assert.Len(t, sr.StepsFee, 1)
+
+	step := sr.StepsFee[0]
+	wantProtocol := new(uint256.Int).Div(&totalFee, uint256.NewInt(4))
+	assert.True(t, step.ProtocolFeeAmount.Eq(wantProtocol))
+	assert.True(t, step.FeeAmount.Eq(&totalFee), "FeeAmount — вся комиссия шага")
+	assert.True(t, new(uint256.Int).Add(&step.LPFeeAmount, &step.ProtocolFeeAmount).Eq(&totalFee))
+	assert.True(t, sr.ProtocolFee.Eq(wantProtocol))
+	assert.True(t, pool.ProtocolFees0.Eq(wantProtocol))
+	assert.True(t, pool.ProtocolFees1.IsZero())
+	assert.True(t, pool.FeeGrowthGlobal0X128.Eq(expectedFeeGrowth(&step.LPFeeAmount, L)))
+
+	// feeProtocol1 = 0: свап в обратную сторону протоколу ничего не отдаёт
+	sr = &SwapResultV2{StepsFee: []StepFeeResult{}}
+	assert.NoError(t, pool.Swap(false, amountIn, nil, sr))
+	assert.True(t, sr.ProtocolFee.IsZero())
+	assert.True(t, sr.StepsFee[0].ProtocolFeeAmount.IsZero())
+
+	// read-only Swap долю считает, но ProtocolFees не накапливает
+	assert.NoError(t, pool.Swap(true, amountIn, nil, sr))
+	assert.False(t, sr.ProtocolFee.IsZero())
+	assert.True(t, pool.ProtocolFees0.Eq(wantProtocol))
+}
+
+func TestPool_CollectProtocol(t *testing.T) {
+	pool := newBoundedTestPool(-100, 100, 0, uint256.NewInt(1e18))
+	pool.ProtocolFees0.SetUint64(100)
+	pool.ProtocolFees1.SetUint64(50)
+
+	var amount0, amount1 utils.Uint128
+	pool.CollectProtocol(uint256.NewInt(30), utils.Uint128Max, &amount0, &amount1)
+	assert.Equal(t, uint64(30), amount0.Uint64())
+	assert.Equal(t, uint64(49), amount1.Uint64(), "при полном выводе в пуле остаётся 1 wei")
+	assert.Equal(t, uint64(70), pool.ProtocolFees0.Uint64())
+	assert.Equal(t, uint64(1), pool.ProtocolFees1.Uint64())
+}
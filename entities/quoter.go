@@ -0,0 +1,267 @@
+package entities
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/holiman/uint256"
+	"github.com/vuquang23/int256"
+)
+
+// ErrQuoteAmountOutMismatch — exact output свап без лимита цены отдал меньше запрошенного:
+// QuoterV2 в этом случае ревертит (require(amountReceived == amountOutCached)).
+var ErrQuoteAmountOutMismatch = errors.New("quote amount received does not match amount out")
+
+// QuoteResult — то, что возвращают quoteExactInput/quoteExactOutput QuoterV2. Списки идут в порядке
+// закодированного пути: для exact output — от последнего пула маршрута к первому.
+type QuoteResult struct {
+	Amount                      *big.Int // amountOut для exact input, amountIn для exact output
+	SqrtPriceX96AfterList       []*big.Int
+	InitializedTicksCrossedList []uint32
+	GasEstimate                 *big.Int
+}
+
+// QuoteSingleResult — то, что возвращают quoteExactInputSingle/quoteExactOutputSingle QuoterV2.
+type QuoteSingleResult struct {
+	Amount                  *big.Int // amountOut для exact input, amountIn для exact output
+	SqrtPriceX96After       *big.Int
+	InitializedTicksCrossed uint32
+	GasEstimate             *big.Int
+}
+
+// QuoteExactInputSingle — QuoterV2.quoteExactInputSingle по локальному состоянию pool.
+// sqrtPriceLimitX96 = nil — без лимита (0 в контракте). gasModel = nil — mainnet-константы GasModel;
+// GasEstimate — эвристика, а не замер газа, как on-chain.
+func QuoteExactInputSingle(pool *Pool, tokenIn *entities.Token, amountIn *big.Int, sqrtPriceLimitX96 *big.Int, gasModel *GasModel) (*QuoteSingleResult, error) {
+	hop, err := quoteHop(pool, tokenIn, amountIn, sqrtPriceLimitX96, entities.ExactInput)
+	if err != nil {
+		return nil, err
+	}
+	return hop.single(gasModel), nil
+}
+
+// QuoteExactOutputSingle — QuoterV2.quoteExactOutputSingle по локальному состоянию pool; tokenIn —
+// входной токен свапа.
+func QuoteExactOutputSingle(pool *Pool, tokenIn *entities.Token, amountOut *big.Int, sqrtPriceLimitX96 *big.Int, gasModel *GasModel) (*QuoteSingleResult, error) {
+	hop, err := quoteHop(pool, tokenIn, amountOut, sqrtPriceLimitX96, entities.ExactOutput)
+	if err != nil {
+		return nil, err
+	}
+	return hop.single(gasModel), nil
+}
+
+// QuoteOptions — параметры QuoteExactInput/QuoteExactOutput; nil — значения по умолчанию.
+type QuoteOptions struct {
+	// GasModel — модель для GasEstimate; nil — mainnet-константы GasModel.
+	GasModel *GasModel
+	// QuoterV2Parity — котировать каждый хоп по исходному состоянию его пула, как on-chain QuoterV2
+	// (его свапы ревертятся). По умолчанию хопы видят состояние пулов после предыдущих хопов маршрута,
+	// как при исполнении через SwapRouter: повторный заход в пул идёт по сдвинутой цене.
+	QuoterV2Parity bool
+}
+
+// QuoteExactInput — QuoterV2.quoteExactInput по route: выход каждого пула — вход следующего,
+// состояние пулов передаётся от хопа к хопу (см. QuoteOptions.QuoterV2Parity).
+func QuoteExactInput(route *Route, amountIn *entities.CurrencyAmount, opts *QuoteOptions) (*QuoteResult, error) {
+	if !amountIn.Currency.Wrapped().Equal(route.Input.Wrapped()) {
+		return nil, ErrInvalidAmountForRoute
+	}
+	opts = quoteOptions(opts)
+	res := newQuoteResult(len(route.Pools))
+	states := make(poolStates, len(route.Pools))
+	amount := amountIn.Quotient()
+	for i := range route.Pools {
+		hop, err := quoteRouteHop(states, route.Pools[i], route.TokenPath[i], amount, entities.ExactInput, opts)
+		if err != nil {
+			return nil, err
+		}
+		res.add(hop)
+		amount = hop.amount
+	}
+	res.Amount = amount
+	res.GasEstimate = res.gasEstimate(opts.GasModel)
+	return res, nil
+}
+
+// QuoteExactOutput — QuoterV2.quoteExactOutput по route: пулы котируются с конца, вход каждого —
+// выход предыдущего по маршруту. Состояние передаётся в том же порядке — от последнего пула к первому,
+// как их меняет SwapRouter.exactOutput.
+func QuoteExactOutput(route *Route, amountOut *entities.CurrencyAmount, opts *QuoteOptions) (*QuoteResult, error) {
+	if !amountOut.Currency.Wrapped().Equal(route.Output.Wrapped()) {
+		return nil, ErrInvalidAmountForRoute
+	}
+	opts = quoteOptions(opts)
+	res := newQuoteResult(len(route.Pools))
+	states := make(poolStates, len(route.Pools))
+	amount := amountOut.Quotient()
+	for i := len(route.Pools) - 1; i >= 0; i-- {
+		hop, err := quoteRouteHop(states, route.Pools[i], route.TokenPath[i], amount, entities.ExactOutput, opts)
+		if err != nil {
+			return nil, err
+		}
+		res.add(hop)
+		amount = hop.amount
+	}
+	res.Amount = amount
+	res.GasEstimate = res.gasEstimate(opts.GasModel)
+	return res, nil
+}
+
+func quoteOptions(opts *QuoteOptions) *QuoteOptions {
+	if opts == nil {
+		return &QuoteOptions{}
+	}
+	return opts
+}
+
+// quoteRouteHop котирует хоп маршрута по текущему в states состоянию пула и, если хопы связаны,
+// запоминает состояние пула после свапа.
+func quoteRouteHop(states poolStates, pool *Pool, tokenIn *entities.Token, amount *big.Int, tradeType entities.TradeType, opts *QuoteOptions) (*quotedHop, error) {
+	if opts.QuoterV2Parity {
+		return quoteHop(pool, tokenIn, amount, nil, tradeType)
+	}
+	addr, current, err := states.current(pool)
+	if err != nil {
+		return nil, err
+	}
+	hop, err := quoteHop(current, tokenIn, amount, nil, tradeType)
+	if err != nil {
+		return nil, err
+	}
+	states[addr] = hop.poolAfter
+	return hop, nil
+}
+
+// quotedHop — итог котировки одного пула.
+type quotedHop struct {
+	amount                  *big.Int
+	sqrtPriceX96After       *big.Int
+	initializedTicksCrossed uint32
+	poolAfter               *Pool
+}
+
+func (h *quotedHop) single(gasModel *GasModel) *QuoteSingleResult {
+	return &QuoteSingleResult{
+		Amount:                  h.amount,
+		SqrtPriceX96After:       h.sqrtPriceX96After,
+		InitializedTicksCrossed: h.initializedTicksCrossed,
+		GasEstimate:             new(big.Int).SetUint64(quoterGasModel(gasModel).RouteGas(1, int(h.initializedTicksCrossed))),
+	}
+}
+
+func newQuoteResult(hops int) *QuoteResult {
+	return &QuoteResult{
+		SqrtPriceX96AfterList:       make([]*big.Int, 0, hops),
+		InitializedTicksCrossedList: make([]uint32, 0, hops),
+	}
+}
+
+func (r *QuoteResult) add(hop *quotedHop) {
+	r.SqrtPriceX96AfterList = append(r.SqrtPriceX96AfterList, hop.sqrtPriceX96After)
+	r.InitializedTicksCrossedList = append(r.InitializedTicksCrossedList, hop.initializedTicksCrossed)
+}
+
+func (r *QuoteResult) gasEstimate(gasModel *GasModel) *big.Int {
+	var ticksCrossed int
+	for _, n := range r.InitializedTicksCrossedList {
+		ticksCrossed += int(n)
+	}
+	return new(big.Int).SetUint64(quoterGasModel(gasModel).RouteGas(len(r.InitializedTicksCrossedList), ticksCrossed))
+}
+
+func quoterGasModel(gasModel *GasModel) *GasModel {
+	if gasModel == nil {
+		return NewGasModel(nil, nil)
+	}
+	return gasModel
+}
+
+// quoteHop — read-only свап tokenIn через pool, как quoteExact{Input,Output}Single QuoterV2.
+func quoteHop(pool *Pool, tokenIn *entities.Token, amount *big.Int, sqrtPriceLimitX96 *big.Int, tradeType entities.TradeType) (*quotedHop, error) {
+	if !pool.InvolvesToken(tokenIn) {
+		return nil, ErrTokenNotInvolved
+	}
+	zeroForOne := tokenIn.Equal(pool.Token0)
+	amountSpecified, err := int256.FromBig(amount)
+	if err != nil {
+		return nil, err
+	}
+	if tradeType == entities.ExactOutput {
+		amountSpecified.Neg(amountSpecified)
+	}
+	var limit *utils.Uint160
+	if sqrtPriceLimitX96 != nil && sqrtPriceLimitX96.Sign() != 0 {
+		var overflow bool
+		if limit, overflow = uint256.FromBig(sqrtPriceLimitX96); overflow || sqrtPriceLimitX96.Sign() < 0 {
+			return nil, utils.ErrOverflowUint160
+		}
+		if err = utils.CheckToUint160(limit); err != nil {
+			return nil, err
+		}
+	}
+
+	swapResult := new(SwapResultV2)
+	if err = pool.Swap(zeroForOne, amountSpecified, limit, swapResult); err != nil {
+		return nil, err
+	}
+	hop := &quotedHop{
+		sqrtPriceX96After:       swapResult.SqrtRatioX96.ToBig(),
+		initializedTicksCrossed: countInitializedTicksCrossed(pool, pool.TickCurrent, swapResult.CurrentTick),
+		poolAfter:               pool.postSwapPool(swapResult),
+	}
+	if tradeType == entities.ExactInput {
+		hop.amount = new(utils.Int256).Neg(swapResult.AmountCalculated).ToBig()
+	} else {
+		if limit == nil && !swapResult.RemainingAmountIn.IsZero() {
+			return nil, ErrQuoteAmountOutMismatch
+		}
+		hop.amount = swapResult.AmountCalculated.ToBig()
+	}
+	return hop, nil
+}
+
+// countInitializedTicksCrossed — PoolTicksCounter.countInitializedTicksCrossed периферии: число
+// инициализированных тиков между tickBefore и tickAfter по bitmap, где tickAfter считается только
+// при свапе вверх, а tickBefore — только при свапе вниз. Сжатие tick/tickSpacing — с округлением
+// к нулю, как в контракте.
+func countInitializedTicksCrossed(pool *Pool, tickBefore, tickAfter int32) uint32 {
+	provider := pool.TickDataProvider
+	if provider == nil {
+		return 0
+	}
+	tickSpacing := int32(pool.TickSpacing)
+	if tickSpacing <= 0 {
+		tickSpacing = 1
+	}
+
+	lower, upper := tickBefore/tickSpacing, tickAfter/tickSpacing
+	if lower > upper {
+		lower, upper = upper, lower
+	}
+	var crossed uint32
+	for next := lower*tickSpacing - 1; ; {
+		idx, initialized, err := provider.NextInitializedTickIndex(next, false)
+		if err != nil || idx > upper*tickSpacing {
+			break
+		}
+		if initialized {
+			crossed++
+		}
+		next = idx
+	}
+
+	if tickAfter%tickSpacing == 0 && tickBefore > tickAfter && isTickInitialized(provider, tickAfter) {
+		crossed--
+	}
+	if tickBefore%tickSpacing == 0 && tickBefore < tickAfter && isTickInitialized(provider, tickBefore) {
+		crossed--
+	}
+	return crossed
+}
+
+func isTickInitialized(provider TickDataProvider, tick int32) bool {
+	t, err := provider.GetTick(tick)
+	return err == nil && t.LiquidityGross != nil && !t.LiquidityGross.IsZero()
+}
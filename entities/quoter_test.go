@@ -0,0 +1,124 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vuquang23/int256"
+)
+
+func TestQuoteExactInputSingle(t *testing.T) {
+	// позиции [-100, 100) и [100, 200): свап DAI -> USDC вверх пересекает тик 100
+	pool := newTwoPositionsPool(uint256.NewInt(1e18), uint256.NewInt(1e18))
+	amountIn := big.NewInt(7e15)
+
+	quote, err := QuoteExactInputSingle(pool, DAI, amountIn, nil, nil)
+	require.NoError(t, err)
+
+	out, err := pool.GetOutputAmount(entities.FromRawAmount(DAI, amountIn), nil)
+	require.NoError(t, err)
+	assert.Equal(t, out.ReturnedAmount.Quotient(), quote.Amount)
+
+	sr := new(SwapResultV2)
+	require.NoError(t, pool.Swap(false, int256.MustFromBig(amountIn), nil, sr))
+	assert.Equal(t, sr.SqrtRatioX96.ToBig(), quote.SqrtPriceX96After)
+	assert.Equal(t, uint32(1), quote.InitializedTicksCrossed)
+	assert.Equal(t, big.NewInt(BaseSwapCost+CostPerHop+CostPerInitTick), quote.GasEstimate)
+
+	// свап внутри диапазона: ни одного инициализированного тика
+	quote, err = QuoteExactInputSingle(pool, USDC, big.NewInt(1e12), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), quote.InitializedTicksCrossed)
+
+	// стартовый инициализированный тик при свапе вверх не считается, как в PoolTicksCounter
+	pool = newBoundedTestPool(0, 200, 0, uint256.NewInt(1e18))
+	quote, err = QuoteExactInputSingle(pool, DAI, big.NewInt(1e15), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), quote.InitializedTicksCrossed)
+}
+
+func TestQuoteExactOutputSingle(t *testing.T) {
+	pool := newTwoPositionsPool(uint256.NewInt(1e18), uint256.NewInt(1e18))
+
+	quote, err := QuoteExactOutputSingle(pool, DAI, big.NewInt(1e15), nil, nil)
+	require.NoError(t, err)
+	in, _, err := pool.GetInputAmount(entities.FromRawAmount(USDC, big.NewInt(1e15)), nil)
+	require.NoError(t, err)
+	assert.Equal(t, in.Quotient(), quote.Amount)
+
+	// выход больше всей ликвидности пула: QuoterV2 ревертит
+	_, err = QuoteExactOutputSingle(pool, DAI, new(big.Int).Mul(OneEther, big.NewInt(10)), nil, nil)
+	assert.ErrorIs(t, err, ErrQuoteAmountOutMismatch)
+}
+
+func TestQuoteExactInput_MultiHop(t *testing.T) {
+	_, _, viaWeth := newSplitTestRoutes(t)
+	amountIn := entities.FromRawAmount(USDC, big.NewInt(1e15))
+
+	quote, err := QuoteExactInput(viaWeth, amountIn, nil)
+	require.NoError(t, err)
+	trade, err := ExactIn(viaWeth, amountIn)
+	require.NoError(t, err)
+	assert.Equal(t, trade.OutputAmount().Quotient(), quote.Amount)
+	require.Len(t, quote.SqrtPriceX96AfterList, 2)
+	assert.Equal(t, []uint32{0, 0}, quote.InitializedTicksCrossedList)
+	assert.Equal(t, big.NewInt(BaseSwapCost+2*CostPerHop+CostPerInitTick), quote.GasEstimate)
+
+	_, err = QuoteExactInput(viaWeth, entities.FromRawAmount(DAI, big.NewInt(1e15)), nil)
+	assert.ErrorIs(t, err, ErrInvalidAmountForRoute)
+}
+
+func TestQuoteExactOutput_MultiHop(t *testing.T) {
+	_, _, viaWeth := newSplitTestRoutes(t)
+	amountOut := entities.FromRawAmount(DAI, big.NewInt(1e15))
+
+	quote, err := QuoteExactOutput(viaWeth, amountOut, nil)
+	require.NoError(t, err)
+	trade, err := ExactOut(viaWeth, amountOut)
+	require.NoError(t, err)
+	assert.Equal(t, trade.InputAmount().Quotient(), quote.Amount)
+
+	// списки — в порядке пути quoteExactOutput: сначала последний пул маршрута
+	last, err := QuoteExactOutputSingle(viaWeth.Pools[1], viaWeth.TokenPath[1], amountOut.Quotient(), nil, nil)
+	require.NoError(t, err)
+	require.Len(t, quote.SqrtPriceX96AfterList, 2)
+	assert.Equal(t, last.SqrtPriceX96After, quote.SqrtPriceX96AfterList[0])
+}
+
+func TestQuoteExactInput_RevisitedPool(t *testing.T) {
+	pool := newSplitTestPool(USDC, DAI, constants.FeeLow, OneEther)
+	route, err := NewRoute([]*Pool{pool, pool}, USDC, USDC)
+	require.NoError(t, err)
+	amountIn := entities.FromRawAmount(USDC, big.NewInt(1e16))
+
+	// второй заход в пул котируется по цене после первого, как SimulateRoute
+	quote, err := QuoteExactInput(route, amountIn, nil)
+	require.NoError(t, err)
+	sim, err := SimulateRoute(route, amountIn, entities.ExactInput)
+	require.NoError(t, err)
+	assert.Equal(t, sim.Amounts[2].Quotient(), quote.Amount)
+	assert.Equal(t, sim.PoolsAfter[1].SqrtRatioX96.ToBig(), quote.SqrtPriceX96AfterList[1])
+
+	// QuoterV2Parity: оба хопа по исходному состоянию пула, как on-chain
+	parity, err := QuoteExactInput(route, amountIn, &QuoteOptions{QuoterV2Parity: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, quote.Amount, parity.Amount)
+	first, err := QuoteExactInputSingle(pool, USDC, amountIn.Quotient(), nil, nil)
+	require.NoError(t, err)
+	second, err := QuoteExactInputSingle(pool, DAI, first.Amount, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, second.Amount, parity.Amount)
+
+	// exact output: состояние передаётся от последнего пула к первому
+	amountOut := entities.FromRawAmount(USDC, big.NewInt(1e16))
+	quote, err = QuoteExactOutput(route, amountOut, nil)
+	require.NoError(t, err)
+	sim, err = SimulateRoute(route, amountOut, entities.ExactOutput)
+	require.NoError(t, err)
+	assert.Equal(t, sim.Amounts[0].Quotient(), quote.Amount)
+}
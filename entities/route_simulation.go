@@ -0,0 +1,87 @@
+package entities
+
+import (
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// RouteSimulation — итог последовательных свапов по маршруту.
+type RouteSimulation struct {
+	// Amounts — суммы вдоль route.TokenPath: Amounts[0] — вход, последняя — выход.
+	Amounts []*entities.CurrencyAmount
+	// PoolsAfter — состояние пула каждого хопа сразу после его свапа, по индексам route.Pools.
+	PoolsAfter []*Pool
+}
+
+// SimulateRoute прогоняет amount через route, передавая каждому хопу состояние его пула после
+// предыдущих свапов маршрута: если маршрут заходит в пул повторно (пул определяется по адресу,
+// как в newTrade), второй свап идёт уже по сдвинутой цене. Exact input свапает пулы от первого
+// к последнему, exact output — от последнего к первому, в том же порядке, в каком SwapRouter
+// меняет состояние пулов. Исходные пулы маршрута не меняются.
+func SimulateRoute(route *Route, amount *entities.CurrencyAmount, tradeType entities.TradeType) (*RouteSimulation, error) {
+	n := len(route.Pools)
+	sim := &RouteSimulation{
+		Amounts:    make([]*entities.CurrencyAmount, n+1),
+		PoolsAfter: make([]*Pool, n),
+	}
+	states := make(poolStates, n)
+
+	if tradeType == entities.ExactInput {
+		if !amount.Currency.Wrapped().Equal(route.Input.Wrapped()) {
+			return nil, ErrInvalidAmountForRoute
+		}
+		sim.Amounts[0] = entities.FromFractionalAmount(route.TokenPath[0], amount.Numerator, amount.Denominator)
+		for i := 0; i < n; i++ {
+			addr, pool, err := states.current(route.Pools[i])
+			if err != nil {
+				return nil, err
+			}
+			res, err := pool.GetOutputAmount(sim.Amounts[i], nil)
+			if err != nil {
+				return nil, err
+			}
+			sim.Amounts[i+1] = res.ReturnedAmount
+			sim.PoolsAfter[i] = res.NewPoolState
+			states[addr] = res.NewPoolState
+		}
+		sim.Amounts[0] = amount
+		sim.Amounts[n] = entities.FromFractionalAmount(route.Output, sim.Amounts[n].Numerator, sim.Amounts[n].Denominator)
+		return sim, nil
+	}
+
+	if !amount.Currency.Wrapped().Equal(route.Output.Wrapped()) {
+		return nil, ErrInvalidAmountForRoute
+	}
+	sim.Amounts[n] = entities.FromFractionalAmount(route.TokenPath[n], amount.Numerator, amount.Denominator)
+	for i := n - 1; i >= 0; i-- {
+		addr, pool, err := states.current(route.Pools[i])
+		if err != nil {
+			return nil, err
+		}
+		amountIn, poolAfter, err := pool.GetInputAmount(sim.Amounts[i+1], nil)
+		if err != nil {
+			return nil, err
+		}
+		sim.Amounts[i] = amountIn
+		sim.PoolsAfter[i] = poolAfter
+		states[addr] = poolAfter
+	}
+	sim.Amounts[0] = entities.FromFractionalAmount(route.Input, sim.Amounts[0].Numerator, sim.Amounts[0].Denominator)
+	sim.Amounts[n] = amount
+	return sim, nil
+}
+
+// poolStates — последнее состояние каждого пула маршрута по его адресу (как в newTrade).
+type poolStates map[common.Address]*Pool
+
+// current — адрес пула и его состояние после предыдущих свапов маршрута (сам pool, если их не было).
+func (s poolStates) current(pool *Pool) (common.Address, *Pool, error) {
+	addr, err := GetAddress(pool.Token0, pool.Token1, pool.Fee, "")
+	if err != nil {
+		return addr, nil, err
+	}
+	if p, ok := s[addr]; ok {
+		return addr, p, nil
+	}
+	return addr, pool, nil
+}
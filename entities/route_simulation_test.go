@@ -0,0 +1,111 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vuquang23/int256"
+)
+
+func TestGetOutputAmount_NewPoolState(t *testing.T) {
+	pool := newTwoPositionsPool(uint256.NewInt(1e18), uint256.NewInt(1e18))
+	amountIn := big.NewInt(7e15)
+
+	res, err := pool.GetOutputAmount(entities.FromRawAmount(DAI, amountIn), nil)
+	require.NoError(t, err)
+	sr := new(SwapResultV2)
+	require.NoError(t, pool.Swap(false, int256.MustFromBig(amountIn), nil, sr))
+
+	after := res.NewPoolState
+	assert.Equal(t, sr.SqrtRatioX96, after.SqrtRatioX96)
+	assert.Equal(t, sr.CurrentTick, after.TickCurrent)
+	assert.Equal(t, sr.Liquidity, after.Liquidity)
+	// свап пересёк тик 100: цена сдвинулась, активна вторая позиция
+	assert.Greater(t, after.TickCurrent, int32(100))
+	assert.Equal(t, uint256.NewInt(1e18), after.Liquidity)
+	// исходный пул не тронут
+	assert.Equal(t, int32(0), pool.TickCurrent)
+
+	in, afterIn, err := pool.GetInputAmount(res.ReturnedAmount, nil)
+	require.NoError(t, err)
+	assert.Equal(t, amountIn, in.Quotient())
+	assert.Equal(t, after.TickCurrent, afterIn.TickCurrent)
+	assert.False(t, afterIn.Liquidity.IsZero())
+}
+
+// TestGetOutputAmount_NewPoolStateIsolated: NewPoolState — самостоятельный снимок: SwapCommit
+// с пересечением тиков и Mint/Burn на нём не меняют тики исходного пула, и снимок снимка тоже.
+func TestGetOutputAmount_NewPoolStateIsolated(t *testing.T) {
+	pool := newTwoPositionsTestPool(t)
+	handler := pool.TickDataProvider.(*TicksHandler)
+	before := handler.snapshotTicks()
+
+	res, err := pool.GetOutputAmount(entities.FromRawAmount(USDC, big.NewInt(1e16)), nil)
+	require.NoError(t, err)
+	after := res.NewPoolState
+
+	var amount0, amount1 utils.Uint256
+	require.NoError(t, after.SwapCommit(false, int256.NewInt(5e16), nil, &SwapResultV2{}, nil))
+	require.NoError(t, after.Mint(alice, -300, 300, uint256.NewInt(1e17), &amount0, &amount1))
+	require.NoError(t, after.Burn(alice, -300, 300, uint256.NewInt(5e16), &amount0, &amount1))
+	assert.Equal(t, before, handler.snapshotTicks(), "тики исходного пула не изменились")
+
+	res, err = after.GetOutputAmount(entities.FromRawAmount(USDC, big.NewInt(1e16)), nil)
+	require.NoError(t, err)
+	overlay := after.TickDataProvider.(*TicksOverlay)
+	var want, got int
+	for _, index := range []int32{-300, 300} {
+		tick, err := overlay.GetTick(index)
+		require.NoError(t, err)
+		want += int(tick.LiquidityGross.Uint64())
+	}
+	require.NoError(t, res.NewPoolState.Mint(alice, -300, 300, uint256.NewInt(1e17), &amount0, &amount1))
+	for _, index := range []int32{-300, 300} {
+		tick, err := overlay.GetTick(index)
+		require.NoError(t, err)
+		got += int(tick.LiquidityGross.Uint64())
+	}
+	assert.Equal(t, want, got, "снимок снимка не меняет тики снимка")
+}
+
+func TestSimulateRoute_RevisitedPool(t *testing.T) {
+	pool := newSplitTestPool(USDC, DAI, constants.FeeLow, OneEther)
+	route, err := NewRoute([]*Pool{pool, pool}, USDC, USDC)
+	require.NoError(t, err)
+	amountIn := entities.FromRawAmount(USDC, big.NewInt(1e16))
+
+	sim, err := SimulateRoute(route, amountIn, entities.ExactInput)
+	require.NoError(t, err)
+	require.Len(t, sim.Amounts, 3)
+	assert.Equal(t, amountIn, sim.Amounts[0])
+
+	// второй хоп идёт по цене после первого, а не по исходному состоянию пула
+	naive, err := pool.GetOutputAmount(sim.Amounts[1], nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, naive.ReturnedAmount.Quotient(), sim.Amounts[2].Quotient())
+	chained, err := sim.PoolsAfter[0].GetOutputAmount(sim.Amounts[1], nil)
+	require.NoError(t, err)
+	assert.Equal(t, chained.ReturnedAmount.Quotient(), sim.Amounts[2].Quotient())
+	assert.Equal(t, chained.NewPoolState.SqrtRatioX96, sim.PoolsAfter[1].SqrtRatioX96)
+	// туда-обратно через один пул: назад приходит меньше, чем ушло, на комиссии
+	assert.Equal(t, -1, sim.Amounts[2].Quotient().Cmp(amountIn.Quotient()))
+
+	// exact output: пулы свапаются с конца, первый хоп видит состояние после последнего
+	amountOut := entities.FromRawAmount(USDC, big.NewInt(1e16))
+	sim, err = SimulateRoute(route, amountOut, entities.ExactOutput)
+	require.NoError(t, err)
+	assert.Equal(t, amountOut, sim.Amounts[2])
+	chainedIn, _, err := sim.PoolsAfter[1].GetInputAmount(sim.Amounts[1], nil)
+	require.NoError(t, err)
+	assert.Equal(t, chainedIn.Quotient(), sim.Amounts[0].Quotient())
+	assert.Equal(t, 1, sim.Amounts[0].Quotient().Cmp(amountOut.Quotient()))
+
+	_, err = SimulateRoute(route, entities.FromRawAmount(DAI, big.NewInt(1)), entities.ExactInput)
+	assert.ErrorIs(t, err, ErrInvalidAmountForRoute)
+}
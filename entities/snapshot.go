@@ -0,0 +1,298 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// SnapshotVersion — текущая версия формата снапшота (бинарного и JSON).
+const SnapshotVersion = 1
+
+var (
+	ErrSnapshotVersion   = errors.New("unsupported snapshot version")
+	ErrSnapshotCorrupted = errors.New("snapshot is corrupted")
+)
+
+// TokenSnapshot — данные токена, достаточные для entities.NewToken.
+type TokenSnapshot struct {
+	ChainID  uint           `json:"chainId"`
+	Address  common.Address `json:"address"`
+	Decimals uint           `json:"decimals"`
+	Symbol   string         `json:"symbol,omitempty"`
+	Name     string         `json:"name,omitempty"`
+}
+
+type PositionSnapshot struct {
+	Owner     common.Address `json:"owner"`
+	TickLower int32          `json:"tickLower"`
+	TickUpper int32          `json:"tickUpper"`
+	PositionInfo
+}
+
+// PoolSnapshot — полное сериализуемое состояние пула: цена, ликвидность, тики, fee growth,
+// доля протокола, оракул и позиции. Scratch-буферы и калькуляторы не сохраняются —
+// Restore создаёт их заново через NewPoolV3.
+type PoolSnapshot struct {
+	Version     uint8          `json:"version"`
+	Address     common.Address `json:"address"`
+	Token0      *TokenSnapshot `json:"token0,omitempty"`
+	Token1      *TokenSnapshot `json:"token1,omitempty"`
+	Fee         uint64         `json:"fee"`
+	TickSpacing uint16         `json:"tickSpacing"`
+
+	SqrtPriceX96 utils.Uint160 `json:"sqrtPriceX96"`
+	Liquidity    utils.Uint128 `json:"liquidity"`
+	TickCurrent  int32         `json:"tickCurrent"`
+
+	FeeGrowthGlobal0X128 utils.Uint256 `json:"feeGrowthGlobal0X128"`
+	FeeGrowthGlobal1X128 utils.Uint256 `json:"feeGrowthGlobal1X128"`
+	FeeProtocol0         uint8         `json:"feeProtocol0"`
+	FeeProtocol1         uint8         `json:"feeProtocol1"`
+	ProtocolFees0        utils.Uint128 `json:"protocolFees0"`
+	ProtocolFees1        utils.Uint128 `json:"protocolFees1"`
+	TickBitmapStepping   bool          `json:"tickBitmapStepping,omitempty"`
+
+	BlockTimestamp             uint32        `json:"blockTimestamp"`
+	Observations               []Observation `json:"observations,omitempty"`
+	ObservationIndex           uint16        `json:"observationIndex"`
+	ObservationCardinality     uint16        `json:"observationCardinality"`
+	ObservationCardinalityNext uint16        `json:"observationCardinalityNext"`
+
+	Positions []PositionSnapshot `json:"positions,omitempty"`
+	Ticks     []Tick             `json:"ticks"`
+}
+
+// Snapshot снимает глубокую копию состояния пула. Позиции упорядочены по ключу,
+// поэтому одинаковое состояние всегда даёт одинаковые байты. Тики снимаются у *TicksHandler
+// и *TickListDataProvider; у прочих провайдеров снапшот содержит только состояние пула.
+func (p *Pool) Snapshot() *PoolSnapshot {
+	s := &PoolSnapshot{
+		Version:                    SnapshotVersion,
+		Address:                    p.Address,
+		Token0:                     snapshotToken(p.Token0),
+		Token1:                     snapshotToken(p.Token1),
+		Fee:                        uint64(p.Fee),
+		TickSpacing:                p.TickSpacing,
+		TickCurrent:                p.TickCurrent,
+		FeeGrowthGlobal0X128:       p.FeeGrowthGlobal0X128,
+		FeeGrowthGlobal1X128:       p.FeeGrowthGlobal1X128,
+		FeeProtocol0:               p.FeeProtocol0,
+		FeeProtocol1:               p.FeeProtocol1,
+		ProtocolFees0:              p.ProtocolFees0,
+		ProtocolFees1:              p.ProtocolFees1,
+		TickBitmapStepping:         p.TickBitmapStepping,
+		BlockTimestamp:             p.BlockTimestamp,
+		Observations:               slices.Clone(p.Observations),
+		ObservationIndex:           p.ObservationIndex,
+		ObservationCardinality:     p.ObservationCardinality,
+		ObservationCardinalityNext: p.ObservationCardinalityNext,
+	}
+	if p.SqrtRatioX96 != nil {
+		s.SqrtPriceX96 = *p.SqrtRatioX96
+	}
+	if p.Liquidity != nil {
+		s.Liquidity = *p.Liquidity
+	}
+
+	if len(p.Positions) != 0 {
+		s.Positions = make([]PositionSnapshot, 0, len(p.Positions))
+		for key, info := range p.Positions {
+			s.Positions = append(s.Positions, PositionSnapshot{Owner: key.Owner, TickLower: key.TickLower, TickUpper: key.TickUpper, PositionInfo: *info})
+		}
+		slices.SortFunc(s.Positions, func(a, b PositionSnapshot) int {
+			if c := bytes.Compare(a.Owner[:], b.Owner[:]); c != 0 {
+				return c
+			}
+			if a.TickLower != b.TickLower {
+				return int(a.TickLower) - int(b.TickLower)
+			}
+			return int(a.TickUpper) - int(b.TickUpper)
+		})
+	}
+
+	switch ticks := p.TickDataProvider.(type) {
+	case *TicksHandler:
+		s.Ticks = ticks.snapshotTicks()
+	case *TickListDataProvider:
+		s.Ticks = (&TicksHandler{Ticks: ticks.ticks, TicksLen: len(ticks.ticks)}).snapshotTicks()
+	case *TicksOverlay:
+		s.Ticks = ticks.snapshotTicks()
+	}
+	return s
+}
+
+// Restore собирает из снапшота готовый к свапам пул: калькуляторы, scratch-буферы и
+// кэши TicksHandler инициализируются так же, как в NewPoolV3.
+func (s *PoolSnapshot) Restore() (*Pool, error) {
+	if s.Version != SnapshotVersion {
+		return nil, ErrSnapshotVersion
+	}
+	token0, err := s.Token0.restore()
+	if err != nil {
+		return nil, err
+	}
+	token1, err := s.Token1.restore()
+	if err != nil {
+		return nil, err
+	}
+
+	ticks := NewTicksHandler()
+	if err := ticks.restoreTicks(s.Ticks); err != nil {
+		return nil, err
+	}
+
+	p := NewPoolV3(s.Address, uint16(s.Fee), s.TickCurrent, &s.SqrtPriceX96, token0, token1, ticks)
+	p.Fee = constants.FeeAmount(s.Fee)
+	p.TickSpacing = s.TickSpacing
+	p.Liquidity.Set(&s.Liquidity)
+	p.FeeGrowthGlobal0X128 = s.FeeGrowthGlobal0X128
+	p.FeeGrowthGlobal1X128 = s.FeeGrowthGlobal1X128
+	p.FeeProtocol0 = s.FeeProtocol0
+	p.FeeProtocol1 = s.FeeProtocol1
+	p.ProtocolFees0 = s.ProtocolFees0
+	p.ProtocolFees1 = s.ProtocolFees1
+	p.TickBitmapStepping = s.TickBitmapStepping
+
+	if int(s.ObservationCardinalityNext) > len(s.Observations) || s.ObservationIndex >= max(s.ObservationCardinality, 1) ||
+		s.ObservationCardinality > s.ObservationCardinalityNext {
+		return nil, ErrSnapshotCorrupted
+	}
+	p.BlockTimestamp = s.BlockTimestamp
+	p.Observations = slices.Clone(s.Observations)
+	p.ObservationIndex = s.ObservationIndex
+	p.ObservationCardinality = s.ObservationCardinality
+	p.ObservationCardinalityNext = s.ObservationCardinalityNext
+
+	if len(s.Positions) != 0 {
+		p.Positions = make(map[PositionKey]*PositionInfo, len(s.Positions))
+		for i := range s.Positions {
+			ps := &s.Positions[i]
+			info := ps.PositionInfo
+			p.Positions[PositionKey{Owner: ps.Owner, TickLower: ps.TickLower, TickUpper: ps.TickUpper}] = &info
+		}
+	}
+	return p, nil
+}
+
+func (p *Pool) MarshalBinary() ([]byte, error) {
+	return p.Snapshot().MarshalBinary()
+}
+
+// UnmarshalBinary заменяет пул восстановленным из снапшота (см. PoolSnapshot.Restore).
+func (p *Pool) UnmarshalBinary(data []byte) error {
+	var s PoolSnapshot
+	if err := s.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	restored, err := s.Restore()
+	if err != nil {
+		return err
+	}
+	*p = *restored
+	return nil
+}
+
+func (p *Pool) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Snapshot())
+}
+
+func (p *Pool) UnmarshalJSON(data []byte) error {
+	var s PoolSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	restored, err := s.Restore()
+	if err != nil {
+		return err
+	}
+	*p = *restored
+	return nil
+}
+
+// snapshotTicks — глубокая копия тиков хендлера.
+func (h *TicksHandler) snapshotTicks() []Tick {
+	ticks := make([]Tick, h.TicksLen)
+	for i := range h.Ticks[:h.TicksLen] {
+		t := &h.Ticks[i]
+		ticks[i] = Tick{
+			Index:                 t.Index,
+			LiquidityGross:        t.LiquidityGross.Clone(),
+			LiquidityNet:          t.LiquidityNet.Clone(),
+			FeeGrowthOutside0X128: t.FeeGrowthOutside0X128,
+			FeeGrowthOutside1X128: t.FeeGrowthOutside1X128,
+		}
+	}
+	return ticks
+}
+
+type ticksHandlerJSON struct {
+	Version uint8  `json:"version"`
+	Ticks   []Tick `json:"ticks"`
+}
+
+func (h *TicksHandler) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&ticksHandlerJSON{Version: SnapshotVersion, Ticks: h.snapshotTicks()})
+}
+
+// UnmarshalJSON заполняет хендлер через SetTicks, поэтому кэши и hint'ы сбрасываются корректно.
+func (h *TicksHandler) UnmarshalJSON(data []byte) error {
+	var j ticksHandlerJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Version != SnapshotVersion {
+		return ErrSnapshotVersion
+	}
+	return h.restoreTicks(j.Ticks)
+}
+
+// restoreTicks заменяет тики хендлера глубокой копией ticks. Сумма LiquidityNet не проверяется:
+// снапшот может содержать только окно тиков вокруг текущей цены.
+func (h *TicksHandler) restoreTicks(ticks []Tick) error {
+	for i := range ticks {
+		if ticks[i].LiquidityGross == nil || ticks[i].LiquidityNet == nil {
+			return ErrSnapshotCorrupted
+		}
+		if i > 0 && ticks[i-1].Index >= ticks[i].Index {
+			return ErrSorted
+		}
+	}
+
+	*h = *NewTicksHandler()
+	if len(ticks) != 0 {
+		h.CloneTicks(ticks)
+	}
+	return nil
+}
+
+func snapshotToken(token *entities.Token) *TokenSnapshot {
+	if token == nil {
+		return nil
+	}
+	return &TokenSnapshot{
+		ChainID:  token.ChainId(),
+		Address:  token.Address,
+		Decimals: token.Decimals(),
+		Symbol:   token.Symbol(),
+		Name:     token.Name(),
+	}
+}
+
+func (t *TokenSnapshot) restore() (*entities.Token, error) {
+	if t == nil {
+		return nil, nil
+	}
+	// NewToken паникует на decimals >= 255
+	if t.Decimals >= 255 {
+		return nil, ErrSnapshotCorrupted
+	}
+	return entities.NewToken(t.ChainID, t.Address, t.Decimals, t.Symbol, t.Name), nil
+}
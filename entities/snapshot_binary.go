@@ -0,0 +1,401 @@
+package entities
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/vuquang23/int256"
+)
+
+// Бинарный формат снапшота: magic (4 байта) + версия (1 байт) + поля в фиксированном порядке.
+// Целые — (u)varint, 256-битные числа — длина + big-endian байты без ведущих нулей,
+// индексы тиков — дельты от предыдущего тика. Формат компактнее JSON в разы для пулов
+// с тысячами тиков и не зависит от порядка обхода map позиций.
+var (
+	poolSnapshotMagic  = [4]byte{'U', 'V', '3', 'P'}
+	ticksSnapshotMagic = [4]byte{'U', 'V', '3', 'T'}
+)
+
+func (s *PoolSnapshot) MarshalBinary() ([]byte, error) {
+	w := snapshotWriter{buf: make([]byte, 0, 256+len(s.Ticks)*64)}
+	w.header(poolSnapshotMagic)
+
+	w.address(s.Address)
+	w.token(s.Token0)
+	w.token(s.Token1)
+	w.uvarint(s.Fee)
+	w.uvarint(uint64(s.TickSpacing))
+
+	w.uint256(&s.SqrtPriceX96)
+	w.uint256(&s.Liquidity)
+	w.varint(int64(s.TickCurrent))
+
+	w.uint256(&s.FeeGrowthGlobal0X128)
+	w.uint256(&s.FeeGrowthGlobal1X128)
+	w.byte(s.FeeProtocol0)
+	w.byte(s.FeeProtocol1)
+	w.uint256(&s.ProtocolFees0)
+	w.uint256(&s.ProtocolFees1)
+	w.bool(s.TickBitmapStepping)
+
+	w.uvarint(uint64(s.BlockTimestamp))
+	w.uvarint(uint64(s.ObservationIndex))
+	w.uvarint(uint64(s.ObservationCardinality))
+	w.uvarint(uint64(s.ObservationCardinalityNext))
+	w.uvarint(uint64(len(s.Observations)))
+	for i := range s.Observations {
+		o := &s.Observations[i]
+		w.uvarint(uint64(o.BlockTimestamp))
+		w.varint(o.TickCumulative)
+		w.uint256(&o.SecondsPerLiquidityCumulativeX128)
+		w.bool(o.Initialized)
+	}
+
+	w.uvarint(uint64(len(s.Positions)))
+	for i := range s.Positions {
+		ps := &s.Positions[i]
+		w.address(ps.Owner)
+		w.varint(int64(ps.TickLower))
+		w.varint(int64(ps.TickUpper))
+		w.uint256(&ps.Liquidity)
+		w.uint256(&ps.FeeGrowthInside0LastX128)
+		w.uint256(&ps.FeeGrowthInside1LastX128)
+		w.uint256(&ps.TokensOwed0)
+		w.uint256(&ps.TokensOwed1)
+	}
+
+	w.ticks(s.Ticks)
+	return w.buf, nil
+}
+
+func (s *PoolSnapshot) UnmarshalBinary(data []byte) error {
+	r := snapshotReader{data: data}
+	r.header(poolSnapshotMagic)
+	*s = PoolSnapshot{Version: SnapshotVersion}
+
+	s.Address = r.address()
+	s.Token0 = r.token()
+	s.Token1 = r.token()
+	s.Fee = r.uvarint()
+	s.TickSpacing = uint16(r.uvarint())
+
+	r.uint256(&s.SqrtPriceX96)
+	r.uint256(&s.Liquidity)
+	s.TickCurrent = int32(r.varint())
+
+	r.uint256(&s.FeeGrowthGlobal0X128)
+	r.uint256(&s.FeeGrowthGlobal1X128)
+	s.FeeProtocol0 = r.byte()
+	s.FeeProtocol1 = r.byte()
+	r.uint256(&s.ProtocolFees0)
+	r.uint256(&s.ProtocolFees1)
+	s.TickBitmapStepping = r.bool()
+
+	s.BlockTimestamp = uint32(r.uvarint())
+	s.ObservationIndex = uint16(r.uvarint())
+	s.ObservationCardinality = uint16(r.uvarint())
+	s.ObservationCardinalityNext = uint16(r.uvarint())
+	if n := r.count(1 + 1 + 1 + 1); n != 0 {
+		s.Observations = make([]Observation, n)
+		for i := range s.Observations {
+			o := &s.Observations[i]
+			o.BlockTimestamp = uint32(r.uvarint())
+			o.TickCumulative = r.varint()
+			r.uint256(&o.SecondsPerLiquidityCumulativeX128)
+			o.Initialized = r.bool()
+		}
+	}
+
+	if n := r.count(common.AddressLength + 2 + 5); n != 0 {
+		s.Positions = make([]PositionSnapshot, n)
+		for i := range s.Positions {
+			ps := &s.Positions[i]
+			ps.Owner = r.address()
+			ps.TickLower = int32(r.varint())
+			ps.TickUpper = int32(r.varint())
+			r.uint256(&ps.Liquidity)
+			r.uint256(&ps.FeeGrowthInside0LastX128)
+			r.uint256(&ps.FeeGrowthInside1LastX128)
+			r.uint256(&ps.TokensOwed0)
+			r.uint256(&ps.TokensOwed1)
+		}
+	}
+
+	s.Ticks = r.ticks()
+	return r.finish()
+}
+
+func (h *TicksHandler) MarshalBinary() ([]byte, error) {
+	w := snapshotWriter{buf: make([]byte, 0, 16+h.TicksLen*64)}
+	w.header(ticksSnapshotMagic)
+	w.ticks(h.Ticks[:h.TicksLen])
+	return w.buf, nil
+}
+
+// UnmarshalBinary заменяет тики хендлера; LRU-кэш и hint'ы сбрасываются.
+func (h *TicksHandler) UnmarshalBinary(data []byte) error {
+	r := snapshotReader{data: data}
+	r.header(ticksSnapshotMagic)
+	ticks := r.ticks()
+	if err := r.finish(); err != nil {
+		return err
+	}
+	return h.restoreTicks(ticks)
+}
+
+type snapshotWriter struct {
+	buf []byte
+}
+
+func (w *snapshotWriter) header(magic [4]byte) {
+	w.buf = append(w.buf, magic[:]...)
+	w.buf = append(w.buf, SnapshotVersion)
+}
+
+func (w *snapshotWriter) byte(b uint8) {
+	w.buf = append(w.buf, b)
+}
+
+func (w *snapshotWriter) bool(b bool) {
+	if b {
+		w.buf = append(w.buf, 1)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *snapshotWriter) uvarint(x uint64) {
+	w.buf = binary.AppendUvarint(w.buf, x)
+}
+
+func (w *snapshotWriter) varint(x int64) {
+	w.buf = binary.AppendVarint(w.buf, x)
+}
+
+func (w *snapshotWriter) bytes(b []byte) {
+	w.uvarint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *snapshotWriter) address(a common.Address) {
+	w.buf = append(w.buf, a[:]...)
+}
+
+func (w *snapshotWriter) uint256(x *uint256.Int) {
+	var b [32]byte
+	x.WriteToArray32(&b)
+	i := 0
+	for i < len(b) && b[i] == 0 {
+		i++
+	}
+	w.bytes(b[i:])
+}
+
+// int256 — знак (1 байт) + модуль.
+func (w *snapshotWriter) int256(x *int256.Int) {
+	var abs uint256.Int
+	if x.Sign() < 0 {
+		w.byte(1)
+		abs = uint256.Int(*new(int256.Int).Neg(x))
+	} else {
+		w.byte(0)
+		abs = uint256.Int(*x)
+	}
+	w.uint256(&abs)
+}
+
+func (w *snapshotWriter) token(t *TokenSnapshot) {
+	if t == nil {
+		w.bool(false)
+		return
+	}
+	w.bool(true)
+	w.uvarint(uint64(t.ChainID))
+	w.address(t.Address)
+	w.uvarint(uint64(t.Decimals))
+	w.bytes([]byte(t.Symbol))
+	w.bytes([]byte(t.Name))
+}
+
+func (w *snapshotWriter) ticks(ticks []Tick) {
+	w.uvarint(uint64(len(ticks)))
+	var prev int32
+	for i := range ticks {
+		t := &ticks[i]
+		w.varint(int64(t.Index) - int64(prev))
+		prev = t.Index
+		w.uint256(t.LiquidityGross)
+		w.int256(t.LiquidityNet)
+		w.uint256(&t.FeeGrowthOutside0X128)
+		w.uint256(&t.FeeGrowthOutside1X128)
+	}
+}
+
+// snapshotReader — декодер с «липкой» ошибкой: после первой ошибки все чтения
+// возвращают нули, а finish отдаёт эту ошибку.
+type snapshotReader struct {
+	data []byte
+	err  error
+}
+
+func (r *snapshotReader) fail() {
+	if r.err == nil {
+		r.err = ErrSnapshotCorrupted
+	}
+	r.data = nil
+}
+
+func (r *snapshotReader) header(magic [4]byte) {
+	if len(r.data) < 5 || [4]byte(r.data[:4]) != magic {
+		r.fail()
+		return
+	}
+	if r.data[4] != SnapshotVersion {
+		r.err = ErrSnapshotVersion
+		r.data = nil
+		return
+	}
+	r.data = r.data[5:]
+}
+
+func (r *snapshotReader) finish() error {
+	if r.err == nil && len(r.data) != 0 {
+		return ErrSnapshotCorrupted
+	}
+	return r.err
+}
+
+func (r *snapshotReader) take(n int) []byte {
+	if r.err != nil || len(r.data) < n {
+		r.fail()
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *snapshotReader) byte() uint8 {
+	if b := r.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *snapshotReader) bool() bool {
+	switch r.byte() {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+	r.fail()
+	return false
+}
+
+func (r *snapshotReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return x
+}
+
+func (r *snapshotReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return x
+}
+
+// count читает длину списка и проверяет, что в оставшихся данных хватит места хотя бы
+// на minSize байт на элемент — защита от огромных аллокаций на битых данных.
+func (r *snapshotReader) count(minSize int) int {
+	n := r.uvarint()
+	if r.err != nil {
+		return 0
+	}
+	if n > uint64(len(r.data)/minSize) {
+		r.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (r *snapshotReader) bytes() []byte {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.fail()
+		return nil
+	}
+	return r.take(int(n))
+}
+
+func (r *snapshotReader) address() common.Address {
+	return common.BytesToAddress(r.take(common.AddressLength))
+}
+
+func (r *snapshotReader) uint256(x *uint256.Int) {
+	b := r.bytes()
+	if len(b) > 32 {
+		r.fail()
+		b = nil
+	}
+	x.SetBytes(b)
+}
+
+func (r *snapshotReader) int256(x *int256.Int) {
+	negative := r.bool()
+	var abs uint256.Int
+	r.uint256(&abs)
+	*x = int256.Int(abs)
+	if negative {
+		x.Neg(x)
+	}
+}
+
+func (r *snapshotReader) token() *TokenSnapshot {
+	if !r.bool() {
+		return nil
+	}
+	return &TokenSnapshot{
+		ChainID:  uint(r.uvarint()),
+		Address:  r.address(),
+		Decimals: uint(r.uvarint()),
+		Symbol:   string(r.bytes()),
+		Name:     string(r.bytes()),
+	}
+}
+
+func (r *snapshotReader) ticks() []Tick {
+	n := r.count(1 + 1 + 2 + 3)
+	if n == 0 {
+		return nil
+	}
+	ticks := make([]Tick, n)
+	var prev int64
+	for i := range ticks {
+		t := &ticks[i]
+		prev += r.varint()
+		t.Index = int32(prev)
+		t.LiquidityGross = new(uint256.Int)
+		r.uint256(t.LiquidityGross)
+		t.LiquidityNet = new(int256.Int)
+		r.int256(t.LiquidityNet)
+		r.uint256(&t.FeeGrowthOutside0X128)
+		r.uint256(&t.FeeGrowthOutside1X128)
+	}
+	return ticks
+}
@@ -0,0 +1,136 @@
+package entities
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// newSnapshotTestPool — пул с позициями, оракулом, долей протокола и историей свапов.
+func newSnapshotTestPool(t *testing.T) *Pool {
+	pool := newTwoPositionsTestPool(t)
+	assert.NoError(t, pool.InitializeOracle(1000))
+	assert.NoError(t, pool.IncreaseObservationCardinalityNext(4))
+	assert.NoError(t, pool.SetFeeProtocol(4, 5))
+
+	var amount0, amount1 utils.Uint256
+	sr := &SwapResultV2{}
+	pool.BlockTimestamp = 1012
+	assert.NoError(t, pool.SwapCommit(true, int256.NewInt(3e16), nil, sr, nil))
+	pool.BlockTimestamp = 1024
+	assert.NoError(t, pool.SwapCommit(false, int256.NewInt(1e16), nil, sr, nil))
+	assert.NoError(t, pool.Burn(bob, -60, 120, uint256.NewInt(1e17), &amount0, &amount1))
+	return pool
+}
+
+// assertSameSwaps: исходный и восстановленный пулы дают одинаковые результаты и состояние.
+func assertSameSwaps(t *testing.T, expected, actual *Pool) {
+	for _, zeroForOne := range []bool{true, false, true} {
+		assertSameSwap(t, expected, actual, zeroForOne, int256.NewInt(2e16), true)
+		assert.True(t, expected.SqrtRatioX96.Eq(actual.SqrtRatioX96))
+		assert.Equal(t, expected.TickCurrent, actual.TickCurrent)
+		assert.True(t, expected.Liquidity.Eq(actual.Liquidity))
+		assert.True(t, expected.FeeGrowthGlobal0X128.Eq(&actual.FeeGrowthGlobal0X128))
+		assert.True(t, expected.ProtocolFees1.Eq(&actual.ProtocolFees1))
+	}
+	assert.Equal(t, expected.ObservationIndex, actual.ObservationIndex)
+	assert.Equal(t, expected.Observations, actual.Observations)
+}
+
+func TestPool_SnapshotBinaryRoundTrip(t *testing.T) {
+	pool := newSnapshotTestPool(t)
+
+	data, err := pool.MarshalBinary()
+	assert.NoError(t, err)
+
+	restored := new(Pool)
+	assert.NoError(t, restored.UnmarshalBinary(data))
+	assert.Equal(t, pool.Snapshot(), restored.Snapshot())
+	assert.Equal(t, USDC.Address, restored.Token0.Address)
+	assert.Equal(t, *pool.GetPosition(bob, -60, 120), *restored.GetPosition(bob, -60, 120))
+
+	// одинаковое состояние — одинаковые байты, несмотря на обход map позиций
+	again, err := restored.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	assertSameSwaps(t, pool, restored)
+}
+
+func TestPool_SnapshotJSONRoundTrip(t *testing.T) {
+	pool := newSnapshotTestPool(t)
+
+	data, err := json.Marshal(pool)
+	assert.NoError(t, err)
+
+	var restored Pool
+	assert.NoError(t, json.Unmarshal(data, &restored))
+	assert.Equal(t, pool.Snapshot(), restored.Snapshot())
+
+	binary, err := pool.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Less(t, len(binary), len(data)/2, "бинарный формат компактнее JSON")
+
+	assertSameSwaps(t, pool, &restored)
+}
+
+func TestPool_SnapshotErrors(t *testing.T) {
+	data, err := newSnapshotTestPool(t).MarshalBinary()
+	assert.NoError(t, err)
+
+	var pool Pool
+	for n := range len(data) {
+		assert.Error(t, pool.UnmarshalBinary(data[:n]), "обрезанный снапшот, %d байт", n)
+	}
+	assert.ErrorIs(t, pool.UnmarshalBinary(append(data, 0)), ErrSnapshotCorrupted)
+
+	future := append([]byte(nil), data...)
+	future[4] = SnapshotVersion + 1
+	assert.ErrorIs(t, pool.UnmarshalBinary(future), ErrSnapshotVersion)
+
+	// снапшот TicksHandler не читается как снапшот пула
+	ticks, err := NewTicksHandler().MarshalBinary()
+	assert.NoError(t, err)
+	assert.ErrorIs(t, pool.UnmarshalBinary(ticks), ErrSnapshotCorrupted)
+
+	assert.ErrorIs(t, pool.UnmarshalJSON([]byte(`{"version":2}`)), ErrSnapshotVersion)
+
+	s := newSnapshotTestPool(t).Snapshot()
+	s.ObservationIndex = s.ObservationCardinality
+	_, err = s.Restore()
+	assert.ErrorIs(t, err, ErrSnapshotCorrupted)
+
+	s = newSnapshotTestPool(t).Snapshot()
+	s.Ticks[0], s.Ticks[1] = s.Ticks[1], s.Ticks[0]
+	_, err = s.Restore()
+	assert.ErrorIs(t, err, ErrSorted)
+}
+
+func TestTicksHandler_SnapshotRoundTrip(t *testing.T) {
+	pool := newSnapshotTestPool(t)
+	handler := pool.TickDataProvider.(*TicksHandler)
+
+	data, err := handler.MarshalBinary()
+	assert.NoError(t, err)
+	fromBinary := NewTicksHandler()
+	assert.NoError(t, fromBinary.UnmarshalBinary(data))
+	assert.Equal(t, handler.snapshotTicks(), fromBinary.snapshotTicks())
+
+	data, err = json.Marshal(handler)
+	assert.NoError(t, err)
+	fromJSON := NewTicksHandler()
+	assert.NoError(t, json.Unmarshal(data, fromJSON))
+	assert.Equal(t, handler.snapshotTicks(), fromJSON.snapshotTicks())
+
+	// восстановленный хендлер — глубокая копия и готов к поиску тиков
+	handler.Ticks[0].LiquidityGross.SetUint64(1)
+	assert.NotEqual(t, handler.Ticks[0].LiquidityGross, fromBinary.Ticks[0].LiquidityGross)
+	tick, err := fromBinary.GetTick(-60)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(-60), tick.Index)
+}
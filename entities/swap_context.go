@@ -0,0 +1,60 @@
+package entities
+
+import "github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+
+// SwapContext — scratch цикла свапа: состояние свапа, текущий шаг, калькулятор шага и временные
+// значения. У каждого пула есть собственный контекст для Swap/SwapCommit; SwapWithContext берёт
+// scratch из переданного, поэтому один пул можно котировать из нескольких горутин без клонирования.
+// Сам контекст не потокобезопасен: по одному на горутину, переиспользуется между свапами и пулами.
+type SwapContext struct {
+	state State
+	step  StepComputations
+
+	swapStepCalculator *utils.SwapStepCalculator
+	fullMath           *utils.FullMath
+
+	liquidityNet    utils.Int128
+	amountInPlusFee utils.Uint256
+	nxtSqrtPriceX96 utils.Uint160
+	feeGrowthDelta  utils.Uint256
+
+	// тики *TicksHandler с sequential hint в tickHint (или в самом хендлере — для контекста пула)
+	ticks    hintedTicks
+	tickHint int
+
+	// пересечённые в committing-свапе тики; flip feeGrowthOutside применяется
+	// после успешного завершения цикла, чтобы ошибка не оставляла тики полуобновлёнными.
+	crossings []tickCrossing
+
+	// текущий шаг трассировки свапа (SwapResultV2.CollectTrace / StepTraceCallback)
+	traceStep SwapStepTrace
+}
+
+func NewSwapContext() *SwapContext {
+	return &SwapContext{
+		state: State{
+			amountSpecifiedRemaining: new(utils.Int256),
+			amountCalculated:         new(utils.Int256),
+			sqrtPriceX96:             new(utils.Uint160),
+			liquidity:                new(utils.Uint128),
+		},
+		swapStepCalculator: utils.NewSwapStepCalculator(),
+		fullMath:           utils.NewFullMath(),
+		tickHint:           -1,
+	}
+}
+
+// hintedTicks — *TicksHandler, у которого sequential hint поиска лежит во внешней памяти:
+// через него свап читает хендлер, ничего в нём не записывая.
+type hintedTicks struct {
+	h    *TicksHandler
+	hint *int
+}
+
+func (t *hintedTicks) GetTick(tick int32) (Tick, error) {
+	return t.h.getTick(tick, *t.hint)
+}
+
+func (t *hintedTicks) NextInitializedTickIndex(tick int32, lte bool) (int32, bool, error) {
+	return t.h.nextInitializedTickIndex(tick, lte, t.hint)
+}
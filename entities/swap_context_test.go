@@ -0,0 +1,70 @@
+package entities
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vuquang23/int256"
+)
+
+// TestPool_SwapWithContext_Concurrent: один пул котируется из нескольких горутин, у каждой свой
+// SwapContext; результаты совпадают с последовательным Swap (проверяется и под -race).
+func TestPool_SwapWithContext_Concurrent(t *testing.T) {
+	pool := newTwoPositionsTestPool(t)
+	amounts := []string{"1000000000000000", "300000000000000000", "-250000000000000000", "5000000000000000000"}
+
+	for _, bitmap := range []bool{false, true} {
+		pool.TickBitmapStepping = bitmap
+
+		type quote struct {
+			amount, remaining string
+			tick              int32
+			loops             int
+		}
+		want := make([]quote, 0, 2*len(amounts))
+		for _, zeroForOne := range []bool{true, false} {
+			for _, a := range amounts {
+				sr := &SwapResultV2{}
+				require.NoError(t, pool.Swap(zeroForOne, int256.MustFromDec(a), nil, sr))
+				want = append(want, quote{sr.AmountCalculated.Dec(), sr.RemainingAmountIn.Dec(), sr.CurrentTick, sr.CrossInitTickLoops})
+			}
+		}
+
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := NewSwapContext()
+				sr := &SwapResultV2{}
+				for iter := 0; iter < 50; iter++ {
+					i := 0
+					for _, zeroForOne := range []bool{true, false} {
+						for _, a := range amounts {
+							if !assert.NoError(t, pool.SwapWithContext(ctx, zeroForOne, int256.MustFromDec(a), nil, sr)) {
+								return
+							}
+							got := quote{sr.AmountCalculated.Dec(), sr.RemainingAmountIn.Dec(), sr.CurrentTick, sr.CrossInitTickLoops}
+							if !assert.Equal(t, want[i], got, "bitmap=%v zeroForOne=%v amount=%s", bitmap, zeroForOne, a) {
+								return
+							}
+							i++
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	// контекст переиспользуется между пулами
+	ctx := NewSwapContext()
+	other := newEmptyTestPool(0)
+	sr := &SwapResultV2{}
+	assert.NoError(t, other.SwapWithContext(ctx, true, int256.MustFromDec("1000"), nil, sr))
+	assert.True(t, sr.AmountCalculated.IsZero())
+	assert.NoError(t, pool.SwapWithContext(ctx, true, int256.MustFromDec("1000"), nil, sr))
+	assert.False(t, sr.AmountCalculated.IsZero())
+}
@@ -0,0 +1,94 @@
+package entities
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// SwapStepTrace — одна итерация цикла свапа (step в UniswapV3Pool.swap): цена и ликвидность до и после,
+// следующий тик и переход через него, суммы шага.
+type SwapStepTrace struct {
+	ZeroForOne bool `json:"zeroForOne"`
+
+	SqrtPriceStartX96 utils.Uint160 `json:"sqrtPriceStartX96"`
+	SqrtPriceNextX96  utils.Uint160 `json:"sqrtPriceNextX96"`
+	SqrtPriceEndX96   utils.Uint160 `json:"sqrtPriceEndX96"`
+
+	TickStart int32 `json:"tickStart"`
+	TickNext  int32 `json:"tickNext"`
+	TickEnd   int32 `json:"tickEnd"`
+	// Initialized — tickNext инициализирован; Crossed — цена дошла до tickNext, и (если тик
+	// инициализирован) ликвидность изменилась на его liquidityNet.
+	Initialized bool `json:"initialized"`
+	Crossed     bool `json:"crossed"`
+
+	// AmountIn — вход шага без комиссии; FeeAmount — вся комиссия шага, LPFeeAmount и
+	// ProtocolFeeAmount — её доли LP и протокола.
+	AmountIn          utils.Uint256 `json:"amountIn"`
+	AmountOut         utils.Uint256 `json:"amountOut"`
+	FeeAmount         utils.Uint256 `json:"feeAmount"`
+	LPFeeAmount       utils.Uint256 `json:"lpFeeAmount"`
+	ProtocolFeeAmount utils.Uint256 `json:"protocolFeeAmount"`
+
+	LiquidityBefore utils.Uint128 `json:"liquidityBefore"`
+	LiquidityAfter  utils.Uint128 `json:"liquidityAfter"`
+}
+
+// SwapStepTraceFunc — callback трассировки шагов свапа. step — scratch пула, валиден только
+// во время вызова: для хранения копировать по значению.
+type SwapStepTraceFunc func(step *SwapStepTrace)
+
+// swapTraceCSVHeader — колонки WriteSwapTraceCSV, в порядке полей SwapStepTrace.
+var swapTraceCSVHeader = []string{
+	"step", "zeroForOne",
+	"sqrtPriceStartX96", "sqrtPriceNextX96", "sqrtPriceEndX96",
+	"tickStart", "tickNext", "tickEnd", "initialized", "crossed",
+	"amountIn", "amountOut", "feeAmount", "lpFeeAmount", "protocolFeeAmount",
+	"liquidityBefore", "liquidityAfter",
+}
+
+// WriteSwapTraceJSON пишет шаги JSON-массивом; большие числа — hex-строками, как в PoolSnapshot.
+func WriteSwapTraceJSON(w io.Writer, steps []SwapStepTrace) error {
+	if steps == nil {
+		steps = []SwapStepTrace{}
+	}
+	return json.NewEncoder(w).Encode(steps)
+}
+
+// WriteSwapTraceCSV пишет шаги CSV с заголовком; первая колонка — номер шага с нуля.
+func WriteSwapTraceCSV(w io.Writer, steps []SwapStepTrace) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(swapTraceCSVHeader); err != nil {
+		return err
+	}
+	record := make([]string, len(swapTraceCSVHeader))
+	for i := range steps {
+		s := &steps[i]
+		record[0] = strconv.Itoa(i)
+		record[1] = strconv.FormatBool(s.ZeroForOne)
+		record[2] = s.SqrtPriceStartX96.Dec()
+		record[3] = s.SqrtPriceNextX96.Dec()
+		record[4] = s.SqrtPriceEndX96.Dec()
+		record[5] = strconv.FormatInt(int64(s.TickStart), 10)
+		record[6] = strconv.FormatInt(int64(s.TickNext), 10)
+		record[7] = strconv.FormatInt(int64(s.TickEnd), 10)
+		record[8] = strconv.FormatBool(s.Initialized)
+		record[9] = strconv.FormatBool(s.Crossed)
+		record[10] = s.AmountIn.Dec()
+		record[11] = s.AmountOut.Dec()
+		record[12] = s.FeeAmount.Dec()
+		record[13] = s.LPFeeAmount.Dec()
+		record[14] = s.ProtocolFeeAmount.Dec()
+		record[15] = s.LiquidityBefore.Dec()
+		record[16] = s.LiquidityAfter.Dec()
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
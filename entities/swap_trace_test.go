@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+func TestSwap_Trace(t *testing.T) {
+	pool := newTwoPositionsTestPool(t)
+	assert.NoError(t, pool.SetFeeProtocol(4, 4))
+
+	var called int
+	sr := &SwapResultV2{
+		CollectTrace:      true,
+		StepTraceCallback: func(step *SwapStepTrace) { called++ },
+	}
+	assert.NoError(t, pool.Swap(true, int256.MustFromDec("10000000000000000000"), nil, sr))
+	assert.Equal(t, len(sr.Trace), called)
+	assert.GreaterOrEqual(t, len(sr.Trace), 3)
+
+	// первые два шага пересекают тики позиций bob (-60) и alice (-600)
+	first, second := sr.Trace[0], sr.Trace[1]
+	assert.Equal(t, int32(-60), first.TickNext)
+	assert.True(t, first.Initialized && first.Crossed)
+	assert.Equal(t, uint64(15e17), first.LiquidityBefore.Uint64())
+	assert.Equal(t, uint64(1e18), first.LiquidityAfter.Uint64())
+	assert.Equal(t, int32(-61), first.TickEnd)
+	assert.True(t, first.SqrtPriceEndX96.Eq(&first.SqrtPriceNextX96))
+	assert.Equal(t, int32(-600), second.TickNext)
+	assert.True(t, second.LiquidityAfter.IsZero())
+
+	// шаги сцеплены, суммы сходятся с итогом свапа
+	var amountIn, amountOut, protocolFee utils.Uint256
+	for i := range sr.Trace {
+		s := &sr.Trace[i]
+		assert.True(t, s.ZeroForOne)
+		if i > 0 {
+			prev := &sr.Trace[i-1]
+			assert.True(t, s.SqrtPriceStartX96.Eq(&prev.SqrtPriceEndX96))
+			assert.Equal(t, prev.TickEnd, s.TickStart)
+			assert.Equal(t, prev.LiquidityAfter, s.LiquidityBefore)
+		}
+		amountIn.Add(&amountIn, &s.AmountIn)
+		amountIn.Add(&amountIn, &s.FeeAmount)
+		assert.Equal(t, s.FeeAmount, *new(utils.Uint256).Add(&s.LPFeeAmount, &s.ProtocolFeeAmount))
+		amountOut.Add(&amountOut, &s.AmountOut)
+		protocolFee.Add(&protocolFee, &s.ProtocolFeeAmount)
+	}
+	last := &sr.Trace[len(sr.Trace)-1]
+	assert.True(t, last.SqrtPriceEndX96.Eq(sr.SqrtRatioX96))
+	assert.Equal(t, sr.CurrentTick, last.TickEnd)
+	assert.Equal(t, "-"+amountOut.Dec(), sr.AmountCalculated.Dec())
+	var spent utils.Int256
+	spent.Sub(int256.MustFromDec("10000000000000000000"), sr.RemainingAmountIn)
+	assert.Equal(t, spent.Dec(), amountIn.Dec())
+	assert.Equal(t, sr.ProtocolFee, protocolFee)
+
+	// буфер переиспользуется; без CollectTrace трасса не собирается
+	steps := len(sr.Trace)
+	assert.NoError(t, pool.Swap(true, int256.MustFromDec("10000000000000000000"), nil, sr))
+	assert.Len(t, sr.Trace, steps)
+	plain := &SwapResultV2{}
+	assert.NoError(t, pool.Swap(true, int256.MustFromDec("10000000000000000000"), nil, plain))
+	assert.Nil(t, plain.Trace)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteSwapTraceJSON(&buf, sr.Trace))
+	var decoded []map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Len(t, decoded, steps)
+	assert.Equal(t, first.AmountIn.Hex(), decoded[0]["amountIn"])
+	assert.Equal(t, float64(-60), decoded[0]["tickNext"])
+
+	buf.Reset()
+	assert.NoError(t, WriteSwapTraceCSV(&buf, sr.Trace))
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, steps+1)
+	assert.Equal(t, swapTraceCSVHeader, records[0])
+	assert.Equal(t, []string{"0", "true"}, records[1][:2])
+	assert.Equal(t, "1000000000000000000", records[1][16])
+
+	buf.Reset()
+	assert.NoError(t, WriteSwapTraceJSON(&buf, nil))
+	assert.Equal(t, "[]\n", buf.String())
+}
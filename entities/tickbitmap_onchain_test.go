@@ -0,0 +1,282 @@
+package entities_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/entities"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/ticklens"
+)
+
+// onchainSwapsDir — записанные mainnet-свапы: состояние пула на блоке перед свапом и лог Swap.
+// Файлы пишет TestRecordOnchainSwap.
+const onchainSwapsDir = "testdata/onchain_swaps"
+
+var swapEventTopic = crypto.Keccak256Hash([]byte("Swap(address,address,int256,int256,uint160,uint128,int24)"))
+
+// onchainSwap — фикстура одного свапа. Свап должен быть exact input без sqrtPriceLimitX96 и первым
+// событием пула в своём блоке: тогда amountSpecified — положительная сумма из лога, а состояние
+// пула до свапа — состояние на Block.
+type onchainSwap struct {
+	Pool         common.Address `json:"pool"`
+	TxHash       common.Hash    `json:"txHash"`
+	Block        uint64         `json:"block"` // блок состояния: блок свапа - 1
+	Fee          uint16         `json:"fee"`
+	TickSpacing  int32          `json:"tickSpacing"`
+	SqrtPriceX96 string         `json:"sqrtPriceX96"`
+	Tick         int32          `json:"tick"`
+	Liquidity    string         `json:"liquidity"`
+	Ticks        []onchainTick  `json:"ticks"`
+	Log          onchainLog     `json:"log"`
+}
+
+type onchainTick struct {
+	Index          int32  `json:"index"`
+	LiquidityGross string `json:"liquidityGross"`
+	LiquidityNet   string `json:"liquidityNet"`
+}
+
+type onchainLog struct {
+	Topics []common.Hash `json:"topics"`
+	Data   hexutil.Bytes `json:"data"`
+}
+
+// swapEvent — неиндексированные поля события Swap.
+type swapEvent struct {
+	Amount0, Amount1, SqrtPriceX96, Liquidity *big.Int
+	Tick                                      int32
+}
+
+func decodeSwapLog(log onchainLog) (*swapEvent, error) {
+	if len(log.Topics) == 0 || log.Topics[0] != swapEventTopic {
+		return nil, fmt.Errorf("not a Swap log")
+	}
+	if len(log.Data) != 5*32 {
+		return nil, fmt.Errorf("swap log data length %d", len(log.Data))
+	}
+	word := func(i int) *big.Int { return new(big.Int).SetBytes(log.Data[i*32 : (i+1)*32]) }
+	signed := func(i int) *big.Int {
+		x := word(i)
+		if x.Bit(255) == 1 {
+			x.Sub(x, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return x
+	}
+	return &swapEvent{
+		Amount0:      signed(0),
+		Amount1:      signed(1),
+		SqrtPriceX96: word(2),
+		Liquidity:    word(3),
+		Tick:         int32(signed(4).Int64()),
+	}, nil
+}
+
+func (s *onchainSwap) pool() *entities.Pool {
+	ticks := make([]entities.Tick, len(s.Ticks))
+	for i, tick := range s.Ticks {
+		ticks[i] = entities.Tick{
+			Index:          tick.Index,
+			LiquidityGross: uint256.MustFromDecimal(tick.LiquidityGross),
+			LiquidityNet:   int256.MustFromDec(tick.LiquidityNet),
+		}
+	}
+	th := entities.NewTicksHandler()
+	th.SetTicks(ticks)
+
+	pool := entities.NewPoolV3(s.Pool, s.Fee, s.Tick, uint256.MustFromDecimal(s.SqrtPriceX96), nil, nil, th)
+	pool.TickSpacing = uint16(s.TickSpacing)
+	pool.Liquidity = uint256.MustFromDecimal(s.Liquidity)
+	pool.TickBitmapStepping = true
+	return pool
+}
+
+// crossesBitmapWord — тики до и после свапа лежат в разных словах tickBitmap.
+func crossesBitmapWord(tickBefore, tickAfter, tickSpacing int32) bool {
+	return ticklens.WordPosition(tickBefore, tickSpacing) != ticklens.WordPosition(tickAfter, tickSpacing)
+}
+
+// TestSwap_TickBitmapStepping_Onchain: в режиме bitmap Swap повторяет записанные mainnet-свапы
+// до wei — amount0, amount1, sqrtPriceX96, liquidity и tick из лога Swap. Среди фикстур должен
+// быть свап, пересекающий границу слова bitmap (разреженный пул), — иначе шаг до границы слова
+// не проверяется.
+func TestSwap_TickBitmapStepping_Onchain(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(onchainSwapsDir, "*.json"))
+	require.NoError(t, err)
+	if len(files) == 0 {
+		t.Skipf("no recorded swaps in %s; record them with TestRecordOnchainSwap", onchainSwapsDir)
+	}
+
+	wordCrossings := 0
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			var fixture onchainSwap
+			require.NoError(t, json.Unmarshal(data, &fixture))
+			event, err := decodeSwapLog(fixture.Log)
+			require.NoError(t, err)
+			if crossesBitmapWord(fixture.Tick, event.Tick, fixture.TickSpacing) {
+				wordCrossings++
+			}
+
+			// exact input: положительная сумма лога — amountSpecified, отрицательная — результат свапа
+			zeroForOne := event.Amount0.Sign() > 0
+			amountIn, amountOut := event.Amount0, event.Amount1
+			if !zeroForOne {
+				amountIn, amountOut = event.Amount1, event.Amount0
+			}
+
+			result := &entities.SwapResultV2{}
+			require.NoError(t, fixture.pool().Swap(zeroForOne, int256.MustFromBig(amountIn), nil, result))
+			assert.True(t, result.RemainingAmountIn.IsZero(), "remaining %s", result.RemainingAmountIn.Dec())
+			assert.Equal(t, amountOut.String(), result.AmountCalculated.Dec())
+			assert.Equal(t, event.SqrtPriceX96.String(), result.SqrtRatioX96.Dec())
+			assert.Equal(t, event.Liquidity.String(), result.Liquidity.Dec())
+			assert.Equal(t, event.Tick, result.CurrentTick)
+		})
+	}
+	assert.Positive(t, wordCrossings, "no recorded swap crosses a tickBitmap word boundary")
+}
+
+// фрагмент ABI UniswapV3Pool (examples/contract/uniswapv3_pool.abi)
+const onchainPoolABI = `[
+	{"name":"slot0","type":"function","stateMutability":"view","inputs":[],
+	 "outputs":[{"name":"sqrtPriceX96","type":"uint160"},{"name":"tick","type":"int24"},
+		{"name":"observationIndex","type":"uint16"},{"name":"observationCardinality","type":"uint16"},
+		{"name":"observationCardinalityNext","type":"uint16"},{"name":"feeProtocol","type":"uint8"},
+		{"name":"unlocked","type":"bool"}]},
+	{"name":"liquidity","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint128"}]},
+	{"name":"fee","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint24"}]},
+	{"name":"tickSpacing","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"int24"}]}
+]`
+
+// TestRecordOnchainSwap записывает фикстуры для TestSwap_TickBitmapStepping_Onchain, по одной
+// на транзакцию из ONCHAIN_SWAP_TX (через запятую):
+//
+//	ONCHAIN_RPC_URL=https://... ONCHAIN_SWAP_TX=0x...,0x... go test ./entities -run TestRecordOnchainSwap
+//
+// Нужен архивный узел. Берётся первый лог Swap транзакции; он должен быть первым событием своего
+// пула в блоке. Хотя бы один свап должен пересекать границу слова bitmap — в разреженном пуле
+// (например, с большим tickSpacing и редкими позициями) это видно по логу «crosses word».
+// Тики загружаются через tickBitmap/ticks пула по словам между тиками до и после свапа (плюс слово
+// с каждой стороны). Что свап — exact input без лимита цены, проверяет уже сам тест фикстуры.
+func TestRecordOnchainSwap(t *testing.T) {
+	rpcURL, txHash := os.Getenv("ONCHAIN_RPC_URL"), os.Getenv("ONCHAIN_SWAP_TX")
+	if rpcURL == "" || txHash == "" {
+		t.Skip("ONCHAIN_RPC_URL and ONCHAIN_SWAP_TX are not set")
+	}
+	client, err := ethclient.Dial(rpcURL)
+	require.NoError(t, err)
+	for _, txHash := range strings.Split(txHash, ",") {
+		txHash = strings.TrimSpace(txHash)
+		t.Run(txHash, func(t *testing.T) { recordOnchainSwap(t, client, txHash) })
+	}
+}
+
+func recordOnchainSwap(t *testing.T, client *ethclient.Client, txHash string) {
+	ctx := context.Background()
+	receipt, err := client.TransactionReceipt(ctx, common.HexToHash(txHash))
+	require.NoError(t, err)
+	var swapLog *onchainLog
+	var fixture onchainSwap
+	for _, log := range receipt.Logs {
+		if len(log.Topics) > 0 && log.Topics[0] == swapEventTopic {
+			fixture.Pool = log.Address
+			swapLog = &onchainLog{Topics: log.Topics, Data: log.Data}
+
+			poolLogs, err := client.FilterLogs(ctx, ethereum.FilterQuery{
+				FromBlock: receipt.BlockNumber, ToBlock: receipt.BlockNumber, Addresses: []common.Address{log.Address},
+			})
+			require.NoError(t, err)
+			require.NotEmpty(t, poolLogs)
+			require.Equal(t, log.Index, poolLogs[0].Index, "swap is not the first event of the pool in its block")
+			break
+		}
+	}
+	require.NotNil(t, swapLog, "no Swap log in %s", txHash)
+	event, err := decodeSwapLog(*swapLog)
+	require.NoError(t, err)
+
+	fixture.TxHash = receipt.TxHash
+	fixture.Block = receipt.BlockNumber.Uint64() - 1
+	fixture.Log = *swapLog
+	block := new(big.Int).SetUint64(fixture.Block)
+
+	poolABI, err := abi.JSON(strings.NewReader(onchainPoolABI))
+	require.NoError(t, err)
+	call := func(calldata []byte) []byte {
+		data, err := client.CallContract(ctx, ethereum.CallMsg{To: &fixture.Pool, Data: calldata}, block)
+		require.NoError(t, err)
+		return data
+	}
+	view := func(method string) []interface{} {
+		calldata, err := poolABI.Pack(method)
+		require.NoError(t, err)
+		values, err := poolABI.Unpack(method, call(calldata))
+		require.NoError(t, err)
+		return values
+	}
+
+	slot0 := view("slot0")
+	fixture.SqrtPriceX96 = slot0[0].(*big.Int).String()
+	fixture.Tick = int32(slot0[1].(*big.Int).Int64())
+	fixture.Liquidity = view("liquidity")[0].(*big.Int).String()
+	fixture.Fee = uint16(view("fee")[0].(*big.Int).Uint64())
+	fixture.TickSpacing = int32(view("tickSpacing")[0].(*big.Int).Int64())
+
+	loader := ticklens.NewLoader(fixture.TickSpacing)
+	loader.Partial = true
+	minWord, maxWord := ticklens.WordRange(fixture.TickSpacing)
+	from := max(int(ticklens.WordPosition(min(fixture.Tick, event.Tick), fixture.TickSpacing))-1, int(minWord))
+	to := min(int(ticklens.WordPosition(max(fixture.Tick, event.Tick), fixture.TickSpacing))+1, int(maxWord))
+	var words []int16
+	var bitmaps [][]byte
+	for w := from; w <= to; w++ {
+		calldata, err := ticklens.PackTickBitmap(int16(w))
+		require.NoError(t, err)
+		words = append(words, int16(w))
+		bitmaps = append(bitmaps, call(calldata))
+	}
+	tickIndexes, err := loader.TicksInBitmaps(words, bitmaps)
+	require.NoError(t, err)
+	tickResults := make([][]byte, len(tickIndexes))
+	for i, tick := range tickIndexes {
+		calldata, err := ticklens.PackTicks(tick)
+		require.NoError(t, err)
+		tickResults[i] = call(calldata)
+	}
+	handler, err := loader.Ticks(tickIndexes, tickResults)
+	require.NoError(t, err)
+	for _, tick := range handler.Ticks[:handler.TicksLen] {
+		fixture.Ticks = append(fixture.Ticks, onchainTick{
+			Index:          tick.Index,
+			LiquidityGross: tick.LiquidityGross.Dec(),
+			LiquidityNet:   tick.LiquidityNet.Dec(),
+		})
+	}
+
+	data, err := json.MarshalIndent(&fixture, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(onchainSwapsDir, 0o755))
+	file := filepath.Join(onchainSwapsDir, fixture.TxHash.Hex()+".json")
+	require.NoError(t, os.WriteFile(file, append(data, '\n'), 0o644))
+	t.Logf("recorded %s: %d ticks, crosses word: %t", file, len(fixture.Ticks),
+		crossesBitmapWord(fixture.Tick, event.Tick, fixture.TickSpacing))
+}
@@ -0,0 +1,145 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// newBitmapTestHandler — тики из TickBitmap.spec.ts v3-core (tickSpacing = 1).
+func newBitmapTestHandler(extra ...int32) *TicksHandler {
+	indexes := []int32{-200, -55, -4, 70, 78, 84, 139, 240, 535}
+	indexes = append(indexes, extra...)
+
+	th := NewTicksHandler()
+	for _, index := range indexes {
+		th.UpdateTicksAfterMint(index, utils.MaxTick, uint256.NewInt(1))
+	}
+	return th
+}
+
+// TestNextInitializedTickWithinOneWord: векторы из TickBitmap.spec.ts контракта.
+func TestNextInitializedTickWithinOneWord(t *testing.T) {
+	tests := []struct {
+		name        string
+		extra       []int32
+		tick        int32
+		lte         bool
+		next        int32
+		initialized bool
+	}{
+		{"gt: returns tick to right if at initialized tick", nil, 78, false, 84, true},
+		{"gt: returns tick to right if at initialized tick (negative)", nil, -55, false, -4, true},
+		{"gt: returns the tick directly to the right", nil, 77, false, 78, true},
+		{"gt: returns the tick directly to the right (negative)", nil, -56, false, -55, true},
+		{"gt: returns the next words initialized tick if on the right boundary", nil, 255, false, 511, false},
+		{"gt: returns the next words initialized tick if on the right boundary (negative)", nil, -257, false, -200, true},
+		{"gt: returns the next initialized tick from the next word", []int32{340}, 328, false, 340, true},
+		{"gt: does not exceed boundary", nil, 508, false, 511, false},
+		{"gt: skips entire word", nil, 255, false, 511, false},
+		{"gt: skips half word", nil, 383, false, 511, false},
+
+		{"lte: returns same tick if initialized", nil, 78, true, 78, true},
+		{"lte: returns tick directly to the left of input tick if not initialized", nil, 79, true, 78, true},
+		{"lte: will not exceed the word boundary", nil, 258, true, 256, false},
+		{"lte: at the word boundary", nil, 256, true, 256, false},
+		{"lte: word boundary less 1 (next initialized tick in next word)", nil, 72, true, 70, true},
+		{"lte: word boundary", nil, -257, true, -512, false},
+		{"lte: entire empty word", nil, 1023, true, 768, false},
+		{"lte: halfway through empty word", nil, 900, true, 768, false},
+		{"lte: boundary is initialized", []int32{329}, 456, true, 329, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := newBitmapTestHandler(tt.extra...)
+			next, initialized, err := th.NextInitializedTickWithinOneWord(tt.tick, tt.lte, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.next, next)
+			assert.Equal(t, tt.initialized, initialized)
+		})
+	}
+}
+
+func TestNextInitializedTickWithinOneWord_TickSpacing(t *testing.T) {
+	th := NewTicksHandler()
+	th.UpdateTicksAfterMint(-60, 60, uint256.NewInt(1))
+
+	// слово при tickSpacing = 60 — 256*60 тиков; отрицательный некратный тик округляется вниз
+	next, initialized, err := th.NextInitializedTickWithinOneWord(-61, true, 60)
+	assert.NoError(t, err)
+	assert.Equal(t, -256*60, int(next))
+	assert.False(t, initialized)
+
+	next, initialized, err = th.NextInitializedTickWithinOneWord(-1, true, 60)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(-60), next)
+	assert.True(t, initialized)
+
+	next, initialized, err = th.NextInitializedTickWithinOneWord(60, false, 60)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(255*60), next)
+	assert.False(t, initialized)
+
+	// пустой хендлер — граница слова в обе стороны
+	empty := NewTicksHandler()
+	next, initialized, err = empty.NextInitializedTickWithinOneWord(0, false, 60)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(255*60), next)
+	assert.False(t, initialized)
+}
+
+// TestSwap_TickBitmapStepping: в разреженном пуле свап в режиме bitmap останавливается на
+// каждой границе слова (256 * tickSpacing), как цикл UniswapV3Pool.swap; суммы расходятся
+// с «прыжковым» режимом только на округление лишних шагов.
+func TestSwap_TickBitmapStepping(t *testing.T) {
+	L := uint256.NewInt(1e18)
+	Lnet := int256.MustFromDec(L.Dec())
+
+	newPool := func(bitmap bool) *Pool {
+		th := NewTicksHandler()
+		th.SetTicks([]Tick{
+			{Index: -30000, LiquidityGross: L.Clone(), LiquidityNet: Lnet.Clone()},
+			{Index: 30000, LiquidityGross: L.Clone(), LiquidityNet: new(int256.Int).Neg(Lnet)},
+		})
+		var sqrtP utils.Uint160
+		utils.NewTickCalculator().GetSqrtRatioAtTickV2(0, &sqrtP)
+		p := NewPoolV3(common.Address{}, uint16(constants.FeeLow), 0, &sqrtP, USDC, DAI, th)
+		p.Liquidity = L.Clone()
+		p.TickBitmapStepping = bitmap
+		return p
+	}
+
+	var limit utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(8000, &limit)
+	amountIn, _ := int256.FromDec("1000000000000000000000000")
+
+	jump := &SwapResultV2{StepsFee: []StepFeeResult{}}
+	assert.NoError(t, newPool(false).Swap(false, amountIn, &limit, jump))
+	assert.Len(t, jump.StepsFee, 1)
+
+	bitmap := &SwapResultV2{StepsFee: []StepFeeResult{}}
+	assert.NoError(t, newPool(true).Swap(false, amountIn, &limit, bitmap))
+
+	// tickSpacing = 10 → слово 2560 тиков: границы 2550, 5110, 7670 и лимит на 8000
+	assert.Len(t, bitmap.StepsFee, 4)
+	assert.Equal(t, []int32{0, 2550, 5110, 7670}, []int32{
+		bitmap.StepsFee[0].Tick, bitmap.StepsFee[1].Tick, bitmap.StepsFee[2].Tick, bitmap.StepsFee[3].Tick,
+	})
+	assert.Equal(t, jump.CurrentTick, bitmap.CurrentTick)
+	assert.True(t, jump.SqrtRatioX96.Eq(bitmap.SqrtRatioX96))
+
+	// каждый из 3 дополнительных шагов округляет вверх amountIn и комиссию (до 2 wei) и вниз amountOut
+	in := new(int256.Int).Sub(amountIn, jump.RemainingAmountIn)
+	inBitmap := new(int256.Int).Sub(amountIn, bitmap.RemainingAmountIn)
+	diff := new(int256.Int).Sub(inBitmap, in)
+	assert.True(t, diff.Sign() >= 0 && diff.Cmp(int256.NewInt(6)) <= 0, "amountIn diff %s", diff.Dec())
+	outDiff := new(int256.Int).Sub(bitmap.AmountCalculated, jump.AmountCalculated)
+	assert.True(t, outDiff.Sign() >= 0 && outDiff.Cmp(int256.NewInt(3)) <= 0, "amountOut diff %s", outDiff.Dec())
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"errors"
 	"slices"
 
 	"github.com/vuquang23/int256"
@@ -8,10 +9,39 @@ import (
 	"github.com/holiman/uint256"
 )
 
+// TickDataProvider — источник тиков для свапа. *TicksHandler реализует его с fast path:
+// пул вызывает его методы напрямую, без интерфейсной диспетчеризации.
+type TickDataProvider interface {
+	// GetTick возвращает тик по индексу (ErrTickNotFound, если тик не инициализирован)
+	GetTick(tick int32) (Tick, error)
+
+	// NextInitializedTickIndex возвращает следующий инициализированный тик в направлении lte;
+	// за крайним тиком — ErrBelowSmallest / ErrAtOrAboveLargest. Провайдер может остановиться и раньше,
+	// вернув промежуточный тик с initialized = false (LazyTickProvider — границу слова битмапа).
+	NextInitializedTickIndex(tick int32, lte bool) (int32, bool, error)
+}
+
+// TickMutator — опциональные хуки изменения тиков. Без них тики пула read-only: Swap работает,
+// а Mint, Burn и SwapCommit/Rollback с пересечением инициализированных тиков возвращают ErrTicksReadOnly.
+type TickMutator interface {
+	// CrossTick переворачивает feeGrowthOutside тика относительно глобальных значений (Tick.cross)
+	CrossTick(tick int32, feeGrowthGlobal0X128, feeGrowthGlobal1X128 *utils.Uint256) error
+
+	// UpdateTicksAfterMint/Burn меняют liquidityGross/liquidityNet граничных тиков позиции;
+	// тик, появившийся при Mint, создаётся с нулевыми feeGrowthOutside.
+	UpdateTicksAfterMint(tickLower, tickUpper int32, liquidity *uint256.Int)
+	UpdateTicksAfterBurn(tickLower, tickUpper int32, liquidity *uint256.Int)
+}
+
 type Tick struct {
 	Index          int32
 	LiquidityGross *uint256.Int
 	LiquidityNet   *utils.Int128
+
+	// fee growth по другую сторону тика (относительно текущего), как в Tick.Info контракта.
+	// Хранятся по значению: не требуют аллокаций и nil-проверок у тиков, созданных без них.
+	FeeGrowthOutside0X128 utils.Uint256
+	FeeGrowthOutside1X128 utils.Uint256
 }
 
 // наш собственный расширенный TickListDataProvider с блэкджеком и шлюхами
@@ -43,11 +73,13 @@ type TicksHandler struct {
 }
 
 func NewTicksHandler() *TicksHandler {
-	return &TicksHandler{}
+	// lastResultIdx = 0 у пустого хендлера указывал бы за пределы Ticks после первого Mint
+	return &TicksHandler{lastResultIdx: -1}
 }
 
 // клонирует текущий тикхандлер путём создания глубокой копии.
 // используется для создания нового тикхандлера каждой стратегии в многопотоке.
+// если стратегии меняют лишь несколько тиков, дешевле NewTicksOverlay: копируются только они.
 func (h *TicksHandler) Clone() *TicksHandler {
 	ticksHandler := NewTicksHandler()
 
@@ -61,9 +93,11 @@ func (h *TicksHandler) SetTicks(ticks []Tick) {
 
 	for idx, tick := range ticks {
 		h.Ticks[idx] = Tick{
-			Index:          tick.Index,
-			LiquidityGross: tick.LiquidityGross,
-			LiquidityNet:   tick.LiquidityNet,
+			Index:                 tick.Index,
+			LiquidityGross:        tick.LiquidityGross,
+			LiquidityNet:          tick.LiquidityNet,
+			FeeGrowthOutside0X128: tick.FeeGrowthOutside0X128,
+			FeeGrowthOutside1X128: tick.FeeGrowthOutside1X128,
 		}
 	}
 
@@ -79,9 +113,11 @@ func (h *TicksHandler) CloneTicks(ticks []Tick) {
 
 	for idx, tick := range ticks {
 		h.Ticks[idx] = Tick{
-			Index:          tick.Index,
-			LiquidityGross: tick.LiquidityGross.Clone(),
-			LiquidityNet:   tick.LiquidityNet.Clone(),
+			Index:                 tick.Index,
+			LiquidityGross:        tick.LiquidityGross.Clone(),
+			LiquidityNet:          tick.LiquidityNet.Clone(),
+			FeeGrowthOutside0X128: tick.FeeGrowthOutside0X128,
+			FeeGrowthOutside1X128: tick.FeeGrowthOutside1X128,
 		}
 	}
 
@@ -92,9 +128,17 @@ func (h *TicksHandler) CloneTicks(ticks []Tick) {
 }
 
 func (h *TicksHandler) GetTick(tick int32) (Tick, error) {
+	return h.getTick(tick, h.lastResultIdx)
+}
+
+// getTick — GetTick с внешним hint (slice-индексом последнего результата NextInitializedTickIndex).
+func (h *TicksHandler) getTick(tick int32, hint int) (Tick, error) {
+	if h.TicksLen == 0 {
+		return EmptyTick, ErrTickNotFound
+	}
 	// Быстрый путь: если последний вызов NextInitializedTickIndex вернул именно этот тик,
 	// возвращаем его без binary search. lastResultIdx — int, нет GC write barrier.
-	if i := h.lastResultIdx; i >= 0 && h.Ticks[i].Index == tick {
+	if i := hint; i >= 0 && h.Ticks[i].Index == tick {
 		return h.Ticks[i], nil
 	}
 	i := h.binarySearch(tick)
@@ -105,7 +149,22 @@ func (h *TicksHandler) GetTick(tick int32) (Tick, error) {
 }
 
 func (h *TicksHandler) NextInitializedTickIndex(tick int32, lte bool) (int32, bool, error) {
+	return h.nextInitializedTickIndex(tick, lte, &h.lastResultIdx)
+}
+
+// nextInitializedTickIndex — NextInitializedTickIndex с sequential hint в *hint: для SwapWithContext
+// hint хранится в SwapContext, и конкурентные свапы хендлер не пишут.
+func (h *TicksHandler) nextInitializedTickIndex(tick int32, lte bool, hint *int) (int32, bool, error) {
 	var i int
+	last := *hint
+
+	// все позиции сожжены (или тики ещё не заданы) — для Swap это пустая зона в обе стороны
+	if h.TicksLen == 0 {
+		if lte {
+			return ZeroValueTickIndex, false, ErrBelowSmallest
+		}
+		return ZeroValueTickIndex, false, ErrAtOrAboveLargest
+	}
 
 	if lte {
 		if h.isBelowSmallest(tick) {
@@ -116,9 +175,9 @@ func (h *TicksHandler) NextInitializedTickIndex(tick int32, lte bool) (int32, bo
 		} else {
 			// Sequential hint: внутри одного свапа тики пересекаются по одному (lte=true → индекс -=1).
 			// Проверяем Ticks[last-1] перед полным binary search — O(1) вместо O(log N).
-			hint := h.lastResultIdx - 1
-			if hint >= 0 && h.Ticks[hint].Index <= tick && h.Ticks[h.lastResultIdx].Index > tick {
-				i = hint
+			prev := last - 1
+			if prev >= 0 && h.Ticks[prev].Index <= tick && h.Ticks[last].Index > tick {
+				i = prev
 			} else {
 				i = h.binarySearch(tick)
 			}
@@ -131,10 +190,10 @@ func (h *TicksHandler) NextInitializedTickIndex(tick int32, lte bool) (int32, bo
 			i = 0
 		} else {
 			// Sequential hint для lte=false: тики пересекаются по одному (индекс +=1).
-			hint := h.lastResultIdx + 1
-			if h.lastResultIdx >= 0 && hint < h.TicksLen &&
-				h.Ticks[h.lastResultIdx].Index <= tick && h.Ticks[hint].Index > tick {
-				i = hint
+			next := last + 1
+			if last >= 0 && next < h.TicksLen &&
+				h.Ticks[last].Index <= tick && h.Ticks[next].Index > tick {
+				i = next
 			} else {
 				i = h.binarySearch(tick) + 1
 			}
@@ -143,11 +202,90 @@ func (h *TicksHandler) NextInitializedTickIndex(tick int32, lte bool) (int32, bo
 
 	// Кэшируем slice-индекс: используется в GetTick и как hint для следующего вызова.
 	// int, не pointer — нет GC write barrier.
-	h.lastResultIdx = i
+	*hint = i
 	t := &h.Ticks[i]
 	return t.Index, !t.LiquidityGross.IsZero(), nil
 }
 
+// NextInitializedTickWithinOneWord — аналог TickBitmap.nextInitializedTickWithinOneWord: ищет
+// следующий инициализированный тик, но не дальше границы 256-битного слова bitmap'а
+// (256 * tickSpacing тиков). Если в пределах слова тика нет, возвращает границу слова
+// с initialized = false — ровно там контракт заканчивает шаг свапа.
+func (h *TicksHandler) NextInitializedTickWithinOneWord(tick int32, lte bool, tickSpacing int32) (int32, bool, error) {
+	return nextInitializedTickWithinOneWord(h, tick, lte, tickSpacing)
+}
+
+// nextInitializedTickWithinOneWord — реализация NextInitializedTickWithinOneWord поверх любого
+// TickDataProvider: нужен только NextInitializedTickIndex. Пустой провайдер за крайним тиком
+// (ErrBelowSmallest/ErrAtOrAboveLargest) — это пустое слово; любая другая ошибка провайдера
+// (например, неудачная подгрузка слова LazyTickProvider) возвращается как есть.
+func nextInitializedTickWithinOneWord(provider TickDataProvider, tick int32, lte bool, tickSpacing int32) (int32, bool, error) {
+	if tickSpacing <= 0 {
+		tickSpacing = 1
+	}
+	compressed := tick / tickSpacing
+	if tick < 0 && tick%tickSpacing != 0 {
+		compressed-- // round towards negative infinity
+	}
+
+	if lte {
+		// all the 1s at or to the right of the current bitPos
+		wordStart := (compressed &^ 0xff) * tickSpacing
+		for next := tick; ; next-- {
+			idx, initialized, err := provider.NextInitializedTickIndex(next, true)
+			if err != nil {
+				if errors.Is(err, ErrBelowSmallest) {
+					return wordStart, false, nil
+				}
+				return 0, false, err
+			}
+			// граница слова без ликвидности (так отвечает LazyTickProvider) — слово пройдено,
+			// соседнее слово не запрашиваем
+			if idx < wordStart || idx == wordStart && !initialized {
+				return wordStart, false, nil
+			}
+			if initialized {
+				return idx, true, nil
+			}
+			// тик без ликвидности в bitmap не выставлен — ищем дальше
+			next = idx
+		}
+	}
+
+	// all the 1s at or to the left of the bitPos of compressed + 1
+	wordEnd := ((compressed + 1) | 0xff) * tickSpacing
+	for next := tick; ; {
+		idx, initialized, err := provider.NextInitializedTickIndex(next, false)
+		if err != nil {
+			if errors.Is(err, ErrAtOrAboveLargest) {
+				return wordEnd, false, nil
+			}
+			return 0, false, err
+		}
+		if idx > wordEnd || idx == wordEnd && !initialized {
+			return wordEnd, false, nil
+		}
+		if initialized {
+			return idx, true, nil
+		}
+		next = idx
+	}
+}
+
+// CrossTick — аналог Tick.cross контракта: переворачивает feeGrowthOutside тика
+// относительно переданных глобальных значений (outside = global - outside, по модулю 2^256).
+// Операция инволютивна: повторный вызов с теми же global возвращает тик в исходное состояние.
+func (h *TicksHandler) CrossTick(tick int32, feeGrowthGlobal0X128, feeGrowthGlobal1X128 *utils.Uint256) error {
+	t, _, exist := h.tickWithSliceKey(tick)
+	if !exist {
+		return ErrTickNotFound
+	}
+
+	t.FeeGrowthOutside0X128.Sub(feeGrowthGlobal0X128, &t.FeeGrowthOutside0X128)
+	t.FeeGrowthOutside1X128.Sub(feeGrowthGlobal1X128, &t.FeeGrowthOutside1X128)
+	return nil
+}
+
 // актуализирует состояние тиков пула после историчекого события mint
 func (h *TicksHandler) UpdateTicksAfterMint(tickLower, tickUpper int32, liquidity *uint256.Int) {
 	liquidityI256 := (*int256.Int)(liquidity)
@@ -159,9 +297,7 @@ func (h *TicksHandler) UpdateTicksAfterMint(tickLower, tickUpper int32, liquidit
 		h.Ticks = slices.Insert(h.Ticks, sliceKey, Tick{Index: tickLower, LiquidityGross: liquidity.Clone(), LiquidityNet: liquidityI256.Clone()})
 		h.TicksLen++
 		h.shiftIndicesAfterInsert(int32(sliceKey))
-		if tickLower < h.SmallestTickIdx {
-			h.SmallestTickIdx = tickLower
-		}
+		h.refreshBounds()
 	}
 
 	if tick, sliceKey, exist := h.tickWithSliceKey(tickUpper); exist {
@@ -171,13 +307,12 @@ func (h *TicksHandler) UpdateTicksAfterMint(tickLower, tickUpper int32, liquidit
 		h.Ticks = slices.Insert(h.Ticks, sliceKey, Tick{Index: tickUpper, LiquidityGross: liquidity.Clone(), LiquidityNet: new(int256.Int).Neg(liquidityI256)})
 		h.TicksLen++
 		h.shiftIndicesAfterInsert(int32(sliceKey))
-		if tickUpper > h.LargestTickIdx {
-			h.LargestTickIdx = tickUpper
-		}
+		h.refreshBounds()
 	}
 }
 
-// актуализирует состояние тиков пула после историчекого события burn
+// актуализирует состояние тиков пула после историчекого события burn.
+// без проверок: неизвестный тик — паника, underflow не ловится (см. BurnTicks)
 func (h *TicksHandler) UpdateTicksAfterBurn(tickLower, tickUpper int32, liquidity *uint256.Int) {
 	liquidityI256 := (*int256.Int)(liquidity)
 
@@ -198,11 +333,17 @@ func (h *TicksHandler) removeTickIfEmpty(tick *Tick, sliceKey int) {
 		h.Ticks = slices.Delete(h.Ticks, sliceKey, sliceKey+1)
 		h.TicksLen--
 		h.shiftIndicesAfterDelete(int32(sliceKey))
+		h.refreshBounds()
+	}
+}
 
-		if h.TicksLen > 0 {
-			h.SmallestTickIdx = h.Ticks[0].Index
-			h.LargestTickIdx = h.Ticks[h.TicksLen-1].Index
-		}
+// refreshBounds пересчитывает Smallest/LargestTickIdx по краям слайса.
+// Сравнение «новый тик < SmallestTickIdx» не годится для пустого хендлера: там оба индекса
+// нулевые, и первый вставленный положительный тик не становился бы SmallestTickIdx.
+func (h *TicksHandler) refreshBounds() {
+	if h.TicksLen > 0 {
+		h.SmallestTickIdx = h.Ticks[0].Index
+		h.LargestTickIdx = h.Ticks[h.TicksLen-1].Index
 	}
 }
 
@@ -0,0 +1,71 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// newListProviderPool — копия pool поверх read-only TickListDataProvider.
+func newListProviderPool(t *testing.T, pool *Pool) *Pool {
+	provider, err := NewTickListDataProvider(pool.TickDataProvider.(*TicksHandler).snapshotTicks(), int(pool.TickSpacing))
+	assert.NoError(t, err)
+
+	listPool := NewPoolV3(pool.Address, uint16(pool.Fee), pool.TickCurrent, pool.SqrtRatioX96, pool.Token0, pool.Token1, provider)
+	listPool.Liquidity.Set(pool.Liquidity)
+	return listPool
+}
+
+// TestPool_TickListDataProvider: любой TickDataProvider даёт те же свапы, что и *TicksHandler.
+func TestPool_TickListDataProvider(t *testing.T) {
+	pool := newTwoPositionsTestPool(t)
+	listPool := newListProviderPool(t, pool)
+
+	for _, stepping := range []bool{false, true} {
+		pool.TickBitmapStepping, listPool.TickBitmapStepping = stepping, stepping
+		for _, zeroForOne := range []bool{true, false} {
+			got := assertSameSwap(t, pool, listPool, zeroForOne, int256.NewInt(5e16), false)
+			assert.Positive(t, got.CrossInitTickLoops)
+		}
+	}
+}
+
+// TestPool_ReadOnlyTickProvider: без TickMutator пул не меняет тики — мутации отклоняются
+// до изменения состояния, свапы без пересечения тиков коммитятся.
+func TestPool_ReadOnlyTickProvider(t *testing.T) {
+	pool := newBoundedTestPool(-100, 100, 0, uint256.NewInt(1e18))
+	listPool := newListProviderPool(t, pool)
+
+	var amount0, amount1 utils.Uint256
+	assert.ErrorIs(t, listPool.Mint(alice, -10, 10, uint256.NewInt(1e6), &amount0, &amount1), ErrTicksReadOnly)
+
+	var cp PoolCheckpoint
+	listPool.Checkpoint(&cp)
+	sr := &SwapResultV2{}
+	assert.ErrorIs(t, listPool.SwapCommit(true, int256.NewInt(1e17), nil, sr, nil), ErrTicksReadOnly)
+	assert.Equal(t, int32(0), listPool.TickCurrent)
+	assert.True(t, listPool.SqrtRatioX96.Eq(&cp.SqrtRatioX96))
+
+	assert.NoError(t, listPool.SwapCommit(true, int256.NewInt(1e12), nil, sr, nil))
+	assert.Equal(t, 0, sr.CrossInitTickLoops)
+	assert.Less(t, listPool.TickCurrent, int32(0))
+}
+
+func TestNewPoolV3_NilTicksHandler(t *testing.T) {
+	var sqrtP utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(0, &sqrtP)
+	var handler *TicksHandler
+	pool := NewPoolV3(common.Address{}, uint16(constants.FeeLow), 0, &sqrtP, USDC, DAI, handler)
+	assert.Nil(t, pool.TickDataProvider)
+
+	// Mint создаёт хендлер сам
+	var amount0, amount1 utils.Uint256
+	assert.NoError(t, pool.Mint(alice, -10, 10, uint256.NewInt(1e6), &amount0, &amount1))
+	assert.Equal(t, 2, pool.TickDataProvider.(*TicksHandler).TicksLen)
+}
@@ -0,0 +1,383 @@
+package entities
+
+import (
+	"slices"
+
+	"github.com/holiman/uint256"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// TicksOverlay — copy-on-write альтернатива TicksHandler.Clone: клоны делят общий массив тиков base,
+// а тики, которые меняет стратегия (Mint, Burn, пересечение при SwapCommit), копируются в overlay.
+// На пустом overlay поиск идёт по base тем же sequential hint и branchless binary search, что и у TicksHandler.
+//
+// base используется только на чтение и может быть общим для overlay'ев из разных горутин,
+// но пока они живут, base нельзя менять (Mint/Burn/SwapCommit пула поверх самого base).
+// Сам TicksOverlay не потокобезопасен.
+type TicksOverlay struct {
+	base *TicksHandler
+
+	// ticks — материализованные тики по возрастанию Index; removed скрывает тик base,
+	// сожжённый в overlay.
+	ticks []overlayTick
+
+	// lastBaseIdx — sequential hint по base.Ticks, аналог TicksHandler.lastResultIdx (-1 — невалиден).
+	// Свой у каждого overlay: поля base не пишутся.
+	lastBaseIdx int
+}
+
+type overlayTick struct {
+	Tick
+	removed bool
+}
+
+// NewTicksOverlay создаёт пустой overlay поверх base. nil base — пул без тиков.
+func NewTicksOverlay(base *TicksHandler) *TicksOverlay {
+	if base == nil {
+		base = NewTicksHandler()
+	}
+	return &TicksOverlay{base: base, lastBaseIdx: -1}
+}
+
+// Clone — новый overlay над тем же base с глубокой копией материализованных тиков.
+func (o *TicksOverlay) Clone() *TicksOverlay {
+	clone := NewTicksOverlay(o.base)
+	clone.ticks = make([]overlayTick, len(o.ticks))
+	for i := range o.ticks {
+		clone.ticks[i] = overlayTick{Tick: cloneTick(&o.ticks[i].Tick), removed: o.ticks[i].removed}
+	}
+	return clone
+}
+
+// Base возвращает общий хендлер тиков.
+func (o *TicksOverlay) Base() *TicksHandler {
+	return o.base
+}
+
+// MaterializedTicks — число тиков, скопированных в overlay (включая сожжённые тики base).
+func (o *TicksOverlay) MaterializedTicks() int {
+	return len(o.ticks)
+}
+
+// Flatten собирает самостоятельный TicksHandler с тиками base и overlay.
+func (o *TicksOverlay) Flatten() *TicksHandler {
+	h := NewTicksHandler()
+	if ticks := o.snapshotTicks(); len(ticks) != 0 {
+		h.SetTicks(ticks)
+	}
+	return h
+}
+
+func (o *TicksOverlay) GetTick(tick int32) (Tick, error) {
+	if len(o.ticks) != 0 {
+		if i, found := o.findOverlay(tick); found {
+			if o.ticks[i].removed {
+				return EmptyTick, ErrTickNotFound
+			}
+			return o.ticks[i].Tick, nil
+		}
+	}
+	if i, found := o.findBase(tick); found {
+		return o.base.Ticks[i], nil
+	}
+	return EmptyTick, ErrTickNotFound
+}
+
+// NextInitializedTickIndex — ближайший из кандидатов base (без скрытых overlay'ем тиков) и overlay.
+func (o *TicksOverlay) NextInitializedTickIndex(tick int32, lte bool) (int32, bool, error) {
+	var (
+		t     *Tick
+		found bool
+	)
+	if bi := o.seekBase(tick, lte); bi >= 0 {
+		t, found = &o.base.Ticks[bi], true
+	}
+	if len(o.ticks) != 0 {
+		if oi := o.seekOverlay(tick, lte); oi >= 0 {
+			ot := &o.ticks[oi].Tick
+			if !found || (lte && ot.Index > t.Index) || (!lte && ot.Index < t.Index) {
+				t, found = ot, true
+			}
+		}
+	}
+
+	if !found {
+		if lte {
+			return ZeroValueTickIndex, false, ErrBelowSmallest
+		}
+		return ZeroValueTickIndex, false, ErrAtOrAboveLargest
+	}
+	return t.Index, !t.LiquidityGross.IsZero(), nil
+}
+
+func (o *TicksOverlay) NextInitializedTickWithinOneWord(tick int32, lte bool, tickSpacing int32) (int32, bool, error) {
+	return nextInitializedTickWithinOneWord(o, tick, lte, tickSpacing)
+}
+
+// CrossTick — Tick.cross над копией тика в overlay.
+func (o *TicksOverlay) CrossTick(tick int32, feeGrowthGlobal0X128, feeGrowthGlobal1X128 *utils.Uint256) error {
+	i, found := o.findOverlay(tick)
+	if !found {
+		if _, inBase := o.findBase(tick); !inBase {
+			return ErrTickNotFound
+		}
+		i = o.materialize(tick)
+	} else if o.ticks[i].removed {
+		return ErrTickNotFound
+	}
+
+	t := &o.ticks[i].Tick
+	t.FeeGrowthOutside0X128.Sub(feeGrowthGlobal0X128, &t.FeeGrowthOutside0X128)
+	t.FeeGrowthOutside1X128.Sub(feeGrowthGlobal1X128, &t.FeeGrowthOutside1X128)
+	return nil
+}
+
+func (o *TicksOverlay) UpdateTicksAfterMint(tickLower, tickUpper int32, liquidity *uint256.Int) {
+	liquidityI256 := (*int256.Int)(liquidity)
+
+	t := o.mintTick(tickLower)
+	t.LiquidityGross.Add(t.LiquidityGross, liquidity)
+	t.LiquidityNet.Add(t.LiquidityNet, liquidityI256)
+
+	t = o.mintTick(tickUpper)
+	t.LiquidityGross.Add(t.LiquidityGross, liquidity)
+	t.LiquidityNet.Sub(t.LiquidityNet, liquidityI256)
+}
+
+func (o *TicksOverlay) UpdateTicksAfterBurn(tickLower, tickUpper int32, liquidity *uint256.Int) {
+	liquidityI256 := (*int256.Int)(liquidity)
+
+	i := o.materialize(tickLower)
+	t := &o.ticks[i].Tick
+	t.LiquidityGross.Sub(t.LiquidityGross, liquidity)
+	t.LiquidityNet.Sub(t.LiquidityNet, liquidityI256)
+	o.removeTickIfEmpty(i)
+
+	i = o.materialize(tickUpper)
+	t = &o.ticks[i].Tick
+	t.LiquidityGross.Sub(t.LiquidityGross, liquidity)
+	t.LiquidityNet.Add(t.LiquidityNet, liquidityI256)
+	o.removeTickIfEmpty(i)
+}
+
+// mintTick возвращает копию тика в overlay для Mint. Новый или воскрешённый после Burn тик
+// начинает с нулевой ликвидностью и нулевыми feeGrowthOutside, как у TicksHandler.
+func (o *TicksOverlay) mintTick(tick int32) *Tick {
+	i := o.materialize(tick)
+	ot := &o.ticks[i]
+	if ot.removed {
+		ot.removed = false
+		ot.LiquidityGross.Clear()
+		ot.LiquidityNet.Clear()
+		ot.FeeGrowthOutside0X128.Clear()
+		ot.FeeGrowthOutside1X128.Clear()
+	}
+	return &ot.Tick
+}
+
+// removeTickIfEmpty: пустой тик base помечается removed, тик, которого нет в base, удаляется из overlay.
+func (o *TicksOverlay) removeTickIfEmpty(i int) {
+	t := &o.ticks[i].Tick
+	if !t.LiquidityGross.IsZero() || !t.LiquidityNet.IsZero() {
+		return
+	}
+	if _, inBase := o.findBase(t.Index); inBase {
+		o.ticks[i].removed = true
+		return
+	}
+	o.ticks = slices.Delete(o.ticks, i, i+1)
+}
+
+// materialize возвращает индекс копии тика в overlay, создавая её из тика base
+// или пустой (removed остаётся как есть — решает вызывающий).
+func (o *TicksOverlay) materialize(tick int32) int {
+	i, found := o.findOverlay(tick)
+	if found {
+		return i
+	}
+
+	t := Tick{Index: tick, LiquidityGross: new(uint256.Int), LiquidityNet: new(int256.Int)}
+	if bi, inBase := o.findBase(tick); inBase {
+		t = cloneTick(&o.base.Ticks[bi])
+	}
+	o.ticks = slices.Insert(o.ticks, i, overlayTick{Tick: t})
+	return i
+}
+
+func (o *TicksOverlay) findOverlay(tick int32) (int, bool) {
+	return slices.BinarySearchFunc(o.ticks, tick, compareOverlayTickIndex)
+}
+
+func compareOverlayTickIndex(t overlayTick, index int32) int {
+	return int(t.Index) - int(index)
+}
+
+// findBase — slice-индекс тика в base без учёта overlay.
+func (o *TicksOverlay) findBase(tick int32) (int, bool) {
+	b := o.base
+	if b.TicksLen == 0 {
+		return 0, false
+	}
+	if i := o.lastBaseIdx; i >= 0 && b.Ticks[i].Index == tick {
+		return i, true
+	}
+	i := b.binarySearch(tick)
+	return i, b.Ticks[i].Index == tick
+}
+
+// seekBase — slice-индекс следующего тика base в направлении lte, не перекрытого overlay, или -1.
+// Повторяет поиск TicksHandler.NextInitializedTickIndex, но hint хранится в overlay.
+func (o *TicksOverlay) seekBase(tick int32, lte bool) int {
+	b := o.base
+	n := b.TicksLen
+	if n == 0 {
+		return -1
+	}
+
+	var i int
+	if lte {
+		if b.isBelowSmallest(tick) {
+			return -1
+		}
+		if b.isAtOrAboveLargest(tick) {
+			i = n - 1
+		} else {
+			hint := o.lastBaseIdx - 1
+			if hint >= 0 && b.Ticks[hint].Index <= tick && b.Ticks[o.lastBaseIdx].Index > tick {
+				i = hint
+			} else {
+				i = b.binarySearch(tick)
+			}
+		}
+		// перекрытые тики base берутся из overlay (или скрыты им)
+		for len(o.ticks) != 0 && i >= 0 && o.covers(b.Ticks[i].Index) {
+			i--
+		}
+		if i < 0 {
+			return -1
+		}
+	} else {
+		if b.isAtOrAboveLargest(tick) {
+			return -1
+		}
+		if b.isBelowSmallest(tick) {
+			i = 0
+		} else {
+			hint := o.lastBaseIdx + 1
+			if o.lastBaseIdx >= 0 && hint < n &&
+				b.Ticks[o.lastBaseIdx].Index <= tick && b.Ticks[hint].Index > tick {
+				i = hint
+			} else {
+				i = b.binarySearch(tick) + 1
+			}
+		}
+		for len(o.ticks) != 0 && i < n && o.covers(b.Ticks[i].Index) {
+			i++
+		}
+		if i >= n {
+			return -1
+		}
+	}
+
+	o.lastBaseIdx = i
+	return i
+}
+
+// seekOverlay — индекс следующего не удалённого тика overlay в направлении lte или -1.
+func (o *TicksOverlay) seekOverlay(tick int32, lte bool) int {
+	// тики overlay целиком по другую сторону — без поиска
+	if (lte && tick < o.ticks[0].Index) || (!lte && tick >= o.ticks[len(o.ticks)-1].Index) {
+		return -1
+	}
+	i, found := o.findOverlay(tick)
+	if lte {
+		if !found {
+			i--
+		}
+		for i >= 0 && o.ticks[i].removed {
+			i--
+		}
+		return i
+	}
+
+	if found {
+		i++
+	}
+	for i < len(o.ticks) && o.ticks[i].removed {
+		i++
+	}
+	if i >= len(o.ticks) {
+		return -1
+	}
+	return i
+}
+
+func (o *TicksOverlay) covers(tick int32) bool {
+	if tick < o.ticks[0].Index || tick > o.ticks[len(o.ticks)-1].Index {
+		return false
+	}
+	_, found := o.findOverlay(tick)
+	return found
+}
+
+// snapshotTicks — глубокая копия объединённых тиков base и overlay.
+func (o *TicksOverlay) snapshotTicks() []Tick {
+	b := o.base
+	ticks := make([]Tick, 0, b.TicksLen+len(o.ticks))
+	bi, oi := 0, 0
+	for bi < b.TicksLen || oi < len(o.ticks) {
+		if oi == len(o.ticks) || (bi < b.TicksLen && b.Ticks[bi].Index < o.ticks[oi].Index) {
+			ticks = append(ticks, cloneTick(&b.Ticks[bi]))
+			bi++
+			continue
+		}
+		if bi < b.TicksLen && b.Ticks[bi].Index == o.ticks[oi].Index {
+			bi++
+		}
+		if !o.ticks[oi].removed {
+			ticks = append(ticks, cloneTick(&o.ticks[oi].Tick))
+		}
+		oi++
+	}
+	return ticks
+}
+
+func cloneTick(t *Tick) Tick {
+	return Tick{
+		Index:                 t.Index,
+		LiquidityGross:        t.LiquidityGross.Clone(),
+		LiquidityNet:          t.LiquidityNet.Clone(),
+		FeeGrowthOutside0X128: t.FeeGrowthOutside0X128,
+		FeeGrowthOutside1X128: t.FeeGrowthOutside1X128,
+	}
+}
+
+func (o *TicksOverlay) CheckMint(tickLower, tickUpper int32, liquidity *uint256.Int) error {
+	return checkMint(o.lookupTick(tickLower), o.lookupTick(tickUpper), tickLower, tickUpper, liquidity)
+}
+
+func (o *TicksOverlay) CheckBurn(tickLower, tickUpper int32, liquidity *uint256.Int) error {
+	return checkBurn(o.lookupTick(tickLower), o.lookupTick(tickUpper), tickLower, tickUpper, liquidity)
+}
+
+// Validate проверяет инварианты объединённых тиков base и overlay (как TicksHandler.Validate).
+// Копирует все тики — только для отладки.
+func (o *TicksOverlay) Validate() error {
+	return validateTicks(o.snapshotTicks())
+}
+
+// lookupTick — действующий тик (из overlay или base, только на чтение) или nil.
+func (o *TicksOverlay) lookupTick(tick int32) *Tick {
+	if i, found := o.findOverlay(tick); found {
+		if o.ticks[i].removed {
+			return nil
+		}
+		return &o.ticks[i].Tick
+	}
+	if i, found := o.findBase(tick); found {
+		return &o.base.Ticks[i]
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package entities
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// newOverlayTestPools — пул на собственном TicksHandler и его копия на TicksOverlay поверх общего base.
+func newOverlayTestPools(t *testing.T) (*Pool, *Pool, *TicksHandler) {
+	s := newSnapshotTestPool(t).Snapshot()
+	pool, err := s.Restore()
+	assert.NoError(t, err)
+	overlayPool, err := s.Restore()
+	assert.NoError(t, err)
+
+	base := overlayPool.TickDataProvider.(*TicksHandler)
+	overlayPool.TickDataProvider = NewTicksOverlay(base)
+	return pool, overlayPool, base
+}
+
+// applyOverlayScenario: Mint в новых и существующих тиках, свапы с пересечением, частичный и полный Burn.
+func applyOverlayScenario(t *testing.T, pool *Pool) {
+	var amount0, amount1 utils.Uint256
+	sr := &SwapResultV2{}
+	assert.NoError(t, pool.Mint(alice, -120, 60, uint256.NewInt(3e17), &amount0, &amount1))
+	assert.NoError(t, pool.Mint(bob, -600, 120, uint256.NewInt(2e17), &amount0, &amount1))
+	assert.NoError(t, pool.SwapCommit(true, int256.NewInt(1e17), nil, sr, nil))
+	assert.Positive(t, sr.CrossInitTickLoops)
+	assert.NoError(t, pool.SwapCommit(false, int256.NewInt(2e17), nil, sr, nil))
+	// bob сжигает позицию (-60, 120) целиком: тик -60 исчезает
+	assert.NoError(t, pool.Burn(bob, -60, 120, uint256.NewInt(4e17), &amount0, &amount1))
+	assert.NoError(t, pool.Burn(alice, -120, 60, uint256.NewInt(3e17), &amount0, &amount1))
+	assert.NoError(t, pool.SwapCommit(true, int256.NewInt(5e16), nil, sr, nil))
+}
+
+func TestTicksOverlay_MatchesTicksHandler(t *testing.T) {
+	pool, overlayPool, base := newOverlayTestPools(t)
+	baseTicks := base.snapshotTicks()
+
+	applyOverlayScenario(t, pool)
+	applyOverlayScenario(t, overlayPool)
+	assert.Equal(t, pool.Snapshot(), overlayPool.Snapshot())
+	assertSameSwaps(t, pool, overlayPool)
+
+	// base не тронут, в overlay — только изменённые тики
+	assert.Equal(t, baseTicks, base.snapshotTicks())
+	overlay := overlayPool.TickDataProvider.(*TicksOverlay)
+	assert.Less(t, overlay.MaterializedTicks(), len(baseTicks)+2)
+	assert.Equal(t, pool.TickDataProvider.(*TicksHandler).snapshotTicks(), overlay.Flatten().snapshotTicks())
+
+	_, err := overlay.GetTick(-60)
+	assert.ErrorIs(t, err, ErrTickNotFound)
+	_, err = base.GetTick(-60)
+	assert.NoError(t, err)
+}
+
+func TestTicksOverlay_Rollback(t *testing.T) {
+	_, overlayPool, base := newOverlayTestPools(t)
+	baseTicks := base.snapshotTicks()
+	before := overlayPool.Snapshot()
+
+	var cp PoolCheckpoint
+	sr := &SwapResultV2{}
+	assert.NoError(t, overlayPool.SwapCommit(true, int256.NewInt(2e17), nil, sr, &cp))
+	assert.Positive(t, sr.CrossInitTickLoops)
+	assert.NoError(t, overlayPool.Rollback(&cp))
+
+	assert.Equal(t, before, overlayPool.Snapshot())
+	assert.Equal(t, baseTicks, base.snapshotTicks())
+}
+
+func TestTicksOverlay_Clone(t *testing.T) {
+	_, overlayPool, base := newOverlayTestPools(t)
+	overlay := overlayPool.TickDataProvider.(*TicksOverlay)
+
+	var amount0, amount1 utils.Uint256
+	assert.NoError(t, overlayPool.Mint(alice, -180, 180, uint256.NewInt(1e17), &amount0, &amount1))
+	clone := overlay.Clone()
+	assert.Same(t, base, clone.Base())
+
+	// Burn в оригинале не виден клону
+	assert.NoError(t, overlayPool.Burn(alice, -180, 180, uint256.NewInt(1e17), &amount0, &amount1))
+	_, err := overlay.GetTick(-180)
+	assert.ErrorIs(t, err, ErrTickNotFound)
+	tick, err := clone.GetTick(-180)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1e17), tick.LiquidityGross.Uint64())
+
+	next, initialized, err := clone.NextInitializedTickIndex(-121, true)
+	assert.NoError(t, err)
+	assert.True(t, initialized)
+	assert.Equal(t, int32(-180), next)
+	next, _, err = overlay.NextInitializedTickIndex(-121, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(-600), next)
+}
+
+func TestTicksOverlay_RemoveAndRevive(t *testing.T) {
+	base := NewTicksHandler()
+	base.UpdateTicksAfterMint(-60, 60, uint256.NewInt(100))
+	base.Ticks[0].FeeGrowthOutside0X128.SetUint64(7)
+	overlay := NewTicksOverlay(base)
+
+	overlay.UpdateTicksAfterBurn(-60, 60, uint256.NewInt(100))
+	_, _, err := overlay.NextInitializedTickIndex(0, true)
+	assert.ErrorIs(t, err, ErrBelowSmallest)
+	_, _, err = overlay.NextInitializedTickIndex(0, false)
+	assert.ErrorIs(t, err, ErrAtOrAboveLargest)
+	assert.ErrorIs(t, overlay.CrossTick(-60, new(utils.Uint256), new(utils.Uint256)), ErrTickNotFound)
+	assert.Empty(t, overlay.snapshotTicks())
+
+	// воскрешённый тик создаётся заново: feeGrowthOutside base не наследуется
+	overlay.UpdateTicksAfterMint(-60, 120, uint256.NewInt(5))
+	tick, err := overlay.GetTick(-60)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), tick.LiquidityGross.Uint64())
+	assert.True(t, tick.FeeGrowthOutside0X128.IsZero())
+
+	next, _, err := overlay.NextInitializedTickIndex(-60, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(120), next)
+
+	// тик, которого нет в base, после Burn удаляется из overlay целиком
+	overlay.UpdateTicksAfterBurn(-60, 120, uint256.NewInt(5))
+	assert.Equal(t, 2, overlay.MaterializedTicks())
+	assert.Equal(t, uint64(7), base.Ticks[0].FeeGrowthOutside0X128.Uint64())
+	assert.Equal(t, 2, base.TicksLen)
+}
+
+// TestTicksOverlay_Concurrent: стратегии в разных горутинах делят один base (проверяется под -race).
+func TestTicksOverlay_Concurrent(t *testing.T) {
+	s := newSnapshotTestPool(t).Snapshot()
+	basePool, err := s.Restore()
+	assert.NoError(t, err)
+	base := basePool.TickDataProvider.(*TicksHandler)
+	baseTicks := base.snapshotTicks()
+
+	var wg sync.WaitGroup
+	snapshots := make([]*PoolSnapshot, 4)
+	for i := range snapshots {
+		pool, err := s.Restore()
+		assert.NoError(t, err)
+		pool.TickDataProvider = NewTicksOverlay(base)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applyOverlayScenario(t, pool)
+			snapshots[i] = pool.Snapshot()
+		}()
+	}
+	wg.Wait()
+
+	for _, snapshot := range snapshots[1:] {
+		assert.Equal(t, snapshots[0], snapshot)
+	}
+	assert.Equal(t, baseTicks, base.snapshotTicks())
+}
+
+func BenchmarkNextInitializedTickIndex_TicksHandler(b *testing.B) {
+	handler := newBenchTicksHandler()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.NextInitializedTickIndex(int32(i%1000)*60+30, i&1 == 0)
+	}
+}
+
+func BenchmarkNextInitializedTickIndex_TicksOverlay(b *testing.B) {
+	overlay := NewTicksOverlay(newBenchTicksHandler())
+	overlay.UpdateTicksAfterMint(-6000, 6000, uint256.NewInt(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		overlay.NextInitializedTickIndex(int32(i%1000)*60+30, i&1 == 0)
+	}
+}
+
+func newBenchTicksHandler() *TicksHandler {
+	handler := NewTicksHandler()
+	for i := int32(0); i < 1000; i++ {
+		handler.UpdateTicksAfterMint(-i*60-60, i*60+60, uint256.NewInt(1))
+	}
+	return handler
+}
@@ -0,0 +1,198 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/holiman/uint256"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var (
+	ErrLiquidityUnderflow   = errors.New("liquidity gross underflow: burn exceeds tick liquidity")
+	ErrLiquidityNetOverflow = errors.New("liquidity net overflows int128")
+	ErrNetExceedsGross      = errors.New("tick |liquidityNet| exceeds liquidityGross")
+	ErrTicksBounds          = errors.New("ticks length or smallest/largest tick index mismatch")
+	ErrNilTickLiquidity     = errors.New("tick liquidity is nil")
+
+	int128Max = int256.MustFromDec("170141183460469231731687303715884105727")
+	int128Min = int256.MustFromDec("-170141183460469231731687303715884105728")
+)
+
+// TickMutationChecker — опциональная предпроверка мутаций тиков. Пул вызывает её в modifyPosition
+// до первой мутации: ошибка (неизвестный тик, underflow, переполнение int128/uint128) возвращается
+// из Mint/Burn, а состояние пула и тиков не меняется.
+type TickMutationChecker interface {
+	CheckMint(tickLower, tickUpper int32, liquidity *uint256.Int) error
+	CheckBurn(tickLower, tickUpper int32, liquidity *uint256.Int) error
+}
+
+// MintTicks — проверяющий вариант UpdateTicksAfterMint: диапазон и кратность tickSpacing,
+// затем CheckMint. При ошибке тики не меняются.
+func (h *TicksHandler) MintTicks(tickLower, tickUpper, tickSpacing int32, liquidity *uint256.Int) error {
+	if err := checkTickRange(tickLower, tickUpper, tickSpacing); err != nil {
+		return err
+	}
+	if err := h.CheckMint(tickLower, tickUpper, liquidity); err != nil {
+		return err
+	}
+	h.UpdateTicksAfterMint(tickLower, tickUpper, liquidity)
+	return nil
+}
+
+// BurnTicks — проверяющий вариант UpdateTicksAfterBurn. Непроверяющий вариант на неизвестном тике
+// паникует, а при underflow тихо заворачивает liquidityGross по модулю 2^256.
+func (h *TicksHandler) BurnTicks(tickLower, tickUpper, tickSpacing int32, liquidity *uint256.Int) error {
+	if err := checkTickRange(tickLower, tickUpper, tickSpacing); err != nil {
+		return err
+	}
+	if err := h.CheckBurn(tickLower, tickUpper, liquidity); err != nil {
+		return err
+	}
+	h.UpdateTicksAfterBurn(tickLower, tickUpper, liquidity)
+	return nil
+}
+
+// CheckMint проверяет, что UpdateTicksAfterMint не переполнит liquidityGross (uint128)
+// и liquidityNet (int128) граничных тиков.
+func (h *TicksHandler) CheckMint(tickLower, tickUpper int32, liquidity *uint256.Int) error {
+	return checkMint(h.lookupTick(tickLower), h.lookupTick(tickUpper), tickLower, tickUpper, liquidity)
+}
+
+// CheckBurn проверяет, что оба тика существуют, liquidityGross не уйдёт в минус,
+// а liquidityNet останется в пределах int128.
+func (h *TicksHandler) CheckBurn(tickLower, tickUpper int32, liquidity *uint256.Int) error {
+	return checkBurn(h.lookupTick(tickLower), h.lookupTick(tickUpper), tickLower, tickUpper, liquidity)
+}
+
+// Validate проверяет глобальные инварианты хендлера: TicksLen и Smallest/LargestTickIdx
+// соответствуют Ticks, тики строго по возрастанию, gross >= |net|, сумма liquidityNet равна нулю.
+// O(N) — для отладки и проверки после загрузки/реплея, не для горячего пути.
+func (h *TicksHandler) Validate() error {
+	if h.TicksLen != len(h.Ticks) {
+		return fmt.Errorf("%w: TicksLen %d, len(Ticks) %d", ErrTicksBounds, h.TicksLen, len(h.Ticks))
+	}
+	if h.TicksLen != 0 && (h.SmallestTickIdx != h.Ticks[0].Index || h.LargestTickIdx != h.Ticks[h.TicksLen-1].Index) {
+		return fmt.Errorf("%w: smallest %d, largest %d, ticks [%d, %d]", ErrTicksBounds,
+			h.SmallestTickIdx, h.LargestTickIdx, h.Ticks[0].Index, h.Ticks[h.TicksLen-1].Index)
+	}
+	return validateTicks(h.Ticks)
+}
+
+// validateTicks — инварианты отсортированного набора тиков, общие для TicksHandler и TicksOverlay.
+func validateTicks(ticks []Tick) error {
+	var sum, absNet int256.Int
+	for i := range ticks {
+		t := &ticks[i]
+		if t.LiquidityGross == nil || t.LiquidityNet == nil {
+			return fmt.Errorf("tick %d: %w", t.Index, ErrNilTickLiquidity)
+		}
+		if i > 0 && ticks[i-1].Index >= t.Index {
+			return fmt.Errorf("tick %d after %d: %w", t.Index, ticks[i-1].Index, ErrSorted)
+		}
+		if t.LiquidityGross.Gt(utils.Uint128Max) {
+			return fmt.Errorf("tick %d: gross: %w", t.Index, utils.ErrOverflowUint128)
+		}
+		if t.LiquidityNet.Gt(int128Max) || t.LiquidityNet.Lt(int128Min) {
+			return fmt.Errorf("tick %d: %w", t.Index, ErrLiquidityNetOverflow)
+		}
+		// gross < 2^128, поэтому сравнение как int256 корректно
+		absNet.Set(t.LiquidityNet)
+		if absNet.IsNegative() {
+			absNet.Neg(&absNet)
+		}
+		if absNet.Gt((*int256.Int)(t.LiquidityGross)) {
+			return fmt.Errorf("tick %d: net %s, gross %s: %w", t.Index, t.LiquidityNet.Dec(), t.LiquidityGross.Dec(), ErrNetExceedsGross)
+		}
+		sum.Add(&sum, t.LiquidityNet)
+	}
+	if !sum.IsZero() {
+		return fmt.Errorf("sum %s: %w", sum.Dec(), ErrZeroNet)
+	}
+	return nil
+}
+
+// lookupTick — тик хендлера или nil; через LRU tickWithSliceKey, чтобы следующая мутация тех же тиков
+// попала в кэш.
+func (h *TicksHandler) lookupTick(tick int32) *Tick {
+	t, _, _ := h.tickWithSliceKey(tick)
+	return t
+}
+
+// checkTickRange — те же проверки границ позиции, что у Pool.checkTicks, плюс кратность tickSpacing.
+func checkTickRange(tickLower, tickUpper, tickSpacing int32) error {
+	switch {
+	case tickSpacing <= 0:
+		return ErrZeroTickSpacing
+	case tickLower >= tickUpper:
+		return ErrTickOrder
+	case tickLower < utils.MinTick:
+		return ErrTickLowerToLow
+	case tickUpper > utils.MaxTick:
+		return ErrTickUpperToHigh
+	case tickLower%tickSpacing != 0:
+		return ErrTickLowerTickSpacing
+	case tickUpper%tickSpacing != 0:
+		return ErrTickUpperTickSpacing
+	}
+	return nil
+}
+
+// checkMint: lower/upper — текущие тики или nil, если тика ещё нет.
+func checkMint(lower, upper *Tick, tickLower, tickUpper int32, liquidity *uint256.Int) error {
+	if liquidity.Gt(utils.Uint128Max) {
+		return fmt.Errorf("liquidity %s: %w", liquidity.Dec(), utils.ErrOverflowUint128)
+	}
+	liquidityI256 := (*int256.Int)(liquidity)
+
+	var gross uint256.Int
+	var net int256.Int
+	if lower != nil {
+		if gross.Add(lower.LiquidityGross, liquidity).Gt(utils.Uint128Max) {
+			return fmt.Errorf("tick %d: gross: %w", tickLower, utils.ErrOverflowUint128)
+		}
+		if net.Add(lower.LiquidityNet, liquidityI256).Gt(int128Max) {
+			return fmt.Errorf("tick %d: %w", tickLower, ErrLiquidityNetOverflow)
+		}
+	}
+	if upper != nil {
+		if gross.Add(upper.LiquidityGross, liquidity).Gt(utils.Uint128Max) {
+			return fmt.Errorf("tick %d: gross: %w", tickUpper, utils.ErrOverflowUint128)
+		}
+		if net.Sub(upper.LiquidityNet, liquidityI256).Lt(int128Min) {
+			return fmt.Errorf("tick %d: %w", tickUpper, ErrLiquidityNetOverflow)
+		}
+	}
+	return nil
+}
+
+func checkBurn(lower, upper *Tick, tickLower, tickUpper int32, liquidity *uint256.Int) error {
+	if lower == nil {
+		return fmt.Errorf("tick %d: %w", tickLower, ErrTickNotFound)
+	}
+	if upper == nil {
+		return fmt.Errorf("tick %d: %w", tickUpper, ErrTickNotFound)
+	}
+	if liquidity.Gt(utils.Uint128Max) {
+		return fmt.Errorf("liquidity %s: %w", liquidity.Dec(), utils.ErrOverflowUint128)
+	}
+	liquidityI256 := (*int256.Int)(liquidity)
+
+	if lower.LiquidityGross.Lt(liquidity) {
+		return fmt.Errorf("tick %d: gross %s, burn %s: %w", tickLower, lower.LiquidityGross.Dec(), liquidity.Dec(), ErrLiquidityUnderflow)
+	}
+	if upper.LiquidityGross.Lt(liquidity) {
+		return fmt.Errorf("tick %d: gross %s, burn %s: %w", tickUpper, upper.LiquidityGross.Dec(), liquidity.Dec(), ErrLiquidityUnderflow)
+	}
+
+	var net int256.Int
+	if net.Sub(lower.LiquidityNet, liquidityI256).Lt(int128Min) {
+		return fmt.Errorf("tick %d: %w", tickLower, ErrLiquidityNetOverflow)
+	}
+	if net.Add(upper.LiquidityNet, liquidityI256).Gt(int128Max) {
+		return fmt.Errorf("tick %d: %w", tickUpper, ErrLiquidityNetOverflow)
+	}
+	return nil
+}
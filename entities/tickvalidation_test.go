@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+func TestTicksHandler_MintBurnTicks(t *testing.T) {
+	h := NewTicksHandler()
+	assert.NoError(t, h.MintTicks(-60, 60, 60, uint256.NewInt(100)))
+	assert.NoError(t, h.Validate())
+
+	assert.ErrorIs(t, h.MintTicks(-60, 90, 60, uint256.NewInt(1)), ErrTickUpperTickSpacing)
+	assert.ErrorIs(t, h.MintTicks(60, -60, 60, uint256.NewInt(1)), ErrTickOrder)
+	assert.ErrorIs(t, h.MintTicks(-60, 60, 0, uint256.NewInt(1)), ErrZeroTickSpacing)
+
+	// неизвестный тик — ошибка вместо паники
+	assert.ErrorIs(t, h.BurnTicks(-120, 60, 60, uint256.NewInt(1)), ErrTickNotFound)
+	assert.ErrorIs(t, h.BurnTicks(-60, 60, 60, uint256.NewInt(101)), ErrLiquidityUnderflow)
+	assert.Equal(t, uint64(100), h.Ticks[0].LiquidityGross.Uint64(), "при ошибке тики не меняются")
+
+	// int128: liquidityNet нижнего тика упирается в max int128 раньше, чем gross в max uint128
+	half := new(uint256.Int).Rsh(utils.Uint128Max, 1)
+	assert.ErrorIs(t, h.MintTicks(-60, 120, 60, half), ErrLiquidityNetOverflow)
+	assert.ErrorIs(t, h.MintTicks(-60, 120, 60, new(uint256.Int).AddUint64(utils.Uint128Max, 1)), utils.ErrOverflowUint128)
+	assert.Equal(t, 2, h.TicksLen)
+
+	assert.NoError(t, h.BurnTicks(-60, 60, 60, uint256.NewInt(100)))
+	assert.Equal(t, 0, h.TicksLen)
+	assert.NoError(t, h.Validate())
+}
+
+func TestTicksHandler_Validate(t *testing.T) {
+	tick := func(index int32, gross uint64, net int64) Tick {
+		return Tick{Index: index, LiquidityGross: uint256.NewInt(gross), LiquidityNet: int256.NewInt(net)}
+	}
+
+	h := NewTicksHandler()
+	h.SetTicks([]Tick{tick(-60, 10, 10), tick(60, 10, -10)})
+	assert.NoError(t, h.Validate())
+
+	h.LargestTickIdx = 120
+	assert.ErrorIs(t, h.Validate(), ErrTicksBounds)
+
+	h.SetTicks([]Tick{tick(-60, 10, 10), tick(60, 5, -10)})
+	assert.ErrorIs(t, h.Validate(), ErrNetExceedsGross)
+
+	h.SetTicks([]Tick{tick(-60, 10, 10), tick(60, 20, -5)})
+	assert.ErrorIs(t, h.Validate(), ErrZeroNet)
+
+	h.SetTicks([]Tick{tick(60, 10, -10), tick(-60, 10, 10)})
+	assert.ErrorIs(t, h.Validate(), ErrSorted)
+
+	h.SetTicks([]Tick{{Index: 0, LiquidityGross: uint256.NewInt(0)}})
+	assert.ErrorIs(t, h.Validate(), ErrNilTickLiquidity)
+}
+
+// TestPool_BurnCorruptedTicks: рассинхрон позиций и тиков даёт ошибку Burn без изменения состояния.
+func TestPool_BurnCorruptedTicks(t *testing.T) {
+	pool := newEmptyTestPool(0)
+	var amount0, amount1 utils.Uint256
+	assert.NoError(t, pool.Mint(alice, -60, 60, uint256.NewInt(1e18), &amount0, &amount1))
+
+	handler := pool.TickDataProvider.(*TicksHandler)
+	handler.Ticks[1].LiquidityGross.SetUint64(1)
+	before := pool.Snapshot()
+
+	assert.ErrorIs(t, pool.Burn(alice, -60, 60, uint256.NewInt(1e18), &amount0, &amount1), ErrLiquidityUnderflow)
+	assert.Equal(t, before, pool.Snapshot())
+	assert.ErrorIs(t, handler.Validate(), ErrNetExceedsGross)
+
+	// то же для TicksOverlay
+	overlayPool, err := before.Restore()
+	assert.NoError(t, err)
+	overlayPool.TickDataProvider = NewTicksOverlay(overlayPool.TickDataProvider.(*TicksHandler))
+	assert.ErrorIs(t, overlayPool.Burn(alice, -60, 60, uint256.NewInt(1e18), &amount0, &amount1), ErrLiquidityUnderflow)
+	assert.ErrorIs(t, overlayPool.TickDataProvider.(*TicksOverlay).Validate(), ErrNetExceedsGross)
+}
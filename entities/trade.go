@@ -85,6 +85,8 @@ type Trade struct {
 	outputAmount   *entities.CurrencyAmount // The cached result of the output amount computation
 	executionPrice *entities.Price          // The cached result of the computed execution price
 	priceImpact    *entities.Percent        // The cached result of the price impact computation
+
+	initializedTicksCrossed []int // The cached initialized ticks crossed by each swap, see InitializedTicksCrossed
 }
 
 type Swap struct {
@@ -452,13 +454,15 @@ func (t *Trade) WorstExecutionPrice(slippageTolerance *entities.Percent) (*entit
 type BestTradeOptions struct {
 	MaxNumResults int // how many results to return
 	MaxHops       int // the maximum number of hops a trade should contain
+	// GasModel, if set, ranks trades by output net of gas (exact in) or input plus gas (exact out)
+	GasModel *GasModel
 }
 
 /**
  * Given a list of pools, and a fixed amount in, returns the top `maxNumResults` trades that go from an input token
  * amount to an output token, making at most `maxHops` hops.
  * Note this does not consider aggregation, as routes are linear. It's possible a better route exists by splitting
- * the amount in among multiple routes.
+ * the amount in among multiple routes, see BestSplitTradeExactIn.
  * @param pools the pools to consider in finding the best trade
  * @param nextAmountIn exact amount of input currency to spend
  * @param currencyOut the desired currency out
@@ -513,7 +517,7 @@ func BestTradeExactIn(pools []*Pool, currencyAmountIn *entities.CurrencyAmount,
 			if err != nil {
 				return nil, err
 			}
-			bestTrades, err = sortedInsert(bestTrades, trade, opts.MaxNumResults, tradeComparator)
+			bestTrades, err = insertTrade(bestTrades, trade, opts)
 			if err != nil {
 				return nil, err
 			}
@@ -523,7 +527,7 @@ func BestTradeExactIn(pools []*Pool, currencyAmountIn *entities.CurrencyAmount,
 			poolsExcludingThisPool = append(poolsExcludingThisPool, pools[i+1:]...)
 
 			// otherwise, consider all the other paths that lead from this token as long as we have not exceeded maxHops
-			bestTrades, err = BestTradeExactIn(poolsExcludingThisPool, currencyAmountIn, currencyOut, &BestTradeOptions{MaxNumResults: opts.MaxNumResults, MaxHops: opts.MaxHops - 1}, append(currentPools, pool), amountOut.ReturnedAmount, bestTrades)
+			bestTrades, err = BestTradeExactIn(poolsExcludingThisPool, currencyAmountIn, currencyOut, &BestTradeOptions{MaxNumResults: opts.MaxNumResults, MaxHops: opts.MaxHops - 1, GasModel: opts.GasModel}, append(currentPools, pool), amountOut.ReturnedAmount, bestTrades)
 			if err != nil {
 				return nil, err
 			}
@@ -537,7 +541,7 @@ func BestTradeExactIn(pools []*Pool, currencyAmountIn *entities.CurrencyAmount,
  * given a list of pools, and a fixed amount out, returns the top `maxNumResults` trades that go from an input token
  * to an output token amount, making at most `maxHops` hops
  * note this does not consider aggregation, as routes are linear. it's possible a better route exists by splitting
- * the amount in among multiple routes.
+ * the amount in among multiple routes, see BestSplitTradeExactOut.
  * @param pools the pools to consider in finding the best trade
  * @param currencyIn the currency to spend
  * @param currencyAmountOut the desired currency amount out
@@ -592,7 +596,7 @@ func BestTradeExactOut(pools []*Pool, currencyIn entities.Currency, currencyAmou
 			if err != nil {
 				return nil, err
 			}
-			bestTrades, err = sortedInsert(bestTrades, trade, opts.MaxNumResults, tradeComparator)
+			bestTrades, err = insertTrade(bestTrades, trade, opts)
 			if err != nil {
 				return nil, err
 			}
@@ -602,7 +606,7 @@ func BestTradeExactOut(pools []*Pool, currencyIn entities.Currency, currencyAmou
 			poolsExcludingThisPool = append(poolsExcludingThisPool, pools[i+1:]...)
 
 			// otherwise, consider all the other paths that arrive at this token as long as we have not exceeded maxHops
-			bestTrades, err = BestTradeExactOut(poolsExcludingThisPool, currencyIn, currencyAmountOut, &BestTradeOptions{MaxNumResults: opts.MaxNumResults, MaxHops: opts.MaxHops - 1}, append([]*Pool{pool}, currentPools...), amountIn, bestTrades)
+			bestTrades, err = BestTradeExactOut(poolsExcludingThisPool, currencyIn, currencyAmountOut, &BestTradeOptions{MaxNumResults: opts.MaxNumResults, MaxHops: opts.MaxHops - 1, GasModel: opts.GasModel}, append([]*Pool{pool}, currentPools...), amountIn, bestTrades)
 			if err != nil {
 				return nil, err
 			}
@@ -611,6 +615,18 @@ func BestTradeExactOut(pools []*Pool, currencyIn entities.Currency, currencyAmou
 	return bestTrades, nil
 }
 
+// insertTrade inserts trade into bestTrades ranked by tradeComparator, or by gasAdjustedTradeComparator
+// when opts.GasModel is set
+func insertTrade(bestTrades []*Trade, trade *Trade, opts *BestTradeOptions) ([]*Trade, error) {
+	if opts.GasModel == nil {
+		return sortedInsert(bestTrades, trade, opts.MaxNumResults, tradeComparator)
+	}
+	if _, err := trade.GasCost(opts.GasModel); err != nil {
+		return nil, err
+	}
+	return sortedInsert(bestTrades, trade, opts.MaxNumResults, gasAdjustedTradeComparator(opts.GasModel))
+}
+
 // sortedInsert given an array of items sorted by `comparator`, insert an item into its sort index and constrain the size to
 // `maxSize` by removing the last item
 func sortedInsert(items []*Trade, add *Trade, maxSize int, comparator func(a, b *Trade) int) ([]*Trade, error) {
@@ -0,0 +1,278 @@
+package entities
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vuquang23/int256"
+)
+
+var (
+	ErrNoRoutes            = errors.New("no routes")
+	ErrInvalidDistribution = errors.New("invalid distribution percent")
+	ErrInvalidMaxSplits    = errors.New("invalid max splits")
+	ErrNoSplitFound        = errors.New("no route split fills the amount")
+)
+
+// SplitTradeOptions — параметры разбиения суммы по маршрутам, как distributionPercent/maxSplits
+// в routing API Uniswap.
+type SplitTradeOptions struct {
+	DistributionPercent int // шаг разбиения в процентах, 100 должно на него делиться
+	MaxSplits           int // сколько маршрутов максимум может быть в сделке
+	// GasModel, если задана, учитывает газ каждого маршрута: максимизируется выход за вычетом газа
+	// (exact input) или минимизируется вход с газом (exact output).
+	GasModel *GasModel
+}
+
+var defaultSplitTradeOptions = SplitTradeOptions{DistributionPercent: 5, MaxSplits: 3}
+
+// BestSplitTradeExactIn делит currencyAmountIn по routes шагами DistributionPercent так, чтобы суммарный
+// выход был максимальным. Маршруты с общими пулами в одну сделку не попадают (как ErrDuplicatePools
+// в newTrade), поэтому котировки маршрутов независимы. Маршрут, который не может исполнить долю целиком,
+// для этой доли не рассматривается. Результат строится через FromRoutes и готов для SwapCallParameters.
+func BestSplitTradeExactIn(routes []*Route, currencyAmountIn *entities.CurrencyAmount, opts *SplitTradeOptions) (*Trade, error) {
+	return bestSplitTrade(routes, currencyAmountIn, entities.ExactInput, opts)
+}
+
+// BestSplitTradeExactOut — то же для exact output: currencyAmountOut делится по routes так, чтобы
+// суммарный вход был минимальным.
+func BestSplitTradeExactOut(routes []*Route, currencyAmountOut *entities.CurrencyAmount, opts *SplitTradeOptions) (*Trade, error) {
+	return bestSplitTrade(routes, currencyAmountOut, entities.ExactOutput, opts)
+}
+
+// splitRoute — маршрут-кандидат: пулы (по адресу, как в newTrade) и котировки долей
+// quotes[k] для k шагов разбиения (nil — доля не исполняется), с учётом газа, если задана GasModel.
+type splitRoute struct {
+	route  *Route
+	pools  []common.Address
+	quotes []*big.Int
+}
+
+// splitSearch — перебор распределений шагов по маршрутам с попарно непересекающимися пулами.
+type splitSearch struct {
+	routes    []*splitRoute
+	exactIn   bool
+	maxSplits int
+
+	usedPools map[common.Address]bool
+	alloc     []int // шагов на маршрут в текущей ветке
+	best      []int
+	bestValue *big.Int
+	bestCount int
+}
+
+func bestSplitTrade(routes []*Route, amount *entities.CurrencyAmount, tradeType entities.TradeType, opts *SplitTradeOptions) (*Trade, error) {
+	if len(routes) == 0 {
+		return nil, ErrNoRoutes
+	}
+	if opts == nil {
+		opts = &defaultSplitTradeOptions
+	}
+	if opts.DistributionPercent <= 0 || opts.DistributionPercent > 100 || 100%opts.DistributionPercent != 0 {
+		return nil, ErrInvalidDistribution
+	}
+	if opts.MaxSplits <= 0 {
+		return nil, ErrInvalidMaxSplits
+	}
+
+	exactIn := tradeType == entities.ExactInput
+	input, output := routes[0].Input.Wrapped(), routes[0].Output.Wrapped()
+	for _, route := range routes {
+		if !route.Input.Wrapped().Equal(input) {
+			return nil, ErrInputCurrencyMismatch
+		}
+		if !route.Output.Wrapped().Equal(output) {
+			return nil, ErrOutputCurrencyMismatch
+		}
+	}
+	if (exactIn && !amount.Currency.Wrapped().Equal(input)) || (!exactIn && !amount.Currency.Wrapped().Equal(output)) {
+		return nil, ErrInvalidAmountForRoute
+	}
+
+	steps := 100 / opts.DistributionPercent
+	total := amount.Quotient()
+	stepAmounts := make([]*big.Int, steps+1)
+	for k := 1; k <= steps; k++ {
+		stepAmounts[k] = new(big.Int).Mul(total, big.NewInt(int64(k*opts.DistributionPercent)))
+		stepAmounts[k].Quo(stepAmounts[k], big.NewInt(100))
+	}
+
+	s := &splitSearch{exactIn: exactIn, maxSplits: opts.MaxSplits, usedPools: make(map[common.Address]bool)}
+	for _, route := range routes {
+		sr, err := newSplitRoute(route, stepAmounts, tradeType, opts.GasModel)
+		if err != nil {
+			return nil, err
+		}
+		if sr != nil {
+			s.routes = append(s.routes, sr)
+		}
+	}
+	s.alloc = make([]int, len(s.routes))
+	s.search(0, steps, 0, new(big.Int))
+	if s.best == nil {
+		return nil, ErrNoSplitFound
+	}
+
+	// доли округлены вниз; остаток (меньше числа маршрутов в wei) уходит в самую крупную
+	var wrappedRoutes []*WrappedRoute
+	largest := 0
+	allocated := new(big.Int)
+	for i, k := range s.best {
+		if k == 0 {
+			continue
+		}
+		if s.best[i] > s.best[largest] {
+			largest = i
+		}
+		allocated.Add(allocated, stepAmounts[k])
+	}
+	for i, k := range s.best {
+		if k == 0 {
+			continue
+		}
+		share := new(big.Int).Set(stepAmounts[k])
+		if i == largest {
+			share.Add(share, new(big.Int).Sub(total, allocated))
+		}
+		wrappedRoutes = append(wrappedRoutes, &WrappedRoute{
+			Amount: entities.FromRawAmount(amount.Currency, share),
+			Route:  s.routes[i].route,
+		})
+	}
+	return FromRoutes(wrappedRoutes, tradeType)
+}
+
+// newSplitRoute котирует route на каждой доле stepAmounts. Маршрут, который сам проходит через
+// один пул дважды, в сделку попасть не может (ErrDuplicatePools) — для него возвращается nil.
+func newSplitRoute(route *Route, stepAmounts []*big.Int, tradeType entities.TradeType, gasModel *GasModel) (*splitRoute, error) {
+	sr := &splitRoute{route: route, quotes: make([]*big.Int, len(stepAmounts))}
+	for _, pool := range route.Pools {
+		addr, err := GetAddress(pool.Token0, pool.Token1, pool.Fee, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range sr.pools {
+			if a == addr {
+				return nil, nil
+			}
+		}
+		sr.pools = append(sr.pools, addr)
+	}
+	for k := 1; k < len(stepAmounts); k++ {
+		if stepAmounts[k].Sign() == 0 {
+			continue
+		}
+		quote, ticksCrossed, err := quoteRoute(route, stepAmounts[k], tradeType)
+		if err != nil {
+			return nil, err
+		}
+		if quote != nil && gasModel != nil {
+			currency := route.Output
+			if tradeType == entities.ExactOutput {
+				currency = route.Input
+			}
+			gasCost, err := gasModel.GasCost(gasModel.RouteGas(len(route.Pools), ticksCrossed), currency)
+			if err != nil {
+				return nil, err
+			}
+			if tradeType == entities.ExactInput {
+				quote.Sub(quote, gasCost.Quotient())
+			} else {
+				quote.Add(quote, gasCost.Quotient())
+			}
+		}
+		sr.quotes[k] = quote
+	}
+	return sr, nil
+}
+
+// quoteRoute — read-only котировка маршрута: выход для exact input, вход для exact output,
+// и сумма CrossInitTickLoops по пулам. Котировка nil, если какой-то пул не исполняет сумму целиком.
+func quoteRoute(route *Route, amount *big.Int, tradeType entities.TradeType) (quote *big.Int, ticksCrossed int, err error) {
+	q, err := int256.FromBig(amount)
+	if err != nil {
+		return nil, 0, err
+	}
+	swapResult := new(SwapResultV2)
+	n := len(route.Pools)
+	for j := 0; j < n; j++ {
+		i := j
+		if tradeType == entities.ExactOutput {
+			i = n - 1 - j
+			q.Neg(q)
+		}
+		pool := route.Pools[i]
+		zeroForOne := route.TokenPath[i].Equal(pool.Token0)
+		if err = pool.Swap(zeroForOne, q, nil, swapResult); err != nil {
+			return nil, 0, err
+		}
+		ticksCrossed += swapResult.CrossInitTickLoops
+		if !swapResult.RemainingAmountIn.IsZero() {
+			return nil, ticksCrossed, nil
+		}
+		if tradeType == entities.ExactInput {
+			q = new(utils.Int256).Neg(swapResult.AmountCalculated)
+		} else {
+			q = swapResult.AmountCalculated.Clone()
+		}
+		if q.Sign() <= 0 {
+			return nil, ticksCrossed, nil
+		}
+	}
+	return q.ToBig(), ticksCrossed, nil
+}
+
+// search распределяет remaining шагов по маршрутам начиная с i; value — сумма котировок ветки.
+// При равенстве предпочитается распределение с меньшим числом маршрутов.
+func (s *splitSearch) search(i, remaining, count int, value *big.Int) {
+	if remaining == 0 {
+		if s.best == nil || s.better(value, count) {
+			s.best = append(s.best[:0], s.alloc...)
+			s.bestValue = new(big.Int).Set(value)
+			s.bestCount = count
+		}
+		return
+	}
+	if i == len(s.routes) || count == s.maxSplits {
+		return
+	}
+
+	sr := s.routes[i]
+	if s.disjoint(sr) {
+		for _, addr := range sr.pools {
+			s.usedPools[addr] = true
+		}
+		for k := remaining; k > 0; k-- {
+			if sr.quotes[k] == nil {
+				continue
+			}
+			s.alloc[i] = k
+			s.search(i+1, remaining-k, count+1, new(big.Int).Add(value, sr.quotes[k]))
+		}
+		s.alloc[i] = 0
+		for _, addr := range sr.pools {
+			delete(s.usedPools, addr)
+		}
+	}
+	s.search(i+1, remaining, count, value)
+}
+
+func (s *splitSearch) better(value *big.Int, count int) bool {
+	c := value.Cmp(s.bestValue)
+	if !s.exactIn {
+		c = -c
+	}
+	return c > 0 || (c == 0 && count < s.bestCount)
+}
+
+func (s *splitSearch) disjoint(sr *splitRoute) bool {
+	for _, addr := range sr.pools {
+		if s.usedPools[addr] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vuquang23/int256"
+)
+
+// newSplitTestPool — full range пул с ценой 1 и ликвидностью liquidity.
+func newSplitTestPool(tokenA, tokenB *entities.Token, fee constants.FeeAmount, liquidity *big.Int) *Pool {
+	token0, token1 := tokenA, tokenB
+	if sorted, _ := utils.SortsBefore(tokenB, tokenA); sorted {
+		token0, token1 = tokenB, tokenA
+	}
+	spacing := constants.TickSpacings[fee]
+	th := NewTicksHandler()
+	th.SetTicks([]Tick{
+		{Index: NearestUsableTick(utils.MinTick, spacing), LiquidityNet: int256.MustFromBig(liquidity), LiquidityGross: uint256.MustFromBig(liquidity)},
+		{Index: NearestUsableTick(utils.MaxTick, spacing), LiquidityNet: int256.MustFromBig(new(big.Int).Neg(liquidity)), LiquidityGross: uint256.MustFromBig(liquidity)},
+	})
+	pool := NewPoolV3(common.Address{}, uint16(fee), 0, utils.EncodeSqrtRatioX96(constants.One, constants.One), token0, token1, th)
+	pool.Liquidity = uint256.MustFromBig(liquidity)
+	return pool
+}
+
+func newSplitTestRoutes(t *testing.T) (direct500, direct3000, viaWeth *Route) {
+	weth := entities.WETH9[1]
+	var err error
+	direct500, err = NewRoute([]*Pool{newSplitTestPool(USDC, DAI, constants.FeeLow, OneEther)}, USDC, DAI)
+	require.NoError(t, err)
+	direct3000, err = NewRoute([]*Pool{newSplitTestPool(USDC, DAI, constants.FeeMedium, OneEther)}, USDC, DAI)
+	require.NoError(t, err)
+	viaWeth, err = NewRoute([]*Pool{
+		newSplitTestPool(USDC, weth, constants.FeeMedium, OneEther),
+		newSplitTestPool(weth, DAI, constants.FeeMedium, OneEther),
+	}, USDC, DAI)
+	require.NoError(t, err)
+	return
+}
+
+func TestBestSplitTradeExactIn(t *testing.T) {
+	direct500, direct3000, viaWeth := newSplitTestRoutes(t)
+	routes := []*Route{direct500, direct3000, viaWeth}
+
+	// маленькая сумма целиком идёт в пул с меньшей комиссией
+	small := entities.FromRawAmount(USDC, big.NewInt(1e6))
+	trade, err := BestSplitTradeExactIn(routes, small, nil)
+	require.NoError(t, err)
+	require.Len(t, trade.Swaps, 1)
+	assert.Same(t, direct500, trade.Swaps[0].Route)
+
+	// крупная сумма с заметным price impact выгоднее разбита
+	amountIn := entities.FromRawAmount(USDC, big.NewInt(3e17))
+	trade, err = BestSplitTradeExactIn(routes, amountIn, nil)
+	require.NoError(t, err)
+	assert.Greater(t, len(trade.Swaps), 1)
+	assert.Equal(t, amountIn.Quotient(), trade.InputAmount().Quotient())
+	for _, route := range routes {
+		single, err := ExactIn(route, amountIn)
+		require.NoError(t, err)
+		assert.True(t, trade.OutputAmount().GreaterThan(single.OutputAmount().Fraction),
+			"split %s should beat single route %s", trade.OutputAmount().Quotient(), single.OutputAmount().Quotient())
+	}
+
+	// при дорогом газе каждый лишний маршрут стоит больше, чем выигрыш от разбиения
+	m := NewGasModel(big.NewInt(1e12), entities.NewPrice(entities.WETH9[1], DAI, big.NewInt(1), big.NewInt(1)))
+	trade, err = BestSplitTradeExactIn(routes, amountIn, &SplitTradeOptions{DistributionPercent: 5, MaxSplits: 3, GasModel: m})
+	require.NoError(t, err)
+	require.Len(t, trade.Swaps, 1)
+	assert.Same(t, direct500, trade.Swaps[0].Route)
+
+	// MaxSplits=1 сводится к лучшему одиночному маршруту
+	trade, err = BestSplitTradeExactIn(routes, amountIn, &SplitTradeOptions{DistributionPercent: 10, MaxSplits: 1})
+	require.NoError(t, err)
+	require.Len(t, trade.Swaps, 1)
+	assert.Same(t, direct500, trade.Swaps[0].Route)
+}
+
+func TestBestSplitTradeExactOut(t *testing.T) {
+	direct500, direct3000, viaWeth := newSplitTestRoutes(t)
+	routes := []*Route{direct500, direct3000, viaWeth}
+
+	amountOut := entities.FromRawAmount(DAI, big.NewInt(3e17))
+	trade, err := BestSplitTradeExactOut(routes, amountOut, nil)
+	require.NoError(t, err)
+	assert.Greater(t, len(trade.Swaps), 1)
+	assert.Equal(t, amountOut.Quotient(), trade.OutputAmount().Quotient())
+	for _, route := range routes {
+		single, err := ExactOut(route, amountOut)
+		require.NoError(t, err)
+		assert.True(t, trade.InputAmount().LessThan(single.InputAmount().Fraction))
+	}
+
+	// больше, чем может отдать любой набор маршрутов
+	_, err = BestSplitTradeExactOut(routes, entities.FromRawAmount(DAI, new(big.Int).Mul(OneEther, big.NewInt(10))), nil)
+	assert.ErrorIs(t, err, ErrNoSplitFound)
+}
+
+func TestBestSplitTrade_SharedPools(t *testing.T) {
+	direct500, direct3000, _ := newSplitTestRoutes(t)
+	// тот же пул ещё раз: делить между direct500 и его копией нельзя
+	dup, err := NewRoute(direct500.Pools, USDC, DAI)
+	require.NoError(t, err)
+
+	amountIn := entities.FromRawAmount(USDC, big.NewInt(3e17))
+	trade, err := BestSplitTradeExactIn([]*Route{direct500, dup, direct3000}, amountIn, nil)
+	require.NoError(t, err)
+	require.Len(t, trade.Swaps, 2)
+	assert.Same(t, direct3000, trade.Swaps[1].Route)
+	assert.Same(t, direct500, trade.Swaps[0].Route)
+}
+
+func TestBestSplitTrade_Errors(t *testing.T) {
+	direct500, _, _ := newSplitTestRoutes(t)
+	amountIn := entities.FromRawAmount(USDC, big.NewInt(1e6))
+
+	_, err := BestSplitTradeExactIn(nil, amountIn, nil)
+	assert.ErrorIs(t, err, ErrNoRoutes)
+	_, err = BestSplitTradeExactIn([]*Route{direct500}, amountIn, &SplitTradeOptions{DistributionPercent: 30, MaxSplits: 3})
+	assert.ErrorIs(t, err, ErrInvalidDistribution)
+	_, err = BestSplitTradeExactIn([]*Route{direct500}, amountIn, &SplitTradeOptions{DistributionPercent: 5})
+	assert.ErrorIs(t, err, ErrInvalidMaxSplits)
+	_, err = BestSplitTradeExactIn([]*Route{direct500}, entities.FromRawAmount(DAI, big.NewInt(1e6)), nil)
+	assert.ErrorIs(t, err, ErrInvalidAmountForRoute)
+}
@@ -75,11 +75,11 @@ func ConstructV3Pool(client *ethclient.Client, token0, token1 *coreEntities.Toke
 	}
 
 	// create tick data provider
-	p, err := entities.NewTickListDataProvider(ticks, constants.TickSpacings[feeAmount])
+	p, err := entities.NewTickListDataProvider(ticks, int(constants.TickSpacings[feeAmount]))
 	if err != nil {
 		return nil, err
 	}
 
 	return entities.NewPool(token0, token1, constants.FeeAmount(poolFee),
-		slot0.SqrtPriceX96, liquidity, int(slot0.Tick.Int64()), p)
+		slot0.SqrtPriceX96, liquidity, int32(slot0.Tick.Int64()), p)
 }
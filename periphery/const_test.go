@@ -55,10 +55,10 @@ var (
 		},
 	}
 
-	p, _     = entities.NewTickListDataProvider(ticks, constants.TickSpacings[feeAmount])
+	p, _     = entities.NewTickListDataProvider(ticks, int(constants.TickSpacings[feeAmount]))
 	makePool = func(token0, token1 *core.Token) *entities.Pool {
 		// pool, _ := entities.NewPool(token0, token1, feeAmount, sqrtRatioX96, liquidity, tick, p)
-		pool := entities.NewPoolV3(uint16(constants.FeeMedium), int32(0), sqrtRatioX96, token0, token1, p)
+		pool := entities.NewPoolV3(common.Address{}, uint16(constants.FeeMedium), int32(0), sqrtRatioX96, token0, token1, p)
 		pool.Liquidity = liquidity
 		pool.TickCurrent = tick
 		return pool
@@ -0,0 +1,292 @@
+package periphery
+
+import (
+	_ "embed"
+	"errors"
+	"math/big"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/entities"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+//go:embed contracts/lens/QuoterV2.sol/QuoterV2.json
+var quoterV2ABI []byte
+
+// ABI, по которым работают декодеры: JSON разбирается один раз при инициализации пакета,
+// а не на каждый вызов.
+var (
+	quoterV1Contract        = GetABI(quoterABI)
+	quoterV2Contract        = GetABI(quoterV2ABI)
+	multicallContract       = GetABI(multicallABI)
+	swapRouterContract      = GetABI(swapRouterABI)
+	positionManagerContract = GetABI(nonFungiblePositionManagerABI)
+	stakerContract          = GetABI(stakerABI)
+	paymentsContract        = GetABI(paymentsABI)
+	selfPermitContract      = GetABI(selfpermitABI)
+)
+
+var (
+	ErrUnknownMethod         = errors.New("unknown method selector")
+	ErrMulticallResultsCount = errors.New("multicall results count does not match calls")
+)
+
+// MintResult — результат NonfungiblePositionManager.mint. Результаты NonfungiblePositionManager
+// остаются в *big.Int, как и параметры, из которых periphery собирает их calldata.
+type MintResult struct {
+	TokenID   *big.Int
+	Liquidity *big.Int
+	Amount0   *big.Int
+	Amount1   *big.Int
+}
+
+// IncreaseLiquidityResult — результат NonfungiblePositionManager.increaseLiquidity.
+type IncreaseLiquidityResult struct {
+	Liquidity *big.Int
+	Amount0   *big.Int
+	Amount1   *big.Int
+}
+
+// TokenAmounts — результат decreaseLiquidity и collect NonfungiblePositionManager.
+type TokenAmounts struct {
+	Amount0 *big.Int
+	Amount1 *big.Int
+}
+
+// CallResult — результат одного вызова: имя метода по селектору calldata и его outputs,
+// распакованные по ABI (uint* — *big.Int или uintN, address — common.Address).
+type CallResult struct {
+	Method  string
+	Outputs []interface{}
+}
+
+// DecodeMulticallResults — results (bytes[]) вызова multicall.
+func DecodeMulticallResults(data []byte) ([][]byte, error) {
+	out, err := unpackResult(&multicallContract, "multicall", data)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].([][]byte), nil
+}
+
+// DecodeCallResults раскладывает результат вызова с calldata, собранной этим пакетом (SwapCallParameters,
+// AddCallParameters, RemoveCallParameters, CollectRewards и т.д.), по вызовам: одиночный вызов даёт
+// один CallResult, multicall — по одному на каждый вложенный вызов в порядке calldata.
+// Quoter сюда не входит: у QuoterV1 и QuoterV2 совпадают селекторы quoteExactInput/quoteExactOutput,
+// их результаты разбирают DecodeQuoteResult и DecodeQuoteV2*.
+func DecodeCallResults(calldata, data []byte) ([]CallResult, error) {
+	method, err := lookupMethod(calldata)
+	if err != nil {
+		return nil, err
+	}
+	if method.Name != "multicall" {
+		outputs, err := method.Outputs.Unpack(data)
+		if err != nil {
+			return nil, err
+		}
+		return []CallResult{{Method: method.Name, Outputs: outputs}}, nil
+	}
+
+	inputs, err := method.Inputs.Unpack(calldata[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := inputs[0].([][]byte)
+	results, err := DecodeMulticallResults(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(calls) != len(results) {
+		return nil, ErrMulticallResultsCount
+	}
+	decoded := make([]CallResult, 0, len(calls))
+	for i, call := range calls {
+		r, err := DecodeCallResults(call, results[i])
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, r...)
+	}
+	return decoded, nil
+}
+
+// DecodeQuoteResult — amountOut/amountIn любого quoteExact* QuoterV1 (calldata QuoteCallParameters).
+func DecodeQuoteResult(data []byte) (*big.Int, error) {
+	out, err := unpackResult(&quoterV1Contract, "quoteExactInput", data)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].(*big.Int), nil
+}
+
+// DecodeQuoteResultUint256 — DecodeQuoteResult в uint256, как суммы в entities.
+func DecodeQuoteResultUint256(data []byte) (*uint256.Int, error) {
+	return decodeUint256(DecodeQuoteResult(data))
+}
+
+// DecodeQuoteV2SingleResult — результат quoteExactInputSingle/quoteExactOutputSingle QuoterV2
+// в тех же типах, что возвращает локальный entities.QuoteExact*Single. Варианта в uint256 нет:
+// результат сравнивают с локальной котировкой, а её поля — *big.Int.
+func DecodeQuoteV2SingleResult(data []byte) (*entities.QuoteSingleResult, error) {
+	out, err := unpackResult(&quoterV2Contract, "quoteExactInputSingle", data)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.QuoteSingleResult{
+		Amount:                  out[0].(*big.Int),
+		SqrtPriceX96After:       out[1].(*big.Int),
+		InitializedTicksCrossed: out[2].(uint32),
+		GasEstimate:             out[3].(*big.Int),
+	}, nil
+}
+
+// DecodeQuoteV2Result — результат quoteExactInput/quoteExactOutput QuoterV2 в тех же типах,
+// что возвращает локальный entities.QuoteExactInput/QuoteExactOutput.
+func DecodeQuoteV2Result(data []byte) (*entities.QuoteResult, error) {
+	out, err := unpackResult(&quoterV2Contract, "quoteExactInput", data)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.QuoteResult{
+		Amount:                      out[0].(*big.Int),
+		SqrtPriceX96AfterList:       out[1].([]*big.Int),
+		InitializedTicksCrossedList: out[2].([]uint32),
+		GasEstimate:                 out[3].(*big.Int),
+	}, nil
+}
+
+// DecodeSwapResult — amountOut/amountIn exactInput*/exactOutput* SwapRouter.
+func DecodeSwapResult(data []byte) (*big.Int, error) {
+	out, err := unpackResult(&swapRouterContract, "exactInput", data)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].(*big.Int), nil
+}
+
+// DecodeSwapResultUint256 — DecodeSwapResult в uint256.
+func DecodeSwapResultUint256(data []byte) (*uint256.Int, error) {
+	return decodeUint256(DecodeSwapResult(data))
+}
+
+// DecodeSwapCallResults — суммы свапов из результата вызова с calldata SwapCallParameters, по одной
+// на каждый exactInput*/exactOutput* в порядке calldata (amountOut для exact input, amountIn для
+// exact output). Вызовы без результата (selfPermit, unwrapWETH9, sweepToken, refundETH) пропускаются.
+func DecodeSwapCallResults(calldata, data []byte) ([]*big.Int, error) {
+	results, err := DecodeCallResults(calldata, data)
+	if err != nil {
+		return nil, err
+	}
+	var amounts []*big.Int
+	for _, r := range results {
+		switch r.Method {
+		case "exactInputSingle", "exactInput", "exactOutputSingle", "exactOutput":
+			amounts = append(amounts, r.Outputs[0].(*big.Int))
+		}
+	}
+	return amounts, nil
+}
+
+// DecodeSwapCallResultsUint256 — DecodeSwapCallResults в uint256.
+func DecodeSwapCallResultsUint256(calldata, data []byte) ([]*uint256.Int, error) {
+	amounts, err := DecodeSwapCallResults(calldata, data)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*uint256.Int, len(amounts))
+	for i, amount := range amounts {
+		// uint256 в ABI: переполнения и отрицательных значений быть не может
+		result[i] = uint256.MustFromBig(amount)
+	}
+	return result, nil
+}
+
+// DecodeCreatePoolResult — адрес пула из createAndInitializePoolIfNecessary.
+func DecodeCreatePoolResult(data []byte) (common.Address, error) {
+	out, err := unpackResult(&positionManagerContract, "createAndInitializePoolIfNecessary", data)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return out[0].(common.Address), nil
+}
+
+// DecodeMintResult — результат mint NonfungiblePositionManager.
+func DecodeMintResult(data []byte) (*MintResult, error) {
+	out, err := unpackResult(&positionManagerContract, "mint", data)
+	if err != nil {
+		return nil, err
+	}
+	return &MintResult{
+		TokenID:   out[0].(*big.Int),
+		Liquidity: out[1].(*big.Int),
+		Amount0:   out[2].(*big.Int),
+		Amount1:   out[3].(*big.Int),
+	}, nil
+}
+
+// DecodeIncreaseLiquidityResult — результат increaseLiquidity NonfungiblePositionManager.
+func DecodeIncreaseLiquidityResult(data []byte) (*IncreaseLiquidityResult, error) {
+	out, err := unpackResult(&positionManagerContract, "increaseLiquidity", data)
+	if err != nil {
+		return nil, err
+	}
+	return &IncreaseLiquidityResult{
+		Liquidity: out[0].(*big.Int),
+		Amount0:   out[1].(*big.Int),
+		Amount1:   out[2].(*big.Int),
+	}, nil
+}
+
+// DecodeDecreaseLiquidityResult — результат decreaseLiquidity NonfungiblePositionManager.
+func DecodeDecreaseLiquidityResult(data []byte) (*TokenAmounts, error) {
+	return decodeTokenAmounts("decreaseLiquidity", data)
+}
+
+// DecodeCollectResult — результат collect NonfungiblePositionManager.
+func DecodeCollectResult(data []byte) (*TokenAmounts, error) {
+	return decodeTokenAmounts("collect", data)
+}
+
+// DecodeClaimRewardResult — reward из claimReward UniswapV3Staker.
+func DecodeClaimRewardResult(data []byte) (*big.Int, error) {
+	out, err := unpackResult(&stakerContract, "claimReward", data)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].(*big.Int), nil
+}
+
+func decodeUint256(x *big.Int, err error) (*uint256.Int, error) {
+	if err != nil {
+		return nil, err
+	}
+	return uint256.MustFromBig(x), nil
+}
+
+func decodeTokenAmounts(method string, data []byte) (*TokenAmounts, error) {
+	out, err := unpackResult(&positionManagerContract, method, data)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenAmounts{Amount0: out[0].(*big.Int), Amount1: out[1].(*big.Int)}, nil
+}
+
+func unpackResult(contract *abi.ABI, method string, data []byte) ([]interface{}, error) {
+	return contract.Unpack(method, data)
+}
+
+// callABIs — ABI, по которым DecodeCallResults ищет метод по селектору calldata.
+var callABIs = []*abi.ABI{&multicallContract, &swapRouterContract, &positionManagerContract, &stakerContract, &paymentsContract, &selfPermitContract}
+
+func lookupMethod(calldata []byte) (*abi.Method, error) {
+	if len(calldata) < 4 {
+		return nil, ErrUnknownMethod
+	}
+	for _, contract := range callABIs {
+		if method, err := contract.MethodById(calldata[:4]); err == nil {
+			return method, nil
+		}
+	}
+	return nil, ErrUnknownMethod
+}
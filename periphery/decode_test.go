@@ -0,0 +1,125 @@
+package periphery
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// packResult кодирует outputs метода так, как их вернул бы контракт.
+func packResult(t *testing.T, abiJSON []byte, method string, values ...interface{}) []byte {
+	abi := GetABI(abiJSON)
+	data, err := abi.Methods[method].Outputs.Pack(values...)
+	require.NoError(t, err)
+	return data
+}
+
+func TestDecodeQuoteResults(t *testing.T) {
+	amount, err := DecodeQuoteResult(packResult(t, quoterABI, "quoteExactOutput", big.NewInt(12345)))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(12345), amount)
+	amountU, err := DecodeQuoteResultUint256(packResult(t, quoterABI, "quoteExactOutput", big.NewInt(12345)))
+	require.NoError(t, err)
+	assert.Equal(t, uint256.NewInt(12345), amountU)
+
+	single, err := DecodeQuoteV2SingleResult(packResult(t, quoterV2ABI, "quoteExactOutputSingle",
+		big.NewInt(100), big.NewInt(1<<62), uint32(3), big.NewInt(90000)))
+	require.NoError(t, err)
+	assert.Equal(t, &entities.QuoteSingleResult{
+		Amount:                  big.NewInt(100),
+		SqrtPriceX96After:       big.NewInt(1 << 62),
+		InitializedTicksCrossed: 3,
+		GasEstimate:             big.NewInt(90000),
+	}, single)
+
+	multi, err := DecodeQuoteV2Result(packResult(t, quoterV2ABI, "quoteExactInput",
+		big.NewInt(200), []*big.Int{big.NewInt(1), big.NewInt(2)}, []uint32{0, 4}, big.NewInt(150000)))
+	require.NoError(t, err)
+	assert.Equal(t, &entities.QuoteResult{
+		Amount:                      big.NewInt(200),
+		SqrtPriceX96AfterList:       []*big.Int{big.NewInt(1), big.NewInt(2)},
+		InitializedTicksCrossedList: []uint32{0, 4},
+		GasEstimate:                 big.NewInt(150000),
+	}, multi)
+
+	_, err = DecodeQuoteV2Result([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestDecodeSwapCallResults(t *testing.T) {
+	router := GetABI(swapRouterABI)
+	swapCalldata, err := router.Pack("exactInputSingle", &ExactInputSingleParams{
+		TokenIn:           common.HexToAddress("0x01"),
+		TokenOut:          common.HexToAddress("0x02"),
+		Fee:               big.NewInt(3000),
+		Deadline:          big.NewInt(123),
+		AmountIn:          big.NewInt(100),
+		AmountOutMinimum:  big.NewInt(0),
+		SqrtPriceLimitX96: big.NewInt(0),
+	})
+	require.NoError(t, err)
+	swapResult := packResult(t, swapRouterABI, "exactInputSingle", big.NewInt(97))
+
+	// одиночный вызов: EncodeMulticall отдаёт calldata как есть
+	amounts, err := DecodeSwapCallResults(swapCalldata, swapResult)
+	require.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(97)}, amounts)
+
+	unwrapCalldata, err := EncodeUnwrapWETH9(big.NewInt(97), common.HexToAddress("0x03"), nil)
+	require.NoError(t, err)
+	calldata, err := EncodeMulticall([][]byte{swapCalldata, swapCalldata, unwrapCalldata})
+	require.NoError(t, err)
+	data := packResult(t, multicallABI, "multicall", [][]byte{swapResult, packResult(t, swapRouterABI, "exactInputSingle", big.NewInt(95)), {}})
+
+	results, err := DecodeCallResults(calldata, data)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, "unwrapWETH9", results[2].Method)
+	assert.Empty(t, results[2].Outputs)
+
+	amounts, err = DecodeSwapCallResults(calldata, data)
+	require.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(97), big.NewInt(95)}, amounts)
+	amountsU, err := DecodeSwapCallResultsUint256(calldata, data)
+	require.NoError(t, err)
+	assert.Equal(t, []*uint256.Int{uint256.NewInt(97), uint256.NewInt(95)}, amountsU)
+	swapAmount, err := DecodeSwapResultUint256(swapResult)
+	require.NoError(t, err)
+	assert.Equal(t, uint256.NewInt(97), swapAmount)
+
+	_, err = DecodeCallResults(calldata, packResult(t, multicallABI, "multicall", [][]byte{swapResult}))
+	assert.ErrorIs(t, err, ErrMulticallResultsCount)
+	_, err = DecodeCallResults([]byte{0xde, 0xad, 0xbe, 0xef}, nil)
+	assert.ErrorIs(t, err, ErrUnknownMethod)
+}
+
+func TestDecodePositionManagerResults(t *testing.T) {
+	pool, err := DecodeCreatePoolResult(packResult(t, nonFungiblePositionManagerABI, "createAndInitializePoolIfNecessary", common.HexToAddress("0xabc")))
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0xabc"), pool)
+
+	mint, err := DecodeMintResult(packResult(t, nonFungiblePositionManagerABI, "mint", big.NewInt(7), big.NewInt(1000), big.NewInt(10), big.NewInt(20)))
+	require.NoError(t, err)
+	assert.Equal(t, &MintResult{TokenID: big.NewInt(7), Liquidity: big.NewInt(1000), Amount0: big.NewInt(10), Amount1: big.NewInt(20)}, mint)
+
+	increase, err := DecodeIncreaseLiquidityResult(packResult(t, nonFungiblePositionManagerABI, "increaseLiquidity", big.NewInt(1000), big.NewInt(10), big.NewInt(20)))
+	require.NoError(t, err)
+	assert.Equal(t, &IncreaseLiquidityResult{Liquidity: big.NewInt(1000), Amount0: big.NewInt(10), Amount1: big.NewInt(20)}, increase)
+
+	decrease, err := DecodeDecreaseLiquidityResult(packResult(t, nonFungiblePositionManagerABI, "decreaseLiquidity", big.NewInt(10), big.NewInt(20)))
+	require.NoError(t, err)
+	assert.Equal(t, &TokenAmounts{Amount0: big.NewInt(10), Amount1: big.NewInt(20)}, decrease)
+
+	collect, err := DecodeCollectResult(packResult(t, nonFungiblePositionManagerABI, "collect", big.NewInt(1), big.NewInt(2)))
+	require.NoError(t, err)
+	assert.Equal(t, &TokenAmounts{Amount0: big.NewInt(1), Amount1: big.NewInt(2)}, collect)
+
+	reward, err := DecodeClaimRewardResult(packResult(t, stakerABI, "claimReward", big.NewInt(55)))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(55), reward)
+}
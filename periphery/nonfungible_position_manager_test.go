@@ -39,7 +39,7 @@ func TestCreateCallParameters(t *testing.T) {
 
 func TestAddCallParameters(t *testing.T) {
 	// throws if liquidity is 0
-	pos, err := entities.NewPosition(pool01T, uint256.NewInt(0), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err := entities.NewPosition(pool01T, uint256.NewInt(0), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts := &AddLiquidityOptions{
 		MintSpecificOptions: &MintSpecificOptions{
@@ -54,7 +54,7 @@ func TestAddCallParameters(t *testing.T) {
 	assert.ErrorIs(t, err, ErrZeroLiquidity)
 
 	// throws if pool does not involve ether and useNative is true
-	pos, err = entities.NewPosition(pool01T, uint256.NewInt(1), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err = entities.NewPosition(pool01T, uint256.NewInt(1), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts = &AddLiquidityOptions{
 		MintSpecificOptions: &MintSpecificOptions{
@@ -70,7 +70,7 @@ func TestAddCallParameters(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNoWETH)
 
 	// succeeds for mint
-	pos, err = entities.NewPosition(pool01T, uint256.NewInt(1), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err = entities.NewPosition(pool01T, uint256.NewInt(1), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts = &AddLiquidityOptions{
 		MintSpecificOptions: &MintSpecificOptions{
@@ -87,7 +87,7 @@ func TestAddCallParameters(t *testing.T) {
 	assert.Equal(t, "0x00", utils.ToHex(params.Value))
 
 	// succeeds for increase
-	pos, err = entities.NewPosition(pool01T, uint256.NewInt(1), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err = entities.NewPosition(pool01T, uint256.NewInt(1), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts = &AddLiquidityOptions{
 		IncreaseSpecificOptions: &IncreaseSpecificOptions{
@@ -104,7 +104,7 @@ func TestAddCallParameters(t *testing.T) {
 	assert.Equal(t, "0x00", utils.ToHex(params.Value))
 
 	// createPool
-	pos, err = entities.NewPosition(pool01T, uint256.NewInt(1), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err = entities.NewPosition(pool01T, uint256.NewInt(1), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts = &AddLiquidityOptions{
 		CommonAddLiquidityOptions: &CommonAddLiquidityOptions{
@@ -122,7 +122,7 @@ func TestAddCallParameters(t *testing.T) {
 	assert.Equal(t, "0x00", utils.ToHex(params.Value))
 
 	// useNative
-	pos, err = entities.NewPosition(pool1wethT, uint256.NewInt(1), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err = entities.NewPosition(pool1wethT, uint256.NewInt(1), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts = &AddLiquidityOptions{
 		CommonAddLiquidityOptions: &CommonAddLiquidityOptions{
@@ -170,7 +170,7 @@ func TestCollectCallParameters(t *testing.T) {
 
 func TestRemoveCallParameters(t *testing.T) {
 	// throws for 0 liquidity
-	pos, err := entities.NewPosition(pool01T, uint256.NewInt(0), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err := entities.NewPosition(pool01T, uint256.NewInt(0), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts := &RemoveLiquidityOptions{
 		TokenID:             tokenIDT,
@@ -187,7 +187,7 @@ func TestRemoveCallParameters(t *testing.T) {
 	assert.Error(t, err, ErrZeroLiquidity)
 
 	// throws for 0 liquidity from small percentage
-	pos, err = entities.NewPosition(pool01T, uint256.NewInt(50), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err = entities.NewPosition(pool01T, uint256.NewInt(50), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts = &RemoveLiquidityOptions{
 		TokenID:             tokenIDT,
@@ -204,7 +204,7 @@ func TestRemoveCallParameters(t *testing.T) {
 	assert.Error(t, err, ErrZeroLiquidity)
 
 	// throws for bad burn
-	pos, err = entities.NewPosition(pool01T, uint256.NewInt(50), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err = entities.NewPosition(pool01T, uint256.NewInt(50), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts = &RemoveLiquidityOptions{
 		TokenID:             tokenIDT,
@@ -222,7 +222,7 @@ func TestRemoveCallParameters(t *testing.T) {
 	assert.Error(t, err, ErrCannotBurn)
 
 	// works
-	pos, err = entities.NewPosition(pool01T, uint256.NewInt(100), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err = entities.NewPosition(pool01T, uint256.NewInt(100), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts = &RemoveLiquidityOptions{
 		TokenID:             tokenIDT,
@@ -241,7 +241,7 @@ func TestRemoveCallParameters(t *testing.T) {
 	assert.Equal(t, "0x00", utils.ToHex(params.Value))
 
 	// works for partial
-	pos, err = entities.NewPosition(pool01T, uint256.NewInt(100), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err = entities.NewPosition(pool01T, uint256.NewInt(100), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts = &RemoveLiquidityOptions{
 		TokenID:             tokenIDT,
@@ -279,7 +279,7 @@ func TestRemoveCallParameters(t *testing.T) {
 		owed0Token = core.EtherOnChain(1).Wrapped()
 		owed1Token = token1T
 	}
-	pos, err = entities.NewPosition(pool01T, uint256.NewInt(100), -constants.TickSpacings[constants.FeeMedium], constants.TickSpacings[constants.FeeMedium])
+	pos, err = entities.NewPosition(pool01T, uint256.NewInt(100), -int32(constants.TickSpacings[constants.FeeMedium]), int32(constants.TickSpacings[constants.FeeMedium]))
 	assert.NoError(t, err)
 	opts = &RemoveLiquidityOptions{
 		TokenID:             tokenIDT,
@@ -0,0 +1,368 @@
+package reference
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/entities"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// Дифференциальные проверки оптимизированных путей utils и entities.Pool против reference.
+// Под `go test` прогоняется детерминированный seed-корпус (seedCount входов на fuzz-цель),
+// для поиска расхождений — `go test ./reference -fuzz=FuzzPoolSwap` и т. п.
+
+const seedCount = 200
+
+var fees = []uint32{100, 500, 3000, 10000}
+
+// u256 собирает uint256 из четырёх слов, обрезая его до bits бит.
+func u256(w0, w1, w2, w3 uint64, bits uint) *uint256.Int {
+	v := &uint256.Int{w0, w1, w2, w3}
+	if bits < 256 {
+		v.And(v, new(uint256.Int).SubUint64(new(uint256.Int).Lsh(uint256.NewInt(1), bits), 1))
+	}
+	return v
+}
+
+// clampTick переводит произвольный int32 в [lo, hi].
+func clampTick(t int32, lo, hi int32) int32 {
+	span := int64(hi) - int64(lo) + 1
+	return int32(((int64(t)-int64(lo))%span+span)%span + int64(lo))
+}
+
+// sqrtInTick — цена внутри тика: sqrtRatio(tick) + offset, но строго ниже sqrtRatio(tick+1).
+func sqrtInTick(tick int32, offset uint64) *big.Int {
+	lo, _ := GetSqrtRatioAtTick(tick)
+	hi, _ := GetSqrtRatioAtTick(tick + 1)
+	span := new(big.Int).Sub(hi, lo)
+	return lo.Add(lo, new(big.Int).Mod(new(big.Int).SetUint64(offset), span))
+}
+
+func FuzzMulDiv(f *testing.F) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < seedCount; i++ {
+		f.Add(rng.Uint64(), rng.Uint64(), rng.Uint64(), rng.Uint64(), rng.Uint64(), rng.Uint64(), uint8(rng.Intn(257)), uint8(rng.Intn(257)), uint8(1+rng.Intn(256)))
+	}
+	f.Fuzz(func(t *testing.T, a0, a1, b0, b1, d0, d1 uint64, aBits, bBits, dBits uint8) {
+		a := u256(a0, a1, a1^a0, a0*3, uint(aBits))
+		b := u256(b0, b1, b0^b1, b1*5, uint(bBits))
+		d := u256(d0, d1, d0^d1, d1*7, uint(dBits))
+		if d.IsZero() {
+			return
+		}
+		fm := utils.NewFullMath()
+
+		want, wantErr := MulDiv(a.ToBig(), b.ToBig(), d.ToBig())
+		var got utils.Uint256
+		err := fm.MulDivV2(a, b, d, &got, nil)
+		if wantErr != nil {
+			assert.ErrorIs(t, err, utils.ErrMulDivOverflow)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, want.String(), got.Dec(), "mulDiv(%s, %s, %s)", a.Dec(), b.Dec(), d.Dec())
+		}
+
+		want, wantErr = MulDivRoundingUp(a.ToBig(), b.ToBig(), d.ToBig())
+		err = fm.MulDivRoundingUpV2(a, b, d, &got)
+		if wantErr != nil {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, want.String(), got.Dec(), "mulDivRoundingUp(%s, %s, %s)", a.Dec(), b.Dec(), d.Dec())
+		}
+
+		want, _ = DivRoundingUp(a.ToBig(), d.ToBig())
+		fm.DivRoundingUp(a, d, &got)
+		assert.Equal(t, want.String(), got.Dec())
+	})
+}
+
+func FuzzTickMath(f *testing.F) {
+	for _, tick := range []int32{MinTick, MinTick + 1, -1, 0, 1, MaxTick - 1, MaxTick} {
+		f.Add(tick, uint64(0))
+		f.Add(tick, ^uint64(0))
+	}
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < seedCount; i++ {
+		f.Add(rng.Int31(), rng.Uint64())
+	}
+	f.Fuzz(func(t *testing.T, tick int32, offset uint64) {
+		tick = clampTick(tick, MinTick, MaxTick)
+		tc := utils.NewTickCalculator()
+
+		want, err := GetSqrtRatioAtTick(tick)
+		require.NoError(t, err)
+		var got utils.Uint160
+		tc.GetSqrtRatioAtTickV2(tick, &got)
+		assert.Equal(t, want.String(), got.Dec(), "getSqrtRatioAtTick(%d)", tick)
+
+		if tick == MaxTick {
+			return
+		}
+		// границы тика и произвольная цена внутри него
+		for _, sqrtP := range []*big.Int{want, sqrtInTick(tick, offset), sqrtInTick(tick, ^uint64(0)>>1)} {
+			wantTick, err := GetTickAtSqrtRatio(sqrtP)
+			require.NoError(t, err)
+			assert.Equal(t, tick, wantTick)
+			gotTick, err := tc.GetTickAtSqrtRatioV2(uint256.MustFromBig(sqrtP))
+			assert.NoError(t, err)
+			assert.Equal(t, wantTick, gotTick, "getTickAtSqrtRatio(%s)", sqrtP)
+		}
+		if tick > MinTick {
+			below := new(big.Int).Sub(want, big.NewInt(1))
+			gotTick, err := tc.GetTickAtSqrtRatioV2(uint256.MustFromBig(below))
+			assert.NoError(t, err)
+			assert.Equal(t, tick-1, gotTick)
+		}
+	})
+}
+
+func FuzzSqrtPriceMath(f *testing.F) {
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < seedCount; i++ {
+		f.Add(rng.Int31(), rng.Int31(), rng.Uint64(), rng.Uint64(), rng.Uint64(), rng.Uint64(), uint8(rng.Intn(129)), uint8(rng.Intn(129)), rng.Intn(2) == 0)
+	}
+	f.Fuzz(func(t *testing.T, tickA, tickB int32, offset, l0, l1, amount uint64, liquidityBits, amountBits uint8, zeroForOne bool) {
+		tickA = clampTick(tickA, MinTick, MaxTick-1)
+		tickB = clampTick(tickB, MinTick, MaxTick-1)
+		sqrtA, sqrtB := sqrtInTick(tickA, offset), sqrtInTick(tickB, offset>>7)
+		if sqrtA.Cmp(sqrtB) > 0 {
+			sqrtA, sqrtB = sqrtB, sqrtA
+		}
+		liquidity := u256(l0, l1, 0, 0, uint(liquidityBits)%129)
+		amountIn := u256(amount, l0^amount, 0, 0, uint(amountBits)%129)
+		sc := utils.NewSqrtPriceCalculator()
+		a, b := uint256.MustFromBig(sqrtA), uint256.MustFromBig(sqrtB)
+
+		var got utils.Uint256
+		for _, roundUp := range []bool{false, true} {
+			want, err := GetAmount0Delta(sqrtA, sqrtB, liquidity.ToBig(), roundUp)
+			require.NoError(t, err)
+			assert.NoError(t, sc.GetAmount0DeltaV2(a, b, liquidity, roundUp, &got))
+			assert.Equal(t, want.String(), got.Dec(), "getAmount0Delta(%s, %s, %s, %v)", sqrtA, sqrtB, liquidity.Dec(), roundUp)
+
+			want, err = GetAmount1Delta(sqrtA, sqrtB, liquidity.ToBig(), roundUp)
+			require.NoError(t, err)
+			assert.NoError(t, sc.GetAmount1DeltaV2(a, b, liquidity, roundUp, &got))
+			assert.Equal(t, want.String(), got.Dec(), "getAmount1Delta(%s, %s, %s, %v)", sqrtA, sqrtB, liquidity.Dec(), roundUp)
+		}
+
+		// вне области определения контракта (revert) оптимизированный путь сверяется только по значению
+		if liquidity.IsZero() {
+			return
+		}
+		if want, err := GetNextSqrtPriceFromInput(sqrtA, liquidity.ToBig(), amountIn.ToBig(), zeroForOne); err == nil {
+			assert.NoError(t, sc.GetNextSqrtPriceFromInput(a, liquidity, amountIn, zeroForOne, &got))
+			assert.Equal(t, want.String(), got.Dec(), "getNextSqrtPriceFromInput(%s, %s, %s, %v)", sqrtA, liquidity.Dec(), amountIn.Dec(), zeroForOne)
+		}
+		if want, err := GetNextSqrtPriceFromOutput(sqrtA, liquidity.ToBig(), amountIn.ToBig(), zeroForOne); err == nil {
+			assert.NoError(t, sc.GetNextSqrtPriceFromOutput(a, liquidity, amountIn, zeroForOne, &got))
+			assert.Equal(t, want.String(), got.Dec(), "getNextSqrtPriceFromOutput(%s, %s, %s, %v)", sqrtA, liquidity.Dec(), amountIn.Dec(), zeroForOne)
+		}
+	})
+}
+
+func FuzzComputeSwapStep(f *testing.F) {
+	rng := rand.New(rand.NewSource(4))
+	for i := 0; i < seedCount; i++ {
+		f.Add(rng.Int31(), rng.Int31n(20000)-10000, rng.Uint64(), rng.Uint64(), rng.Uint64(), uint8(rng.Intn(129)), uint8(rng.Intn(128)), rng.Intn(2) == 0, uint8(rng.Intn(len(fees))))
+	}
+	f.Fuzz(func(t *testing.T, tick, delta int32, offset, l0, amount uint64, liquidityBits, amountBits uint8, exactIn bool, feeIdx uint8) {
+		tick = clampTick(tick, MinTick, MaxTick-1)
+		target := clampTick(tick+delta, MinTick, MaxTick-1)
+		sqrtCurrent, sqrtTarget := sqrtInTick(tick, offset), sqrtInTick(target, offset>>3)
+		zeroForOne := sqrtCurrent.Cmp(sqrtTarget) >= 0
+		liquidity := u256(l0, l0>>3, 0, 0, uint(liquidityBits)%129)
+		remaining := u256(amount, amount^l0, 0, 0, uint(amountBits)%128).ToBig()
+		if !exactIn {
+			remaining.Neg(remaining)
+		}
+		exactIn = remaining.Sign() >= 0 // -0 — exact input, как в контракте
+		fee := fees[int(feeIdx)%len(fees)]
+
+		want, err := ComputeSwapStep(sqrtCurrent, sqrtTarget, liquidity.ToBig(), remaining, fee)
+		require.NoError(t, err)
+
+		var next utils.Uint160
+		var amountIn, amountOut, feeAmount utils.Uint256
+		utils.NewSwapStepCalculator().ComputeSwapStep(uint256.MustFromBig(sqrtCurrent), uint256.MustFromBig(sqrtTarget), liquidity,
+			int256.MustFromBig(remaining), uint64(fee), &next, &amountIn, &amountOut, &feeAmount, zeroForOne, exactIn)
+
+		msg := []any{"computeSwapStep(%s, %s, %s, %s, %d)", sqrtCurrent, sqrtTarget, liquidity.Dec(), remaining, fee}
+		assert.Equal(t, want.SqrtRatioNextX96.String(), next.Dec(), msg...)
+		assert.Equal(t, want.AmountIn.String(), amountIn.Dec(), msg...)
+		assert.Equal(t, want.AmountOut.String(), amountOut.Dec(), msg...)
+		assert.Equal(t, want.FeeAmount.String(), feeAmount.Dec(), msg...)
+	})
+}
+
+func FuzzMaxLiquidityForAmounts(f *testing.F) {
+	rng := rand.New(rand.NewSource(5))
+	for i := 0; i < seedCount; i++ {
+		f.Add(rng.Int31(), rng.Int31(), rng.Int31(), rng.Uint64(), rng.Uint64(), rng.Uint64(), rng.Intn(2) == 0)
+	}
+	f.Fuzz(func(t *testing.T, tickCurrent, tickA, tickB int32, offset, amount0, amount1 uint64, useFullPrecision bool) {
+		// промежуточные произведения SDK-формул должны помещаться в uint256: цены ниже 2^128,
+		// суммы — до 2^96 (реальные пулы далеко внутри этой области)
+		const maxTick = 443636
+		tickCurrent = clampTick(tickCurrent, MinTick, maxTick)
+		tickA = clampTick(tickA, MinTick, maxTick)
+		tickB = clampTick(tickB, MinTick, maxTick)
+		if tickA == tickB {
+			return
+		}
+		sqrtCurrent := sqrtInTick(tickCurrent, offset)
+		sqrtA, _ := GetSqrtRatioAtTick(tickA)
+		sqrtB, _ := GetSqrtRatioAtTick(tickB)
+		a0 := new(big.Int).Lsh(new(big.Int).SetUint64(amount0), 32)
+		a1 := new(big.Int).Lsh(new(big.Int).SetUint64(amount1), 32)
+
+		want := MaxLiquidityForAmounts(sqrtCurrent, sqrtA, sqrtB, a0, a1, useFullPrecision)
+		got := utils.NewMaxLiquidityForAmountsCalculator().MaxLiquidityForAmounts(uint256.MustFromBig(sqrtCurrent),
+			uint256.MustFromBig(sqrtA), uint256.MustFromBig(sqrtB), uint256.MustFromBig(a0), uint256.MustFromBig(a1), useFullPrecision)
+		assert.Equal(t, want.String(), got.Dec())
+	})
+}
+
+// swapFixture — пул из случайных позиций на сетке tickSpacing в обоих представлениях.
+type swapFixture struct {
+	ref  *Pool
+	pool *entities.Pool
+}
+
+func newSwapFixture(t *testing.T, seed int64) swapFixture {
+	rng := rand.New(rand.NewSource(seed))
+	feeAmount := constants.FeeAmount(fees[rng.Intn(len(fees))])
+	spacing := int32(constants.TickSpacings[feeAmount])
+
+	// позиции в окрестности текущего тика, иногда — у границ диапазона
+	center := rng.Int31n(2*400000) - 400000
+	if rng.Intn(8) == 0 {
+		center = MaxTick - 5000
+	}
+	if rng.Intn(8) == 0 {
+		center = MinTick + 5000
+	}
+	minUsable := (MinTick/spacing + 1) * spacing
+	maxUsable := (MaxTick/spacing - 1) * spacing
+
+	net := map[int32]*big.Int{}
+	var positions [][3]*big.Int
+	for n := rng.Intn(12); n >= 0; n-- {
+		lower := (center + rng.Int31n(400*spacing) - 200*spacing) / spacing * spacing
+		upper := lower + (1+rng.Int31n(100))*spacing
+		lower = max(minUsable, min(lower, maxUsable-spacing))
+		upper = max(lower+spacing, min(upper, maxUsable))
+		liquidity := new(big.Int).Rand(rng, new(big.Int).Lsh(big.NewInt(1), uint(40+rng.Intn(60))))
+		liquidity.Add(liquidity, big.NewInt(1))
+		for tick, sign := range map[int32]int64{lower: 1, upper: -1} {
+			if net[tick] == nil {
+				net[tick] = new(big.Int)
+			}
+			net[tick].Add(net[tick], new(big.Int).Mul(liquidity, big.NewInt(sign)))
+		}
+		positions = append(positions, [3]*big.Int{big.NewInt(int64(lower)), big.NewInt(int64(upper)), liquidity})
+	}
+
+	tickCurrent := clampTick(center+rng.Int31n(100*spacing)-50*spacing, MinTick, MaxTick-1)
+	sqrtP := sqrtInTick(tickCurrent, rng.Uint64())
+	active := new(big.Int)
+	for _, p := range positions {
+		if p[0].Int64() <= int64(tickCurrent) && int64(tickCurrent) < p[1].Int64() {
+			active.Add(active, p[2])
+		}
+	}
+
+	ref := &Pool{
+		Fee:                uint32(feeAmount),
+		TickSpacing:        spacing,
+		SqrtPriceX96:       sqrtP,
+		Liquidity:          active,
+		Tick:               tickCurrent,
+		FeeProtocol0:       uint8([]int{0, 4, 7, 10}[rng.Intn(4)]),
+		FeeProtocol1:       uint8([]int{0, 5, 6, 9}[rng.Intn(4)]),
+		TickBitmapStepping: rng.Intn(2) == 0,
+	}
+	var ticks []entities.Tick
+	for tick := minUsable; tick <= maxUsable; tick += spacing {
+		if net[tick] == nil {
+			continue
+		}
+		ref.Ticks = append(ref.Ticks, Tick{Index: tick, LiquidityNet: net[tick]})
+		ticks = append(ticks, entities.Tick{
+			Index:          tick,
+			LiquidityGross: uint256.NewInt(1),
+			LiquidityNet:   int256.MustFromBig(net[tick]),
+		})
+	}
+	handler := entities.NewTicksHandler()
+	if len(ticks) > 0 {
+		handler.SetTicks(ticks)
+	}
+
+	pool := entities.NewPoolV3(common.Address{}, uint16(feeAmount), tickCurrent, uint256.MustFromBig(sqrtP), nil, nil, handler)
+	pool.Liquidity = uint256.MustFromBig(active)
+	pool.FeeProtocol0, pool.FeeProtocol1 = ref.FeeProtocol0, ref.FeeProtocol1
+	pool.TickBitmapStepping = ref.TickBitmapStepping
+	require.NoError(t, ref.Validate())
+	return swapFixture{ref: ref, pool: pool}
+}
+
+func FuzzPoolSwap(f *testing.F) {
+	rng := rand.New(rand.NewSource(6))
+	for i := 0; i < seedCount; i++ {
+		f.Add(rng.Int63(), rng.Intn(2) == 0, rng.Intn(2) == 0, rng.Uint64(), uint8(rng.Intn(120)), rng.Int31n(4000)-2000)
+	}
+	f.Fuzz(func(t *testing.T, seed int64, zeroForOne, exactIn bool, amount uint64, amountBits uint8, limitDelta int32) {
+		fx := newSwapFixture(t, seed)
+		amountSpecified := new(big.Int).Lsh(new(big.Int).SetUint64(amount|1), uint(amountBits)%120)
+		if !exactIn {
+			amountSpecified.Neg(amountSpecified)
+		}
+
+		// limitDelta = 0 — без лимита, иначе цена на limitDelta тиков в сторону свапа
+		var limit *big.Int
+		if limitDelta != 0 {
+			if limitDelta < 0 {
+				limitDelta = -limitDelta
+			}
+			limitTick := fx.ref.Tick + limitDelta
+			if zeroForOne {
+				limitTick = fx.ref.Tick - limitDelta + 1
+			}
+			limitTick = max(MinTick+1, min(limitTick, MaxTick-1))
+			limit, _ = GetSqrtRatioAtTick(limitTick)
+		}
+
+		want, err := fx.ref.Swap(zeroForOne, amountSpecified, limit)
+		if err == ErrSqrtPriceLimit {
+			return
+		}
+		require.NoError(t, err)
+
+		var limitU *utils.Uint160
+		if limit != nil {
+			limitU = uint256.MustFromBig(limit)
+		}
+		res := &entities.SwapResultV2{}
+		require.NoError(t, fx.pool.Swap(zeroForOne, int256.MustFromBig(amountSpecified), limitU, res))
+
+		msg := []any{"seed=%d zeroForOne=%v amount=%s limit=%v bitmap=%v", seed, zeroForOne, amountSpecified, limit, fx.ref.TickBitmapStepping}
+		assert.Equal(t, want.AmountCalculated.String(), res.AmountCalculated.Dec(), msg...)
+		assert.Equal(t, want.RemainingAmount.String(), res.RemainingAmountIn.Dec(), msg...)
+		assert.Equal(t, want.SqrtPriceX96.String(), res.SqrtRatioX96.Dec(), msg...)
+		assert.Equal(t, want.Liquidity.String(), res.Liquidity.Dec(), msg...)
+		assert.Equal(t, want.Tick, res.CurrentTick, msg...)
+		assert.Equal(t, want.ProtocolFee.String(), res.ProtocolFee.Dec(), msg...)
+		assert.Equal(t, want.CrossInitTickLoops, res.CrossInitTickLoops, msg...)
+	})
+}
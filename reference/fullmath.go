@@ -0,0 +1,86 @@
+// Package reference — медленная, заведомо корректная реализация математики Uniswap V3 на math/big:
+// FullMath, TickMath, SqrtPriceMath, SwapMath, LiquidityMath и цикл свапа пула. Код повторяет
+// библиотеки v3-core строка в строку, без таблиц, реципрокалов и ручной работы со словами, и
+// служит оракулом для дифференциальной проверки оптимизированных путей utils и entities.Pool.
+//
+// Аргументы не изменяются, результаты всегда новые *big.Int. Переполнения 256/160/128-битных
+// типов контракта, которые там приводят к revert, здесь возвращаются ошибками.
+package reference
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrDivisionByZero = errors.New("division by zero")
+	ErrMulDivOverflow = errors.New("muldiv overflow")
+	ErrInvariant      = errors.New("invariant violation")
+	ErrUint160        = errors.New("value overflows uint160")
+)
+
+var (
+	Q32  = new(big.Int).Lsh(big.NewInt(1), 32)
+	Q96  = new(big.Int).Lsh(big.NewInt(1), 96)
+	Q128 = new(big.Int).Lsh(big.NewInt(1), 128)
+
+	MaxUint128 = new(big.Int).Sub(Q128, big.NewInt(1))
+	MaxUint160 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+	MaxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+)
+
+// MulDiv — FullMath.mulDiv: floor(a×b÷denominator), результат обязан помещаться в uint256.
+func MulDiv(a, b, denominator *big.Int) (*big.Int, error) {
+	if denominator.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	result := new(big.Int).Mul(a, b)
+	result.Quo(result, denominator)
+	if result.Cmp(MaxUint256) > 0 {
+		return nil, ErrMulDivOverflow
+	}
+	return result, nil
+}
+
+// MulDivRoundingUp — FullMath.mulDivRoundingUp: ceil(a×b÷denominator) в пределах uint256.
+func MulDivRoundingUp(a, b, denominator *big.Int) (*big.Int, error) {
+	if denominator.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	result, remainder := new(big.Int).QuoRem(new(big.Int).Mul(a, b), denominator, new(big.Int))
+	if remainder.Sign() != 0 {
+		result.Add(result, big.NewInt(1))
+	}
+	if result.Cmp(MaxUint256) > 0 {
+		return nil, ErrMulDivOverflow
+	}
+	return result, nil
+}
+
+// DivRoundingUp — UnsafeMath.divRoundingUp: ceil(x÷y).
+func DivRoundingUp(x, y *big.Int) (*big.Int, error) {
+	if y.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	result, remainder := new(big.Int).QuoRem(x, y, new(big.Int))
+	if remainder.Sign() != 0 {
+		result.Add(result, big.NewInt(1))
+	}
+	return result, nil
+}
+
+// toUint160 — SafeCast.toUint160.
+func toUint160(x *big.Int) (*big.Int, error) {
+	if x.Sign() < 0 || x.Cmp(MaxUint160) > 0 {
+		return nil, ErrUint160
+	}
+	return x, nil
+}
+
+func mustHex(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		panic("reference: bad constant " + s)
+	}
+	return v
+}
@@ -0,0 +1,69 @@
+package reference
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrLiquiditySub = errors.New("liquidity underflow")
+	ErrLiquidityAdd = errors.New("liquidity overflows uint128")
+)
+
+// AddDelta — LiquidityMath.addDelta: x + y для uint128 x и int128 y.
+func AddDelta(x, y *big.Int) (*big.Int, error) {
+	z := new(big.Int).Add(x, y)
+	if z.Sign() < 0 {
+		return nil, ErrLiquiditySub
+	}
+	if z.Cmp(MaxUint128) > 0 {
+		return nil, ErrLiquidityAdd
+	}
+	return z, nil
+}
+
+// MaxLiquidityForAmounts — maxLiquidityForAmounts SDK: наибольшая ликвидность, которую можно
+// получить из amount0 и amount1 в диапазоне [sqrtRatioAX96, sqrtRatioBX96] при текущей цене.
+// useFullPrecision=false повторяет LiquidityAmounts.getLiquidityForAmount0 периферии (деление на Q96
+// в промежуточном шаге).
+func MaxLiquidityForAmounts(sqrtRatioCurrentX96, sqrtRatioAX96, sqrtRatioBX96, amount0, amount1 *big.Int, useFullPrecision bool) *big.Int {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+
+	forAmount0 := maxLiquidityForAmount0Imprecise
+	if useFullPrecision {
+		forAmount0 = maxLiquidityForAmount0Precise
+	}
+
+	if sqrtRatioCurrentX96.Cmp(sqrtRatioAX96) <= 0 {
+		return forAmount0(sqrtRatioAX96, sqrtRatioBX96, amount0)
+	}
+	if sqrtRatioCurrentX96.Cmp(sqrtRatioBX96) < 0 {
+		liquidity0 := forAmount0(sqrtRatioCurrentX96, sqrtRatioBX96, amount0)
+		liquidity1 := maxLiquidityForAmount1(sqrtRatioAX96, sqrtRatioCurrentX96, amount1)
+		if liquidity0.Cmp(liquidity1) < 0 {
+			return liquidity0
+		}
+		return liquidity1
+	}
+	return maxLiquidityForAmount1(sqrtRatioAX96, sqrtRatioBX96, amount1)
+}
+
+func maxLiquidityForAmount0Imprecise(sqrtRatioAX96, sqrtRatioBX96, amount0 *big.Int) *big.Int {
+	intermediate := new(big.Int).Mul(sqrtRatioAX96, sqrtRatioBX96)
+	intermediate.Quo(intermediate, Q96)
+	result := new(big.Int).Mul(amount0, intermediate)
+	return result.Quo(result, new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96))
+}
+
+func maxLiquidityForAmount0Precise(sqrtRatioAX96, sqrtRatioBX96, amount0 *big.Int) *big.Int {
+	numerator := new(big.Int).Mul(amount0, sqrtRatioAX96)
+	numerator.Mul(numerator, sqrtRatioBX96)
+	denominator := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+	denominator.Mul(denominator, Q96)
+	return numerator.Quo(numerator, denominator)
+}
+
+func maxLiquidityForAmount1(sqrtRatioAX96, sqrtRatioBX96, amount1 *big.Int) *big.Int {
+	result := new(big.Int).Mul(amount1, Q96)
+	return result.Quo(result, new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96))
+}
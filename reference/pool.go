@@ -0,0 +1,217 @@
+package reference
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+var (
+	ErrZeroAmount        = errors.New("amount specified is zero")
+	ErrSqrtPriceLimit    = errors.New("invalid sqrt price limit")
+	ErrTicksNotSorted    = errors.New("ticks are not strictly increasing")
+	ErrInvalidTickBounds = errors.New("tick outside of [MinTick, MaxTick]")
+)
+
+// Tick — инициализированный тик reference-пула.
+type Tick struct {
+	Index        int32
+	LiquidityNet *big.Int
+}
+
+// Pool — состояние пула, достаточное для UniswapV3Pool.swap. Ticks — инициализированные тики
+// по возрастанию Index; Swap состояние не меняет.
+type Pool struct {
+	Fee          uint32
+	TickSpacing  int32
+	SqrtPriceX96 *big.Int
+	Liquidity    *big.Int
+	Tick         int32
+	FeeProtocol0 uint8
+	FeeProtocol1 uint8
+	Ticks        []Tick
+
+	// TickBitmapStepping — шаги по границам 256-битных слов tickBitmap, как в контракте;
+	// без него шаг идёт сразу до следующего инициализированного тика (как entities.Pool по умолчанию).
+	TickBitmapStepping bool
+}
+
+// SwapResult — итог свапа в терминах entities.SwapResultV2.
+type SwapResult struct {
+	// AmountCalculated — для exact input минус выход, для exact output — вход с комиссией.
+	AmountCalculated *big.Int
+	// RemainingAmount — неизрасходованная часть amountSpecified.
+	RemainingAmount    *big.Int
+	SqrtPriceX96       *big.Int
+	Liquidity          *big.Int
+	Tick               int32
+	ProtocolFee        *big.Int
+	CrossInitTickLoops int
+}
+
+// Validate проверяет, что тики отсортированы, уникальны и лежат в допустимом диапазоне.
+func (p *Pool) Validate() error {
+	for i, t := range p.Ticks {
+		if t.Index < MinTick || t.Index > MaxTick {
+			return ErrInvalidTickBounds
+		}
+		if i > 0 && p.Ticks[i-1].Index >= t.Index {
+			return ErrTicksNotSorted
+		}
+	}
+	return nil
+}
+
+// Swap — UniswapV3Pool.swap без колбэков и записи состояния. sqrtPriceLimitX96 = nil
+// означает MIN_SQRT_RATIO+1 / MAX_SQRT_RATIO-1 в зависимости от направления.
+func (p *Pool) Swap(zeroForOne bool, amountSpecified, sqrtPriceLimitX96 *big.Int) (*SwapResult, error) {
+	if amountSpecified.Sign() == 0 {
+		return nil, ErrZeroAmount
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if sqrtPriceLimitX96 == nil {
+		if zeroForOne {
+			sqrtPriceLimitX96 = new(big.Int).Add(MinSqrtRatio, big.NewInt(1))
+		} else {
+			sqrtPriceLimitX96 = new(big.Int).Sub(MaxSqrtRatio, big.NewInt(1))
+		}
+	}
+	if zeroForOne {
+		if sqrtPriceLimitX96.Cmp(p.SqrtPriceX96) >= 0 || sqrtPriceLimitX96.Cmp(MinSqrtRatio) <= 0 {
+			return nil, ErrSqrtPriceLimit
+		}
+	} else if sqrtPriceLimitX96.Cmp(p.SqrtPriceX96) <= 0 || sqrtPriceLimitX96.Cmp(MaxSqrtRatio) >= 0 {
+		return nil, ErrSqrtPriceLimit
+	}
+
+	feeProtocol := p.FeeProtocol1
+	if zeroForOne {
+		feeProtocol = p.FeeProtocol0
+	}
+	exactInput := amountSpecified.Sign() > 0
+
+	res := &SwapResult{
+		AmountCalculated: new(big.Int),
+		RemainingAmount:  new(big.Int).Set(amountSpecified),
+		SqrtPriceX96:     new(big.Int).Set(p.SqrtPriceX96),
+		Liquidity:        new(big.Int).Set(p.Liquidity),
+		Tick:             p.Tick,
+		ProtocolFee:      new(big.Int),
+	}
+
+	for res.RemainingAmount.Sign() != 0 && res.SqrtPriceX96.Cmp(sqrtPriceLimitX96) != 0 {
+		sqrtPriceStartX96 := res.SqrtPriceX96
+
+		tickNext, initialized := p.nextTick(res.Tick, zeroForOne)
+		if tickNext < MinTick {
+			tickNext = MinTick
+		} else if tickNext > MaxTick {
+			tickNext = MaxTick
+		}
+
+		sqrtPriceNextX96, err := GetSqrtRatioAtTick(tickNext)
+		if err != nil {
+			return nil, err
+		}
+		target := sqrtPriceNextX96
+		if (zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceLimitX96) < 0) || (!zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceLimitX96) > 0) {
+			target = sqrtPriceLimitX96
+		}
+
+		step, err := ComputeSwapStep(res.SqrtPriceX96, target, res.Liquidity, res.RemainingAmount, p.Fee)
+		if err != nil {
+			return nil, err
+		}
+		res.SqrtPriceX96 = step.SqrtRatioNextX96
+
+		amountInPlusFee := new(big.Int).Add(step.AmountIn, step.FeeAmount)
+		if exactInput {
+			res.RemainingAmount.Sub(res.RemainingAmount, amountInPlusFee)
+			res.AmountCalculated.Sub(res.AmountCalculated, step.AmountOut)
+		} else {
+			res.RemainingAmount.Add(res.RemainingAmount, step.AmountOut)
+			res.AmountCalculated.Add(res.AmountCalculated, amountInPlusFee)
+		}
+
+		if feeProtocol > 0 {
+			res.ProtocolFee.Add(res.ProtocolFee, new(big.Int).Quo(step.FeeAmount, big.NewInt(int64(feeProtocol))))
+		}
+
+		if res.SqrtPriceX96.Cmp(sqrtPriceNextX96) == 0 {
+			if initialized {
+				liquidityNet := new(big.Int).Set(p.Ticks[p.tickPosition(tickNext)].LiquidityNet)
+				if zeroForOne {
+					liquidityNet.Neg(liquidityNet)
+				}
+				if res.Liquidity, err = AddDelta(res.Liquidity, liquidityNet); err != nil {
+					return nil, err
+				}
+				res.CrossInitTickLoops++
+			}
+			if zeroForOne {
+				res.Tick = tickNext - 1
+			} else {
+				res.Tick = tickNext
+			}
+		} else if res.SqrtPriceX96.Cmp(sqrtPriceStartX96) != 0 {
+			if res.Tick, err = GetTickAtSqrtRatio(res.SqrtPriceX96); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// tickPosition — индекс первого тика с Index >= tick.
+func (p *Pool) tickPosition(tick int32) int {
+	return sort.Search(len(p.Ticks), func(i int) bool { return p.Ticks[i].Index >= tick })
+}
+
+// nextTick — следующий тик шага свапа: инициализированный тик (<= tick при zeroForOne, > tick иначе)
+// либо, в режиме TickBitmapStepping, граница слова tickBitmap.nextInitializedTickWithinOneWord.
+func (p *Pool) nextTick(tick int32, lte bool) (int32, bool) {
+	var next int32
+	var found bool
+	if lte {
+		i := p.tickPosition(tick + 1)
+		if found = i > 0; found {
+			next = p.Ticks[i-1].Index
+		} else {
+			next = MinTick
+		}
+	} else {
+		i := p.tickPosition(tick + 1)
+		if found = i < len(p.Ticks); found {
+			next = p.Ticks[i].Index
+		} else {
+			next = MaxTick
+		}
+	}
+	if !p.TickBitmapStepping {
+		return next, found
+	}
+
+	tickSpacing := p.TickSpacing
+	if tickSpacing <= 0 {
+		tickSpacing = 1
+	}
+	compressed := tick / tickSpacing
+	if tick < 0 && tick%tickSpacing != 0 {
+		compressed--
+	}
+	if lte {
+		wordStart := (compressed - compressed&0xff) * tickSpacing
+		if found && next >= wordStart {
+			return next, true
+		}
+		return wordStart, false
+	}
+	wordEnd := (compressed + 1 + 255 - (compressed+1)&0xff) * tickSpacing
+	if found && next <= wordEnd {
+		return next, true
+	}
+	return wordEnd, false
+}
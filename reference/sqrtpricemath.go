@@ -0,0 +1,134 @@
+package reference
+
+import (
+	"errors"
+	"math/big"
+)
+
+var ErrZeroLiquidityOrPrice = errors.New("sqrt price or liquidity is zero")
+
+func sortRatios(sqrtRatioAX96, sqrtRatioBX96 *big.Int) (*big.Int, *big.Int) {
+	if sqrtRatioAX96.Cmp(sqrtRatioBX96) > 0 {
+		return sqrtRatioBX96, sqrtRatioAX96
+	}
+	return sqrtRatioAX96, sqrtRatioBX96
+}
+
+// GetAmount0Delta — SqrtPriceMath.getAmount0Delta: liquidity / sqrt(lower) - liquidity / sqrt(upper).
+func GetAmount0Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) (*big.Int, error) {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+	if sqrtRatioAX96.Sign() <= 0 {
+		return nil, ErrZeroLiquidityOrPrice
+	}
+
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	numerator2 := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+
+	if roundUp {
+		q, err := MulDivRoundingUp(numerator1, numerator2, sqrtRatioBX96)
+		if err != nil {
+			return nil, err
+		}
+		return DivRoundingUp(q, sqrtRatioAX96)
+	}
+	q, err := MulDiv(numerator1, numerator2, sqrtRatioBX96)
+	if err != nil {
+		return nil, err
+	}
+	return q.Quo(q, sqrtRatioAX96), nil
+}
+
+// GetAmount1Delta — SqrtPriceMath.getAmount1Delta: liquidity * (sqrt(upper) - sqrt(lower)).
+func GetAmount1Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) (*big.Int, error) {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+	diff := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+	if roundUp {
+		return MulDivRoundingUp(liquidity, diff, Q96)
+	}
+	return MulDiv(liquidity, diff, Q96)
+}
+
+// GetNextSqrtPriceFromInput — SqrtPriceMath.getNextSqrtPriceFromInput.
+func GetNextSqrtPriceFromInput(sqrtPX96, liquidity, amountIn *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, ErrZeroLiquidityOrPrice
+	}
+	if zeroForOne {
+		return getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountIn, true)
+	}
+	return getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountIn, true)
+}
+
+// GetNextSqrtPriceFromOutput — SqrtPriceMath.getNextSqrtPriceFromOutput.
+func GetNextSqrtPriceFromOutput(sqrtPX96, liquidity, amountOut *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, ErrZeroLiquidityOrPrice
+	}
+	if zeroForOne {
+		return getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountOut, false)
+	}
+	return getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountOut, false)
+}
+
+func getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if amount.Sign() == 0 {
+		return new(big.Int).Set(sqrtPX96), nil
+	}
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	product := new(big.Int).Mul(amount, sqrtPX96)
+	// в контракте amount * sqrtPX96 считается в uint256 и проверяется на переполнение делением
+	productFits := product.Cmp(MaxUint256) <= 0
+
+	if add {
+		if productFits {
+			denominator := new(big.Int).Add(numerator1, product)
+			if denominator.Cmp(MaxUint256) <= 0 {
+				return MulDivRoundingUp(numerator1, sqrtPX96, denominator)
+			}
+		}
+		// liquidity / (liquidity / sqrtP + amount)
+		denominator := new(big.Int).Quo(numerator1, sqrtPX96)
+		denominator.Add(denominator, amount)
+		return DivRoundingUp(numerator1, denominator)
+	}
+
+	if !productFits || numerator1.Cmp(product) <= 0 {
+		return nil, ErrInvariant
+	}
+	result, err := MulDivRoundingUp(numerator1, sqrtPX96, new(big.Int).Sub(numerator1, product))
+	if err != nil {
+		return nil, err
+	}
+	return toUint160(result)
+}
+
+func getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if add {
+		var quotient *big.Int
+		if amount.Cmp(MaxUint160) <= 0 {
+			quotient = new(big.Int).Lsh(amount, 96)
+			quotient.Quo(quotient, liquidity)
+		} else {
+			var err error
+			if quotient, err = MulDiv(amount, Q96, liquidity); err != nil {
+				return nil, err
+			}
+		}
+		return toUint160(quotient.Add(quotient, sqrtPX96))
+	}
+
+	var quotient *big.Int
+	var err error
+	if amount.Cmp(MaxUint160) <= 0 {
+		quotient, err = DivRoundingUp(new(big.Int).Lsh(amount, 96), liquidity)
+	} else {
+		quotient, err = MulDivRoundingUp(amount, Q96, liquidity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sqrtPX96.Cmp(quotient) <= 0 {
+		return nil, ErrInvariant
+	}
+	return quotient.Sub(sqrtPX96, quotient), nil
+}
@@ -0,0 +1,100 @@
+package reference
+
+import "math/big"
+
+const MaxFee = 1000000
+
+// SwapStep — результат SwapMath.computeSwapStep.
+type SwapStep struct {
+	SqrtRatioNextX96 *big.Int
+	AmountIn         *big.Int
+	AmountOut        *big.Int
+	FeeAmount        *big.Int
+}
+
+// ComputeSwapStep — SwapMath.computeSwapStep. Направление определяется соотношением цен,
+// тип свапа — знаком amountRemaining (>= 0 — exact input).
+func ComputeSwapStep(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, amountRemaining *big.Int, feePips uint32) (SwapStep, error) {
+	var step SwapStep
+	var err error
+
+	zeroForOne := sqrtRatioCurrentX96.Cmp(sqrtRatioTargetX96) >= 0
+	exactIn := amountRemaining.Sign() >= 0
+	amountRemainingAbs := new(big.Int).Abs(amountRemaining)
+	fee := big.NewInt(int64(feePips))
+	maxFeeMinusFee := big.NewInt(int64(MaxFee - feePips))
+
+	if exactIn {
+		var amountRemainingLessFee *big.Int
+		if amountRemainingLessFee, err = MulDiv(amountRemaining, maxFeeMinusFee, big.NewInt(MaxFee)); err != nil {
+			return step, err
+		}
+		if zeroForOne {
+			step.AmountIn, err = GetAmount0Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, true)
+		} else {
+			step.AmountIn, err = GetAmount1Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, true)
+		}
+		if err != nil {
+			return step, err
+		}
+		if amountRemainingLessFee.Cmp(step.AmountIn) >= 0 {
+			step.SqrtRatioNextX96 = new(big.Int).Set(sqrtRatioTargetX96)
+		} else if step.SqrtRatioNextX96, err = GetNextSqrtPriceFromInput(sqrtRatioCurrentX96, liquidity, amountRemainingLessFee, zeroForOne); err != nil {
+			return step, err
+		}
+	} else {
+		if zeroForOne {
+			step.AmountOut, err = GetAmount1Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, false)
+		} else {
+			step.AmountOut, err = GetAmount0Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, false)
+		}
+		if err != nil {
+			return step, err
+		}
+		if amountRemainingAbs.Cmp(step.AmountOut) >= 0 {
+			step.SqrtRatioNextX96 = new(big.Int).Set(sqrtRatioTargetX96)
+		} else if step.SqrtRatioNextX96, err = GetNextSqrtPriceFromOutput(sqrtRatioCurrentX96, liquidity, amountRemainingAbs, zeroForOne); err != nil {
+			return step, err
+		}
+	}
+
+	max := sqrtRatioTargetX96.Cmp(step.SqrtRatioNextX96) == 0
+
+	// get the input/output amounts
+	if zeroForOne {
+		if !(max && exactIn) {
+			if step.AmountIn, err = GetAmount0Delta(step.SqrtRatioNextX96, sqrtRatioCurrentX96, liquidity, true); err != nil {
+				return step, err
+			}
+		}
+		if !(max && !exactIn) {
+			if step.AmountOut, err = GetAmount1Delta(step.SqrtRatioNextX96, sqrtRatioCurrentX96, liquidity, false); err != nil {
+				return step, err
+			}
+		}
+	} else {
+		if !(max && exactIn) {
+			if step.AmountIn, err = GetAmount1Delta(sqrtRatioCurrentX96, step.SqrtRatioNextX96, liquidity, true); err != nil {
+				return step, err
+			}
+		}
+		if !(max && !exactIn) {
+			if step.AmountOut, err = GetAmount0Delta(sqrtRatioCurrentX96, step.SqrtRatioNextX96, liquidity, false); err != nil {
+				return step, err
+			}
+		}
+	}
+
+	// cap the output amount to not exceed the remaining output amount
+	if !exactIn && step.AmountOut.Cmp(amountRemainingAbs) > 0 {
+		step.AmountOut = amountRemainingAbs
+	}
+
+	if exactIn && step.SqrtRatioNextX96.Cmp(sqrtRatioTargetX96) != 0 {
+		// we didn't reach the target, so take the remainder of the maximum input as fee
+		step.FeeAmount = new(big.Int).Sub(amountRemaining, step.AmountIn)
+	} else if step.FeeAmount, err = MulDivRoundingUp(step.AmountIn, fee, maxFeeMinusFee); err != nil {
+		return step, err
+	}
+	return step, nil
+}
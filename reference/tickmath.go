@@ -0,0 +1,91 @@
+package reference
+
+import (
+	"errors"
+	"math/big"
+)
+
+const (
+	MinTick = int32(-887272)
+	MaxTick = -MinTick
+)
+
+var (
+	ErrInvalidTick      = errors.New("invalid tick")
+	ErrInvalidSqrtRatio = errors.New("invalid sqrt ratio")
+
+	MinSqrtRatio = big.NewInt(4295128739)
+	MaxSqrtRatio = mustHex("1461446703485210103287273052203988822378723970342")
+)
+
+// множители TickMath.getSqrtRatioAtTick: sqrt(1.0001)^-(2^i) в Q128.128, i = 0..19
+var sqrtRatioFactors = [20]*big.Int{
+	mustHex("0xfffcb933bd6fad37aa2d162d1a594001"),
+	mustHex("0xfff97272373d413259a46990580e213a"),
+	mustHex("0xfff2e50f5f656932ef12357cf3c7fdcc"),
+	mustHex("0xffe5caca7e10e4e61c3624eaa0941cd0"),
+	mustHex("0xffcb9843d60f6159c9db58835c926644"),
+	mustHex("0xff973b41fa98c081472e6896dfb254c0"),
+	mustHex("0xff2ea16466c96a3843ec78b326b52861"),
+	mustHex("0xfe5dee046a99a2a811c461f1969c3053"),
+	mustHex("0xfcbe86c7900a88aedcffc83b479aa3a4"),
+	mustHex("0xf987a7253ac413176f2b074cf7815e54"),
+	mustHex("0xf3392b0822b70005940c7a398e4b70f3"),
+	mustHex("0xe7159475a2c29b7443b29c7fa6e889d9"),
+	mustHex("0xd097f3bdfd2022b8845ad8f792aa5825"),
+	mustHex("0xa9f746462d870fdf8a65dc1f90e061e5"),
+	mustHex("0x70d869a156d2a1b890bb3df62baf32f7"),
+	mustHex("0x31be135f97d08fd981231505542fcfa6"),
+	mustHex("0x9aa508b5b7a84e1c677de54f3e99bc9"),
+	mustHex("0x5d6af8dedb81196699c329225ee604"),
+	mustHex("0x2216e584f5fa1ea926041bedfe98"),
+	mustHex("0x48a170391f7dc42444e8fa2"),
+}
+
+// GetSqrtRatioAtTick — TickMath.getSqrtRatioAtTick: sqrt(1.0001^tick) в Q64.96, округление вверх.
+func GetSqrtRatioAtTick(tick int32) (*big.Int, error) {
+	if tick < MinTick || tick > MaxTick {
+		return nil, ErrInvalidTick
+	}
+	absTick := tick
+	if tick < 0 {
+		absTick = -tick
+	}
+
+	ratio := new(big.Int).Set(Q128)
+	for i, factor := range sqrtRatioFactors {
+		if absTick&(1<<i) != 0 {
+			ratio.Mul(ratio, factor)
+			ratio.Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		ratio.Quo(MaxUint256, ratio)
+	}
+
+	// Q128.128 -> Q64.96 с округлением вверх
+	return DivRoundingUp(ratio, Q32)
+}
+
+// GetTickAtSqrtRatio — TickMath.getTickAtSqrtRatio: наибольший тик, для которого
+// GetSqrtRatioAtTick(tick) <= sqrtPriceX96. Бинарный поиск по GetSqrtRatioAtTick вместо log2.
+func GetTickAtSqrtRatio(sqrtPriceX96 *big.Int) (int32, error) {
+	if sqrtPriceX96.Cmp(MinSqrtRatio) < 0 || sqrtPriceX96.Cmp(MaxSqrtRatio) >= 0 {
+		return 0, ErrInvalidSqrtRatio
+	}
+
+	lo, hi := MinTick, MaxTick-1
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		ratio, err := GetSqrtRatioAtTick(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ratio.Cmp(sqrtPriceX96) <= 0 {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,199 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+type EventType string
+
+const (
+	EventInitialize EventType = "Initialize"
+	EventSwap       EventType = "Swap"
+	EventMint       EventType = "Mint"
+	EventBurn       EventType = "Burn"
+	EventCollect    EventType = "Collect"
+	EventFlash      EventType = "Flash"
+)
+
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// Event — записанный лог пула. Поля — объединение аргументов событий UniswapV3Pool,
+// для каждого типа используются только свои:
+//
+//	Initialize: SqrtPriceX96, Tick
+//	Swap:       Amount0, Amount1 (со знаком, > 0 — вход в пул), SqrtPriceX96, Liquidity, Tick — состояние после свапа
+//	Mint, Burn: Owner, TickLower, TickUpper, Liquidity (аргумент amount события), Amount0, Amount1
+//	Collect:    Owner, TickLower, TickUpper, Amount0, Amount1 — фактически выведенные суммы
+//	Flash:      Amount0, Amount1 — заём, Paid0, Paid1 — доплата сверх заёма
+type Event struct {
+	Type           EventType
+	BlockNumber    uint64
+	BlockTimestamp uint32
+	TxHash         common.Hash
+	LogIndex       uint
+
+	Owner     common.Address
+	TickLower int32
+	TickUpper int32
+
+	Amount0      utils.Int256
+	Amount1      utils.Int256
+	Paid0        utils.Uint256
+	Paid1        utils.Uint256
+	Liquidity    utils.Uint128
+	SqrtPriceX96 utils.Uint160
+	Tick         int32
+}
+
+// jsonEvent — JSONL-представление Event: большие числа — десятичные строки, как их отдают
+// индексаторы логов; liquidity Mint/Burn принимается и как "amount" (имя аргумента события).
+type jsonEvent struct {
+	Type           EventType       `json:"type"`
+	BlockNumber    uint64          `json:"blockNumber,omitempty"`
+	BlockTimestamp uint32          `json:"blockTimestamp,omitempty"`
+	TxHash         *common.Hash    `json:"txHash,omitempty"`
+	LogIndex       uint            `json:"logIndex,omitempty"`
+	Owner          *common.Address `json:"owner,omitempty"`
+	TickLower      int32           `json:"tickLower,omitempty"`
+	TickUpper      int32           `json:"tickUpper,omitempty"`
+	Amount         string          `json:"amount,omitempty"`
+	Amount0        string          `json:"amount0,omitempty"`
+	Amount1        string          `json:"amount1,omitempty"`
+	Paid0          string          `json:"paid0,omitempty"`
+	Paid1          string          `json:"paid1,omitempty"`
+	Liquidity      string          `json:"liquidity,omitempty"`
+	SqrtPriceX96   string          `json:"sqrtPriceX96,omitempty"`
+	Tick           int32           `json:"tick,omitempty"`
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var j jsonEvent
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*e = Event{
+		Type:           j.Type,
+		BlockNumber:    j.BlockNumber,
+		BlockTimestamp: j.BlockTimestamp,
+		LogIndex:       j.LogIndex,
+		TickLower:      j.TickLower,
+		TickUpper:      j.TickUpper,
+		Tick:           j.Tick,
+	}
+	if j.TxHash != nil {
+		e.TxHash = *j.TxHash
+	}
+	if j.Owner != nil {
+		e.Owner = *j.Owner
+	}
+
+	liquidity := j.Liquidity
+	if liquidity == "" {
+		liquidity = j.Amount
+	}
+
+	if err := parseInt(j.Amount0, &e.Amount0); err != nil {
+		return fmt.Errorf("amount0: %w", err)
+	}
+	if err := parseInt(j.Amount1, &e.Amount1); err != nil {
+		return fmt.Errorf("amount1: %w", err)
+	}
+	if err := parseUint(j.Paid0, &e.Paid0); err != nil {
+		return fmt.Errorf("paid0: %w", err)
+	}
+	if err := parseUint(j.Paid1, &e.Paid1); err != nil {
+		return fmt.Errorf("paid1: %w", err)
+	}
+	if err := parseUint(liquidity, &e.Liquidity); err != nil {
+		return fmt.Errorf("liquidity: %w", err)
+	}
+	if err := parseUint(j.SqrtPriceX96, &e.SqrtPriceX96); err != nil {
+		return fmt.Errorf("sqrtPriceX96: %w", err)
+	}
+
+	switch e.Type {
+	case EventInitialize, EventSwap, EventMint, EventBurn, EventCollect, EventFlash:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownEventType, e.Type)
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	j := jsonEvent{
+		Type:           e.Type,
+		BlockNumber:    e.BlockNumber,
+		BlockTimestamp: e.BlockTimestamp,
+		LogIndex:       e.LogIndex,
+	}
+	if e.TxHash != (common.Hash{}) {
+		j.TxHash = &e.TxHash
+	}
+
+	switch e.Type {
+	case EventInitialize:
+		j.SqrtPriceX96, j.Tick = e.SqrtPriceX96.Dec(), e.Tick
+	case EventSwap:
+		j.Amount0, j.Amount1 = e.Amount0.Dec(), e.Amount1.Dec()
+		j.SqrtPriceX96, j.Liquidity, j.Tick = e.SqrtPriceX96.Dec(), e.Liquidity.Dec(), e.Tick
+	case EventMint, EventBurn:
+		j.Owner, j.TickLower, j.TickUpper = &e.Owner, e.TickLower, e.TickUpper
+		j.Amount = e.Liquidity.Dec()
+		j.Amount0, j.Amount1 = e.Amount0.Dec(), e.Amount1.Dec()
+	case EventCollect:
+		j.Owner, j.TickLower, j.TickUpper = &e.Owner, e.TickLower, e.TickUpper
+		j.Amount0, j.Amount1 = e.Amount0.Dec(), e.Amount1.Dec()
+	case EventFlash:
+		j.Amount0, j.Amount1 = e.Amount0.Dec(), e.Amount1.Dec()
+		j.Paid0, j.Paid1 = e.Paid0.Dec(), e.Paid1.Dec()
+	}
+	return json.Marshal(j)
+}
+
+// String — краткое описание события для сообщений об ошибках.
+func (e *Event) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s block=%d logIndex=%d", e.Type, e.BlockNumber, e.LogIndex)
+	if e.TxHash != (common.Hash{}) {
+		fmt.Fprintf(&b, " tx=%s", e.TxHash.Hex())
+	}
+	return b.String()
+}
+
+// parseInt разбирает десятичную строку со знаком; пустая строка — ноль.
+func parseInt(s string, result *utils.Int256) error {
+	if s == "" {
+		result.Clear()
+		return nil
+	}
+	return result.SetFromDec(s)
+}
+
+// parseUint разбирает десятичную или 0x-hex строку; пустая строка — ноль.
+func parseUint(s string, result *utils.Uint256) error {
+	if s == "" {
+		result.Clear()
+		return nil
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return result.SetFromHex(s)
+	}
+	return result.SetFromDecimal(s)
+}
+
+// amountToUint — неотрицательные Amount0/Amount1 события как беззнаковые.
+func amountToUint(amount *utils.Int256, result *utils.Uint256) error {
+	if amount.Sign() < 0 {
+		return fmt.Errorf("negative amount %s", amount.Dec())
+	}
+	*result = uint256.Int(*amount)
+	return nil
+}
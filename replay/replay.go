@@ -0,0 +1,291 @@
+// Package replay прогоняет записанные логи пула (Swap/Mint/Burn/Collect/Flash) через
+// entities.Pool и сверяет состояние симуляции с состоянием, зафиксированным в событиях.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/entities"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+// DivergenceError — первое расхождение симуляции с событием. Содержит событие, его порядковый
+// номер в потоке, поле с ожидаемым и фактическим значениями и состояние пула до события.
+type DivergenceError struct {
+	Index    int
+	Event    Event
+	Field    string
+	Expected string
+	Actual   string
+
+	SqrtPriceX96Before utils.Uint160
+	LiquidityBefore    utils.Uint128
+	TickBefore         int32
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("replay: divergence at event #%d (%s): %s expected %s, got %s (pool before: sqrtPriceX96=%s tick=%d liquidity=%s)",
+		e.Index, e.Event.String(), e.Field, e.Expected, e.Actual,
+		e.SqrtPriceX96Before.Dec(), e.TickBefore, e.LiquidityBefore.Dec())
+}
+
+// Replayer применяет события к Pool по порядку. Pool должен быть создан NewPoolV3
+// (нужны калькуляторы свапа) и иметь TickDataProvider.
+type Replayer struct {
+	Pool *entities.Pool
+
+	// LimitToRecordedPrice ограничивает свап записанной ценой (sqrtPriceLimitX96 = SqrtPriceX96
+	// события). Логи не различают exactInput и exactOutput: exactOutput-свап, повторённый как
+	// exactInput на ту же сумму, может перескочить цену на единицы wei. С лимитом перескок
+	// невозможен, но недолёт по-прежнему ловится.
+	LimitToRecordedPrice bool
+
+	// ValidateTicks после каждого Mint/Burn проверяет инварианты тиков (TicksHandler.Validate):
+	// испорченный поток событий падает на первом событии, а не расходится позже. O(N) на событие.
+	ValidateTicks bool
+
+	// Applied — число успешно применённых событий.
+	Applied int
+
+	// CheckpointEvery > 0 включает чекпоинты: перед первым событием каждого окна из
+	// CheckpointEvery блоков состояние пула (Pool.MarshalBinary) передаётся в OnCheckpoint
+	// вместе с номером блока события. Бэктест может начать с любого чекпоинта, восстановив
+	// пул через Pool.UnmarshalBinary и пропустив события блоков раньше block.
+	CheckpointEvery uint64
+	OnCheckpoint    func(block uint64, snapshot []byte) error
+
+	swapResult       entities.SwapResultV2
+	checkpointWindow uint64
+	checkpointed     bool
+}
+
+func NewReplayer(pool *entities.Pool) *Replayer {
+	return &Replayer{Pool: pool}
+}
+
+// Replay применяет все события из events; останавливается на первой ошибке разбора,
+// ошибке пула или расхождении (*DivergenceError).
+func (r *Replayer) Replay(events iter.Seq2[*Event, error]) error {
+	for ev, err := range events {
+		if err != nil {
+			return err
+		}
+		if err := r.Apply(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply применяет одно событие. BlockTimestamp события становится временем блока пула
+// (для оракула). Swap сверяется по цене, тику и ликвидности, Mint/Burn/Collect — по суммам токенов.
+func (r *Replayer) Apply(ev *Event) error {
+	if err := r.checkpoint(ev); err != nil {
+		return err
+	}
+
+	p := r.Pool
+	p.BlockTimestamp = ev.BlockTimestamp
+
+	d := DivergenceError{
+		Index:              r.Applied,
+		Event:              *ev,
+		SqrtPriceX96Before: *p.SqrtRatioX96,
+		LiquidityBefore:    *p.Liquidity,
+		TickBefore:         p.TickCurrent,
+	}
+
+	var err error
+	switch ev.Type {
+	case EventInitialize:
+		p.SqrtRatioX96.Set(&ev.SqrtPriceX96)
+		p.TickCurrent = ev.Tick
+		if p.ObservationCardinality == 0 {
+			err = p.InitializeOracle(ev.BlockTimestamp)
+		}
+	case EventSwap:
+		err = r.applySwap(ev, &d)
+	case EventMint, EventBurn:
+		err = r.applyModifyPosition(ev, &d)
+	case EventCollect:
+		err = r.applyCollect(ev, &d)
+	case EventFlash:
+		var amount0, amount1 utils.Uint256
+		if err = amountToUint(&ev.Amount0, &amount0); err == nil {
+			if err = amountToUint(&ev.Amount1, &amount1); err == nil {
+				err = p.Flash(&amount0, &amount1, &ev.Paid0, &ev.Paid1)
+			}
+		}
+	default:
+		err = fmt.Errorf("%w: %q", ErrUnknownEventType, ev.Type)
+	}
+	if err != nil {
+		if _, ok := err.(*DivergenceError); ok {
+			return err
+		}
+		return fmt.Errorf("replay: event #%d (%s): %w", r.Applied, ev.String(), err)
+	}
+
+	r.Applied++
+	return nil
+}
+
+func (r *Replayer) checkpoint(ev *Event) error {
+	if r.CheckpointEvery == 0 || r.OnCheckpoint == nil {
+		return nil
+	}
+	window := ev.BlockNumber / r.CheckpointEvery
+	if r.checkpointed && window == r.checkpointWindow {
+		return nil
+	}
+
+	snapshot, err := r.Pool.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("replay: checkpoint at block %d: %w", ev.BlockNumber, err)
+	}
+	if err := r.OnCheckpoint(ev.BlockNumber, snapshot); err != nil {
+		return fmt.Errorf("replay: checkpoint at block %d: %w", ev.BlockNumber, err)
+	}
+	r.checkpointWindow, r.checkpointed = window, true
+	return nil
+}
+
+// nominalSwapAmount — exact input для Swap-события с нулевыми суммами и сдвинутой ценой.
+var nominalSwapAmount = int256.NewInt(1)
+
+func (r *Replayer) applySwap(ev *Event, d *DivergenceError) error {
+	var zeroForOne bool
+	var amountSpecified *utils.Int256
+	var limit *utils.Uint160
+	if r.LimitToRecordedPrice {
+		limit = &ev.SqrtPriceX96
+	}
+	switch {
+	case ev.Amount0.Sign() > 0:
+		zeroForOne, amountSpecified = true, &ev.Amount0
+	case ev.Amount1.Sign() > 0:
+		zeroForOne, amountSpecified = false, &ev.Amount1
+	case ev.SqrtPriceX96.Eq(r.Pool.SqrtRatioX96):
+		// пустой свап, цена не изменилась — сверяем как есть
+		return r.checkState(ev, d)
+	default:
+		// обе суммы нулевые, но цена сдвинулась: свап прошёл по участку без ликвидности.
+		// Повторяем его до записанной цены с номинальной суммой — без ликвидности она не тратится.
+		zeroForOne = ev.SqrtPriceX96.Lt(r.Pool.SqrtRatioX96)
+		amountSpecified, limit = nominalSwapAmount, &ev.SqrtPriceX96
+	}
+
+	if err := r.Pool.SwapCommit(zeroForOne, amountSpecified, limit, &r.swapResult, nil); err != nil {
+		return err
+	}
+	return r.checkState(ev, d)
+}
+
+func (r *Replayer) checkState(ev *Event, d *DivergenceError) error {
+	p := r.Pool
+	switch {
+	case !p.SqrtRatioX96.Eq(&ev.SqrtPriceX96):
+		return d.with("sqrtPriceX96", ev.SqrtPriceX96.Dec(), p.SqrtRatioX96.Dec())
+	case p.TickCurrent != ev.Tick:
+		return d.with("tick", fmt.Sprint(ev.Tick), fmt.Sprint(p.TickCurrent))
+	case !p.Liquidity.Eq(&ev.Liquidity):
+		return d.with("liquidity", ev.Liquidity.Dec(), p.Liquidity.Dec())
+	}
+	return nil
+}
+
+func (r *Replayer) applyModifyPosition(ev *Event, d *DivergenceError) error {
+	var amount0, amount1 utils.Uint256
+	var err error
+	if ev.Type == EventMint {
+		err = r.Pool.Mint(ev.Owner, ev.TickLower, ev.TickUpper, &ev.Liquidity, &amount0, &amount1)
+	} else {
+		err = r.Pool.Burn(ev.Owner, ev.TickLower, ev.TickUpper, &ev.Liquidity, &amount0, &amount1)
+	}
+	if err != nil {
+		return err
+	}
+	if r.ValidateTicks {
+		if v, ok := r.Pool.TickDataProvider.(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return fmt.Errorf("ticks: %w", err)
+			}
+		}
+	}
+	return checkAmounts(ev, d, &amount0, &amount1)
+}
+
+func (r *Replayer) applyCollect(ev *Event, d *DivergenceError) error {
+	var requested0, requested1, amount0, amount1 utils.Uint128
+	if err := amountToUint(&ev.Amount0, &requested0); err != nil {
+		return err
+	}
+	if err := amountToUint(&ev.Amount1, &requested1); err != nil {
+		return err
+	}
+	// в логе только выведенные суммы; запрос на них же проверяет, что долг позиции не меньше
+	r.Pool.Collect(ev.Owner, ev.TickLower, ev.TickUpper, &requested0, &requested1, &amount0, &amount1)
+	return checkAmounts(ev, d, &amount0, &amount1)
+}
+
+func checkAmounts(ev *Event, d *DivergenceError, amount0, amount1 *utils.Uint256) error {
+	if !(*utils.Uint256)(&ev.Amount0).Eq(amount0) {
+		return d.with("amount0", ev.Amount0.Dec(), amount0.Dec())
+	}
+	if !(*utils.Uint256)(&ev.Amount1).Eq(amount1) {
+		return d.with("amount1", ev.Amount1.Dec(), amount1.Dec())
+	}
+	return nil
+}
+
+func (d *DivergenceError) with(field, expected, actual string) *DivergenceError {
+	d.Field, d.Expected, d.Actual = field, expected, actual
+	return d
+}
+
+// ReadJSONL читает события из JSONL (одно событие на строку, пустые строки пропускаются).
+// Ошибка разбора отдаётся вместе с номером строки и завершает последовательность.
+func ReadJSONL(reader io.Reader) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+		line := 0
+		for scanner.Scan() {
+			line++
+			data := scanner.Bytes()
+			if len(data) == 0 {
+				continue
+			}
+
+			ev := new(Event)
+			if err := json.Unmarshal(data, ev); err != nil {
+				yield(nil, fmt.Errorf("replay: line %d: %w", line, err))
+				return
+			}
+			if !yield(ev, nil) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(nil, fmt.Errorf("replay: line %d: %w", line+1, err))
+		}
+	}
+}
+
+// Events превращает срез событий в последовательность для Replay.
+func Events(events []Event) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		for i := range events {
+			if !yield(&events[i], nil) {
+				return
+			}
+		}
+	}
+}
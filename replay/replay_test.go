@@ -0,0 +1,260 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/daoleno/uniswap-sdk-core/entities"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/constants"
+	v3 "github.com/bobinmad/uniswapv3-sdk-uint256/entities"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var (
+	USDC  = entities.NewToken(1, common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), 6, "USDC", "USD Coin")
+	DAI   = entities.NewToken(1, common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"), 18, "DAI", "Dai Stablecoin")
+	owner = common.HexToAddress("0x00000000000000000000000000000000000a11ce")
+)
+
+func newPool() *v3.Pool {
+	var sqrtP utils.Uint160
+	utils.NewTickCalculator().GetSqrtRatioAtTickV2(0, &sqrtP)
+	return v3.NewPoolV3(common.Address{}, uint16(constants.FeeLow), 0, &sqrtP, USDC, DAI, v3.NewTicksHandler())
+}
+
+// recordEvents играет роль блокчейна: выполняет операции на эталонном пуле и записывает
+// события с тем состоянием, которое эмитировал бы контракт.
+func recordEvents(t *testing.T) []Event {
+	chain := newPool()
+	var events []Event
+	block := uint64(100)
+
+	mint := func(typ EventType, lower, upper int32, liquidity uint64) {
+		ev := Event{Type: typ, BlockNumber: block, BlockTimestamp: uint32(block * 12), Owner: owner, TickLower: lower, TickUpper: upper}
+		ev.Liquidity.SetUint64(liquidity)
+		var amount0, amount1 utils.Uint256
+		var err error
+		if typ == EventMint {
+			err = chain.Mint(owner, lower, upper, &ev.Liquidity, &amount0, &amount1)
+		} else {
+			err = chain.Burn(owner, lower, upper, &ev.Liquidity, &amount0, &amount1)
+		}
+		assert.NoError(t, err)
+		ev.Amount0, ev.Amount1 = utils.Int256(amount0), utils.Int256(amount1)
+		events = append(events, ev)
+		block++
+	}
+	swap := func(zeroForOne bool, amountSpecified int64) {
+		sr := &v3.SwapResultV2{}
+		assert.NoError(t, chain.SwapCommit(zeroForOne, int256.NewInt(amountSpecified), nil, sr, nil))
+		// exact input: знак amountSpecified совпадает с входной стороной
+		ev := Event{Type: EventSwap, BlockNumber: block, BlockTimestamp: uint32(block * 12), Tick: chain.TickCurrent}
+		in := new(int256.Int).Sub(int256.NewInt(amountSpecified), sr.RemainingAmountIn)
+		if zeroForOne {
+			ev.Amount0, ev.Amount1 = *in, *sr.AmountCalculated
+		} else {
+			ev.Amount0, ev.Amount1 = *sr.AmountCalculated, *in
+		}
+		ev.SqrtPriceX96.Set(chain.SqrtRatioX96)
+		ev.Liquidity.Set(chain.Liquidity)
+		events = append(events, ev)
+		block++
+	}
+
+	mint(EventMint, -600, 600, 1e18)
+	mint(EventMint, -60, 120, 5e17)
+	swap(true, 1e16)
+	swap(false, 3e16)
+	swap(true, 5e16)
+	mint(EventBurn, -60, 120, 5e17)
+
+	// collect всего долга позиции
+	position := chain.GetPosition(owner, -60, 120)
+	ev := Event{Type: EventCollect, BlockNumber: block, Owner: owner, TickLower: -60, TickUpper: 120}
+	ev.Amount0, ev.Amount1 = utils.Int256(position.TokensOwed0), utils.Int256(position.TokensOwed1)
+	events = append(events, ev)
+	return events
+}
+
+func TestReplay_MatchesRecordedEvents(t *testing.T) {
+	events := recordEvents(t)
+
+	r := NewReplayer(newPool())
+	assert.NoError(t, r.Replay(Events(events)))
+	assert.Equal(t, len(events), r.Applied)
+	assert.Nil(t, r.Pool.GetPosition(owner, -60, 120), "после collect позиция пуста")
+}
+
+func TestReplay_JSONLRoundTrip(t *testing.T) {
+	events := recordEvents(t)
+
+	var buf bytes.Buffer
+	for _, ev := range events {
+		line, err := json.Marshal(ev)
+		assert.NoError(t, err)
+		buf.Write(line)
+		buf.WriteString("\n\n")
+	}
+
+	r := NewReplayer(newPool())
+	assert.NoError(t, r.Replay(ReadJSONL(&buf)))
+	assert.Equal(t, len(events), r.Applied)
+}
+
+// TestReplay_ReportsFirstDivergence: искажённый тик второго свапа останавливает replay именно на нём.
+func TestReplay_ReportsFirstDivergence(t *testing.T) {
+	events := recordEvents(t)
+	events[3].Tick++
+
+	r := NewReplayer(newPool())
+	err := r.Replay(Events(events))
+
+	var divergence *DivergenceError
+	assert.True(t, errors.As(err, &divergence))
+	assert.Equal(t, 3, divergence.Index)
+	assert.Equal(t, "tick", divergence.Field)
+	assert.Equal(t, EventSwap, divergence.Event.Type)
+	assert.Equal(t, events[2].Tick, divergence.TickBefore, "контекст — состояние пула до события")
+	assert.Contains(t, err.Error(), "event #3")
+	assert.Equal(t, 3, r.Applied)
+}
+
+func TestReplay_MintAmountDivergence(t *testing.T) {
+	events := recordEvents(t)
+	events[0].Amount1.Add(&events[0].Amount1, int256.NewInt(1))
+
+	err := NewReplayer(newPool()).Replay(Events(events))
+	var divergence *DivergenceError
+	assert.True(t, errors.As(err, &divergence))
+	assert.Equal(t, "amount1", divergence.Field)
+}
+
+// TestReplay_ZeroLiquiditySwap: в пустом пуле свап двигает цену с amount0 = amount1 = 0 —
+// replay доводит цену до записанной в обе стороны, а не сообщает расхождение.
+func TestReplay_ZeroLiquiditySwap(t *testing.T) {
+	chain := newPool()
+	var events []Event
+	for _, tick := range []int32{-150, 75, 75} {
+		var limit utils.Uint160
+		chain.TickCalculator.GetSqrtRatioAtTickV2(tick, &limit)
+		if !limit.Eq(chain.SqrtRatioX96) {
+			sr := &v3.SwapResultV2{}
+			assert.NoError(t, chain.SwapCommit(limit.Lt(chain.SqrtRatioX96), int256.NewInt(1e18), &limit, sr, nil))
+			assert.True(t, sr.AmountCalculated.IsZero())
+		}
+		ev := Event{Type: EventSwap, Tick: chain.TickCurrent}
+		ev.SqrtPriceX96.Set(chain.SqrtRatioX96)
+		events = append(events, ev)
+	}
+	assert.Equal(t, int32(75), chain.TickCurrent)
+
+	r := NewReplayer(newPool())
+	assert.NoError(t, r.Replay(Events(events)))
+	assert.Equal(t, len(events), r.Applied)
+	assert.True(t, r.Pool.SqrtRatioX96.Eq(chain.SqrtRatioX96))
+	assert.Equal(t, int32(75), r.Pool.TickCurrent)
+}
+
+// TestReplay_ValidateTicks: испорченные стартовые тики ловятся на первом же Mint.
+func TestReplay_ValidateTicks(t *testing.T) {
+	events := recordEvents(t)
+
+	r := NewReplayer(newPool())
+	r.ValidateTicks = true
+	assert.NoError(t, r.Replay(Events(events)))
+
+	pool := newPool()
+	handler := pool.TickDataProvider.(*v3.TicksHandler)
+	handler.SetTicks([]v3.Tick{{Index: -600, LiquidityGross: uint256.NewInt(10), LiquidityNet: int256.NewInt(10)}})
+	r = NewReplayer(pool)
+	r.ValidateTicks = true
+	err := r.Replay(Events(events))
+	assert.ErrorIs(t, err, v3.ErrZeroNet)
+	assert.Contains(t, err.Error(), "event #0")
+	assert.Equal(t, 0, r.Applied)
+}
+
+func TestReadJSONL(t *testing.T) {
+	input := `{"type":"Mint","blockNumber":1,"owner":"0x00000000000000000000000000000000000a11ce","tickLower":-10,"tickUpper":10,"amount":"1000","amount0":"5","amount1":"6"}
+{"type":"Swap","amount0":"-100","amount1":"16","sqrtPriceX96":"0x1000000000000000000000000","liquidity":"1000","tick":-3}
+{"type":"Sync"}
+`
+	var got []*Event
+	var lastErr error
+	for ev, err := range ReadJSONL(strings.NewReader(input)) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		got = append(got, ev)
+	}
+
+	assert.Len(t, got, 2)
+	assert.Equal(t, EventMint, got[0].Type)
+	assert.Equal(t, owner, got[0].Owner)
+	assert.Equal(t, uint64(1000), got[0].Liquidity.Uint64(), "amount Mint — это ликвидность")
+	assert.Equal(t, int64(-100), got[1].Amount0.Int64())
+	assert.ErrorIs(t, lastErr, ErrUnknownEventType)
+	assert.Contains(t, lastErr.Error(), "line 3")
+
+	// суммы со знаком — только десятичные
+	_, err := parseLine(`{"type":"Swap","amount1":"0x10"}`)
+	assert.Error(t, err)
+
+	var sqrtPrice uint256.Int
+	assert.NoError(t, parseUint("0x1000000000000000000000000", &sqrtPrice))
+	assert.Equal(t, "79228162514264337593543950336", sqrtPrice.Dec())
+}
+
+func parseLine(line string) (*Event, error) {
+	ev := new(Event)
+	return ev, json.Unmarshal([]byte(line), ev)
+}
+
+// TestReplay_Checkpoints: replay с середины истории из чекпоинта приходит к тому же состоянию.
+func TestReplay_Checkpoints(t *testing.T) {
+	events := recordEvents(t)
+
+	type checkpoint struct {
+		block    uint64
+		snapshot []byte
+	}
+	var checkpoints []checkpoint
+	full := NewReplayer(newPool())
+	full.CheckpointEvery = 3
+	full.OnCheckpoint = func(block uint64, snapshot []byte) error {
+		checkpoints = append(checkpoints, checkpoint{block, snapshot})
+		return nil
+	}
+	assert.NoError(t, full.Replay(Events(events)))
+
+	// блоки 100..106, окна по 3 блока: 99-101, 102-104, 105-107
+	assert.Len(t, checkpoints, 3)
+	assert.Equal(t, uint64(102), checkpoints[1].block)
+
+	resumed := new(v3.Pool)
+	assert.NoError(t, resumed.UnmarshalBinary(checkpoints[1].snapshot))
+	var tail []Event
+	for _, ev := range events {
+		if ev.BlockNumber >= checkpoints[1].block {
+			tail = append(tail, ev)
+		}
+	}
+	assert.NoError(t, NewReplayer(resumed).Replay(Events(tail)))
+	assert.Equal(t, full.Pool.Snapshot(), resumed.Snapshot())
+
+	failing := NewReplayer(newPool())
+	failing.CheckpointEvery = 1
+	failing.OnCheckpoint = func(uint64, []byte) error { return errors.New("disk full") }
+	err := failing.Replay(Events(events))
+	assert.ErrorContains(t, err, "checkpoint at block 100")
+	assert.Equal(t, 0, failing.Applied)
+}
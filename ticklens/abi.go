@@ -0,0 +1,143 @@
+// Package ticklens загружает тики пула в entities.TicksHandler из результатов вызовов
+// TickLens.getPopulatedTicksInWord либо сырых UniswapV3Pool.tickBitmap/ticks, а также
+// кодирует calldata этих вызовов для нужных слов битмапа.
+package ticklens
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/entities"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var ErrValueOverflow = errors.New("abi value overflows its type")
+
+// фрагменты ABI TickLens и UniswapV3Pool (periphery/contracts/lens/TickLens.sol, examples/contract/uniswapv3_pool.abi)
+const lensABIJSON = `[
+	{"name":"getPopulatedTicksInWord","type":"function","stateMutability":"view",
+	 "inputs":[{"name":"pool","type":"address"},{"name":"tickBitmapIndex","type":"int16"}],
+	 "outputs":[{"name":"populatedTicks","type":"tuple[]","components":[
+		{"name":"tick","type":"int24"},{"name":"liquidityNet","type":"int128"},{"name":"liquidityGross","type":"uint128"}]}]},
+	{"name":"tickBitmap","type":"function","stateMutability":"view",
+	 "inputs":[{"name":"","type":"int16"}],
+	 "outputs":[{"name":"","type":"uint256"}]},
+	{"name":"ticks","type":"function","stateMutability":"view",
+	 "inputs":[{"name":"","type":"int24"}],
+	 "outputs":[{"name":"liquidityGross","type":"uint128"},{"name":"liquidityNet","type":"int128"},
+		{"name":"feeGrowthOutside0X128","type":"uint256"},{"name":"feeGrowthOutside1X128","type":"uint256"},
+		{"name":"tickCumulativeOutside","type":"int56"},{"name":"secondsPerLiquidityOutsideX128","type":"uint160"},
+		{"name":"secondsOutside","type":"uint32"},{"name":"initialized","type":"bool"}]}
+]`
+
+var lensABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(lensABIJSON))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// PopulatedTick — элемент результата getPopulatedTicksInWord (ITickLens.PopulatedTick).
+type PopulatedTick struct {
+	Tick           int32
+	LiquidityNet   utils.Int128
+	LiquidityGross utils.Uint128
+}
+
+// populatedTickABI — форма, в которую go-ethereum распаковывает tuple PopulatedTick.
+type populatedTickABI struct {
+	Tick           *big.Int
+	LiquidityNet   *big.Int
+	LiquidityGross *big.Int
+}
+
+// PackGetPopulatedTicksInWord — calldata TickLens.getPopulatedTicksInWord(pool, word).
+func PackGetPopulatedTicksInWord(pool common.Address, word int16) ([]byte, error) {
+	return lensABI.Pack("getPopulatedTicksInWord", pool, word)
+}
+
+// UnpackPopulatedTicks разбирает результат getPopulatedTicksInWord.
+func UnpackPopulatedTicks(data []byte) ([]PopulatedTick, error) {
+	values, err := lensABI.Unpack("getPopulatedTicksInWord", data)
+	if err != nil {
+		return nil, err
+	}
+	raw := *abi.ConvertType(values[0], new([]populatedTickABI)).(*[]populatedTickABI)
+
+	ticks := make([]PopulatedTick, len(raw))
+	for i := range raw {
+		ticks[i].Tick = int32(raw[i].Tick.Int64())
+		if err := setInt(raw[i].LiquidityNet, &ticks[i].LiquidityNet); err != nil {
+			return nil, fmt.Errorf("tick %d: liquidityNet: %w", ticks[i].Tick, err)
+		}
+		if err := setUint(raw[i].LiquidityGross, &ticks[i].LiquidityGross); err != nil {
+			return nil, fmt.Errorf("tick %d: liquidityGross: %w", ticks[i].Tick, err)
+		}
+	}
+	return ticks, nil
+}
+
+// PackTickBitmap — calldata UniswapV3Pool.tickBitmap(word).
+func PackTickBitmap(word int16) ([]byte, error) {
+	return lensABI.Pack("tickBitmap", word)
+}
+
+// UnpackTickBitmap разбирает результат tickBitmap в result.
+func UnpackTickBitmap(data []byte, result *utils.Uint256) error {
+	values, err := lensABI.Unpack("tickBitmap", data)
+	if err != nil {
+		return err
+	}
+	return setUint(values[0].(*big.Int), result)
+}
+
+// PackTicks — calldata UniswapV3Pool.ticks(tick).
+func PackTicks(tick int32) ([]byte, error) {
+	return lensABI.Pack("ticks", big.NewInt(int64(tick)))
+}
+
+// UnpackTicks разбирает результат ticks(tick) в result. Возвращает false для неинициализированного тика;
+// tickCumulativeOutside и прочие поля оракула симуляции не нужны и отбрасываются.
+func UnpackTicks(tick int32, data []byte, result *entities.Tick) (bool, error) {
+	values, err := lensABI.Unpack("ticks", data)
+	if err != nil {
+		return false, err
+	}
+
+	*result = entities.Tick{Index: tick, LiquidityGross: new(uint256.Int), LiquidityNet: new(int256.Int)}
+	if err := setUint(values[0].(*big.Int), result.LiquidityGross); err != nil {
+		return false, fmt.Errorf("tick %d: liquidityGross: %w", tick, err)
+	}
+	if err := setInt(values[1].(*big.Int), result.LiquidityNet); err != nil {
+		return false, fmt.Errorf("tick %d: liquidityNet: %w", tick, err)
+	}
+	if err := setUint(values[2].(*big.Int), &result.FeeGrowthOutside0X128); err != nil {
+		return false, fmt.Errorf("tick %d: feeGrowthOutside0X128: %w", tick, err)
+	}
+	if err := setUint(values[3].(*big.Int), &result.FeeGrowthOutside1X128); err != nil {
+		return false, fmt.Errorf("tick %d: feeGrowthOutside1X128: %w", tick, err)
+	}
+	return values[7].(bool), nil
+}
+
+func setUint(x *big.Int, result *utils.Uint256) error {
+	if result.SetFromBig(x) {
+		return ErrValueOverflow
+	}
+	return nil
+}
+
+func setInt(x *big.Int, result *utils.Int256) error {
+	if result.SetFromBig(x) {
+		return ErrValueOverflow
+	}
+	return nil
+}
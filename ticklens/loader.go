@@ -0,0 +1,204 @@
+package ticklens
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/vuquang23/int256"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/entities"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+var (
+	ErrDuplicateTick     = errors.New("duplicate tick")
+	ErrResultsCount      = errors.New("number of results does not match number of requests")
+	ErrTickOutsideWord   = errors.New("tick does not belong to requested bitmap word")
+	ErrUninitializedTick = errors.New("tick has zero liquidityGross")
+)
+
+// WordPosition — индекс слова tickBitmap, в котором лежит tick (TickBitmap.position).
+func WordPosition(tick, tickSpacing int32) int16 {
+	compressed := tick / tickSpacing
+	if tick < 0 && tick%tickSpacing != 0 {
+		compressed-- // round towards negative infinity
+	}
+	return int16(compressed >> 8)
+}
+
+// WordRange — первое и последнее слова битмапа, в которых могут быть тики при данном tickSpacing.
+func WordRange(tickSpacing int32) (int16, int16) {
+	return WordPosition(utils.MinTick, tickSpacing), WordPosition(utils.MaxTick, tickSpacing)
+}
+
+// WordsAround — слова битмапа от слова текущего тика на radius в обе стороны, обрезанные WordRange.
+// radius < 0 — все слова пула.
+func WordsAround(tick, tickSpacing int32, radius int) []int16 {
+	minWord, maxWord := WordRange(tickSpacing)
+	from, to := int(minWord), int(maxWord)
+	if radius >= 0 {
+		current := int(WordPosition(tick, tickSpacing))
+		from, to = max(from, current-radius), min(to, current+radius)
+	}
+
+	words := make([]int16, 0, to-from+1)
+	for w := from; w <= to; w++ {
+		words = append(words, int16(w))
+	}
+	return words
+}
+
+// TicksInWord — инициализированные тики слова word по его битмапу, по возрастанию.
+func TicksInWord(word int16, bitmap *utils.Uint256, tickSpacing int32) []int32 {
+	var ticks []int32
+	for limb := range 4 {
+		for v := bitmap[limb]; v != 0; v &= v - 1 {
+			bit := int32(limb*64 + bits.TrailingZeros64(v))
+			ticks = append(ticks, (int32(word)<<8+bit)*tickSpacing)
+		}
+	}
+	return ticks
+}
+
+// Loader собирает TicksHandler из результатов TickLens или tickBitmap/ticks пула.
+type Loader struct {
+	TickSpacing int32
+
+	// Partial — загружены не все слова битмапа (например, WordsAround с ограниченным radius):
+	// сумма LiquidityNet загруженных тиков не обязана быть нулевой, остальные проверки остаются.
+	Partial bool
+}
+
+func NewLoader(tickSpacing int32) *Loader {
+	return &Loader{TickSpacing: tickSpacing}
+}
+
+// PopulatedTicks собирает хендлер из ABI-результатов getPopulatedTicksInWord для слов words
+// (results[i] — ответ на запрос слова words[i]).
+func (l *Loader) PopulatedTicks(words []int16, results [][]byte) (*entities.TicksHandler, error) {
+	if len(words) != len(results) {
+		return nil, ErrResultsCount
+	}
+
+	var ticks []entities.Tick
+	for i, data := range results {
+		populated, err := UnpackPopulatedTicks(data)
+		if err != nil {
+			return nil, fmt.Errorf("word %d: %w", words[i], err)
+		}
+		for j := range populated {
+			pt := &populated[j]
+			if WordPosition(pt.Tick, l.TickSpacing) != words[i] {
+				return nil, fmt.Errorf("word %d: tick %d: %w", words[i], pt.Tick, ErrTickOutsideWord)
+			}
+			ticks = append(ticks, entities.Tick{
+				Index:          pt.Tick,
+				LiquidityGross: new(uint256.Int).Set(&pt.LiquidityGross),
+				LiquidityNet:   new(int256.Int).Set(&pt.LiquidityNet),
+			})
+		}
+	}
+	return l.build(ticks)
+}
+
+// TicksInBitmaps разбирает результаты tickBitmap для слов words и возвращает тики, которые нужно
+// запросить через ticks() (см. PackTicks), по возрастанию.
+func (l *Loader) TicksInBitmaps(words []int16, results [][]byte) ([]int32, error) {
+	if len(words) != len(results) {
+		return nil, ErrResultsCount
+	}
+
+	var ticks []int32
+	var bitmap utils.Uint256
+	for i, data := range results {
+		if err := UnpackTickBitmap(data, &bitmap); err != nil {
+			return nil, fmt.Errorf("word %d: %w", words[i], err)
+		}
+		ticks = append(ticks, TicksInWord(words[i], &bitmap, l.TickSpacing)...)
+	}
+	slices.Sort(ticks)
+	return ticks, nil
+}
+
+// Ticks собирает хендлер из ABI-результатов ticks(tick) для тиков ticks. В отличие от TickLens,
+// сохраняются и feeGrowthOutside; неинициализированные тики пропускаются.
+func (l *Loader) Ticks(ticks []int32, results [][]byte) (*entities.TicksHandler, error) {
+	if len(ticks) != len(results) {
+		return nil, ErrResultsCount
+	}
+
+	loaded := make([]entities.Tick, 0, len(ticks))
+	for i, data := range results {
+		var tick entities.Tick
+		initialized, err := UnpackTicks(ticks[i], data, &tick)
+		if err != nil {
+			return nil, err
+		}
+		if initialized {
+			loaded = append(loaded, tick)
+		}
+	}
+	return l.build(loaded)
+}
+
+// build сортирует тики и проверяет их так же, как ValidateList: кратность tickSpacing,
+// нулевую сумму LiquidityNet (кроме Partial), плюс отсутствие дублей и пустых тиков.
+func (l *Loader) build(ticks []entities.Tick) (*entities.TicksHandler, error) {
+	slices.SortFunc(ticks, func(a, b entities.Tick) int {
+		return int(a.Index) - int(b.Index)
+	})
+	for i := range ticks {
+		if ticks[i].LiquidityGross.IsZero() {
+			return nil, fmt.Errorf("tick %d: %w", ticks[i].Index, ErrUninitializedTick)
+		}
+		if i > 0 && ticks[i-1].Index == ticks[i].Index {
+			return nil, fmt.Errorf("tick %d: %w", ticks[i].Index, ErrDuplicateTick)
+		}
+	}
+
+	handler := entities.NewTicksHandler()
+	if len(ticks) == 0 {
+		return handler, nil
+	}
+
+	if err := entities.ValidateList(ticks, int(l.TickSpacing)); err != nil {
+		if !l.Partial || !errors.Is(err, entities.ErrZeroNet) {
+			return nil, err
+		}
+	}
+	handler.SetTicks(ticks)
+	return handler, nil
+}
+
+// Fetcher — entities.TickFetcher для entities.LazyTickProvider поверх TickLens: call выполняет
+// eth_call к контракту TickLens с calldata на блоке block и возвращает ABI-результат.
+func Fetcher(pool common.Address, call func(block uint64, calldata []byte) ([]byte, error)) entities.TickFetcher {
+	return func(block uint64, word int16) ([]entities.Tick, error) {
+		calldata, err := PackGetPopulatedTicksInWord(pool, word)
+		if err != nil {
+			return nil, err
+		}
+		data, err := call(block, calldata)
+		if err != nil {
+			return nil, err
+		}
+		populated, err := UnpackPopulatedTicks(data)
+		if err != nil {
+			return nil, err
+		}
+
+		ticks := make([]entities.Tick, len(populated))
+		for i := range populated {
+			ticks[i] = entities.Tick{
+				Index:          populated[i].Tick,
+				LiquidityGross: &populated[i].LiquidityGross,
+				LiquidityNet:   &populated[i].LiquidityNet,
+			}
+		}
+		return ticks, nil
+	}
+}
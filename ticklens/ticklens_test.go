@@ -0,0 +1,230 @@
+package ticklens
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bobinmad/uniswapv3-sdk-uint256/entities"
+	"github.com/bobinmad/uniswapv3-sdk-uint256/utils"
+)
+
+const spacing = 60
+
+// chainTicks — тики пула с tickSpacing 60: full range, узкая позиция у нуля и позиция в соседнем слове.
+// Слова: -58 (-887220), -1 (-120), 0 (120), 1 (15360, 15420), 57 (887220).
+var chainTicks = []struct {
+	tick       int32
+	gross, net int64
+	feeGrowth0 uint64
+}{
+	{tick: -887220, gross: 1000, net: 1000},
+	{tick: -120, gross: 300, net: 300, feeGrowth0: 7},
+	{tick: 120, gross: 300, net: -300},
+	{tick: 15360, gross: 50, net: 50},
+	{tick: 15420, gross: 50, net: -50},
+	{tick: 887220, gross: 1000, net: -1000},
+}
+
+// packPopulatedTicks — ответ TickLens.getPopulatedTicksInWord для слова word.
+func packPopulatedTicks(t *testing.T, word int16) []byte {
+	var ticks []populatedTickABI
+	for _, ct := range chainTicks {
+		if WordPosition(ct.tick, spacing) == word {
+			ticks = append(ticks, populatedTickABI{big.NewInt(int64(ct.tick)), big.NewInt(ct.net), big.NewInt(ct.gross)})
+		}
+	}
+	if ticks == nil {
+		ticks = []populatedTickABI{}
+	}
+	data, err := lensABI.Methods["getPopulatedTicksInWord"].Outputs.Pack(ticks)
+	assert.NoError(t, err)
+	return data
+}
+
+// packTickBitmap — ответ pool.tickBitmap(word).
+func packTickBitmap(t *testing.T, word int16) []byte {
+	bitmap := new(big.Int)
+	for _, ct := range chainTicks {
+		if WordPosition(ct.tick, spacing) == word {
+			bitmap.SetBit(bitmap, int(ct.tick/spacing)&0xff, 1)
+		}
+	}
+	data, err := lensABI.Methods["tickBitmap"].Outputs.Pack(bitmap)
+	assert.NoError(t, err)
+	return data
+}
+
+// packTicks — ответ pool.ticks(tick).
+func packTicks(t *testing.T, tick int32) []byte {
+	gross, net, feeGrowth0 := int64(0), int64(0), uint64(0)
+	for _, ct := range chainTicks {
+		if ct.tick == tick {
+			gross, net, feeGrowth0 = ct.gross, ct.net, ct.feeGrowth0
+		}
+	}
+	data, err := lensABI.Methods["ticks"].Outputs.Pack(big.NewInt(gross), big.NewInt(net),
+		new(big.Int).SetUint64(feeGrowth0), big.NewInt(0), big.NewInt(-5), big.NewInt(0), uint32(0), gross != 0)
+	assert.NoError(t, err)
+	return data
+}
+
+func assertChainTicks(t *testing.T, handler *entities.TicksHandler, from, to int) {
+	assert.Equal(t, to-from, handler.TicksLen)
+	for i, ct := range chainTicks[from:to] {
+		tick := handler.Ticks[i]
+		assert.Equal(t, ct.tick, tick.Index)
+		assert.Equal(t, uint64(ct.gross), tick.LiquidityGross.Uint64())
+		assert.Equal(t, ct.net, tick.LiquidityNet.Int64())
+	}
+}
+
+func TestWordPosition(t *testing.T) {
+	assert.Equal(t, int16(0), WordPosition(0, 1))
+	assert.Equal(t, int16(0), WordPosition(255, 1))
+	assert.Equal(t, int16(1), WordPosition(256, 1))
+	assert.Equal(t, int16(-1), WordPosition(-1, 1))
+	assert.Equal(t, int16(-1), WordPosition(-256, 1))
+	assert.Equal(t, int16(-2), WordPosition(-257, 1))
+	// -61 / 60 округляется к минус бесконечности: compressed = -2
+	assert.Equal(t, int16(-1), WordPosition(-61, spacing))
+	assert.Equal(t, int16(1), WordPosition(15360, spacing))
+
+	minWord, maxWord := WordRange(1)
+	assert.Equal(t, int16(-3466), minWord)
+	assert.Equal(t, int16(3465), maxWord)
+	minWord, maxWord = WordRange(spacing)
+	assert.Equal(t, int16(-58), minWord)
+	assert.Equal(t, int16(57), maxWord)
+
+	assert.Equal(t, []int16{-2, -1, 0, 1, 2}, WordsAround(30, spacing, 2))
+	assert.Equal(t, []int16{55, 56, 57}, WordsAround(utils.MaxTick, spacing, 2))
+	assert.Len(t, WordsAround(0, spacing, -1), 116)
+}
+
+func TestTicksInWord(t *testing.T) {
+	// биты 0, 63, 64, 255
+	bitmap := utils.Uint256{1 | 1<<63, 1, 0, 1 << 63}
+	assert.Equal(t, []int32{-256 * spacing, -193 * spacing, -192 * spacing, -1 * spacing}, TicksInWord(-1, &bitmap, spacing))
+	assert.Nil(t, TicksInWord(3, new(utils.Uint256), spacing))
+}
+
+func TestPackCalldata(t *testing.T) {
+	pool := common.HexToAddress("0x8ad599c3A0ff1De082011EFDDc58f1908eb6e6D8")
+	data, err := PackGetPopulatedTicksInWord(pool, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256([]byte("getPopulatedTicksInWord(address,int16)"))[:4], data[:4])
+	assert.Equal(t, pool.Bytes(), data[4+12:4+32])
+	assert.Equal(t, common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff").Bytes(), data[4+32:], "int16(-1)")
+
+	data, err = PackTicks(-887220)
+	assert.NoError(t, err)
+	assert.Len(t, data, 4+32)
+}
+
+func TestLoader_PopulatedTicks(t *testing.T) {
+	words := WordsAround(0, spacing, -1)
+	results := make([][]byte, len(words))
+	for i, word := range words {
+		results[i] = packPopulatedTicks(t, word)
+	}
+
+	handler, err := NewLoader(spacing).PopulatedTicks(words, results)
+	assert.NoError(t, err)
+	assertChainTicks(t, handler, 0, len(chainTicks))
+
+	// только слово тика 0: сумма LiquidityNet не нулевая — только с Partial
+	window := WordsAround(0, spacing, 0)
+	results = results[:0]
+	for _, word := range window {
+		results = append(results, packPopulatedTicks(t, word))
+	}
+	_, err = NewLoader(spacing).PopulatedTicks(window, results)
+	assert.ErrorIs(t, err, entities.ErrZeroNet)
+
+	loader := NewLoader(spacing)
+	loader.Partial = true
+	handler, err = loader.PopulatedTicks(window, results)
+	assert.NoError(t, err)
+	assertChainTicks(t, handler, 2, 3)
+
+	// ответ не для того слова
+	_, err = loader.PopulatedTicks([]int16{5}, [][]byte{packPopulatedTicks(t, 1)})
+	assert.ErrorIs(t, err, ErrTickOutsideWord)
+	_, err = loader.PopulatedTicks([]int16{1, 1}, [][]byte{packPopulatedTicks(t, 1), packPopulatedTicks(t, 1)})
+	assert.ErrorIs(t, err, ErrDuplicateTick)
+	_, err = loader.PopulatedTicks(window, results[:0])
+	assert.ErrorIs(t, err, ErrResultsCount)
+
+	handler, err = loader.PopulatedTicks([]int16{3}, [][]byte{packPopulatedTicks(t, 3)})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, handler.TicksLen)
+}
+
+func TestLoader_BitmapAndTicks(t *testing.T) {
+	words := WordsAround(0, spacing, -1)
+	bitmaps := make([][]byte, len(words))
+	for i, word := range words {
+		bitmaps[i] = packTickBitmap(t, word)
+	}
+
+	loader := NewLoader(spacing)
+	ticks, err := loader.TicksInBitmaps(words, bitmaps)
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{-887220, -120, 120, 15360, 15420, 887220}, ticks)
+
+	// лишний неинициализированный тик пропускается
+	ticks = append(ticks, 60)
+	results := make([][]byte, len(ticks))
+	for i, tick := range ticks {
+		results[i] = packTicks(t, tick)
+	}
+	handler, err := loader.Ticks(ticks, results)
+	assert.NoError(t, err)
+	assertChainTicks(t, handler, 0, len(chainTicks))
+	assert.Equal(t, uint64(7), handler.Ticks[1].FeeGrowthOutside0X128.Uint64(), "ticks() отдаёт и feeGrowthOutside")
+
+	next, initialized, err := handler.NextInitializedTickIndex(0, false)
+	assert.NoError(t, err)
+	assert.True(t, initialized)
+	assert.Equal(t, int32(120), next)
+
+	// тик не кратен tickSpacing
+	_, err = loader.Ticks([]int32{61}, [][]byte{packTicks(t, 120)})
+	assert.ErrorIs(t, err, entities.ErrInvalidTickSpacing)
+}
+
+func TestFetcher(t *testing.T) {
+	pool := common.HexToAddress("0x8ad599c3A0ff1De082011EFDDc58f1908eb6e6D8")
+	var blocks []uint64
+	call := func(block uint64, calldata []byte) ([]byte, error) {
+		blocks = append(blocks, block)
+		args, err := lensABI.Methods["getPopulatedTicksInWord"].Inputs.Unpack(calldata[4:])
+		assert.NoError(t, err)
+		assert.Equal(t, pool, args[0])
+		return packPopulatedTicks(t, args[1].(int16)), nil
+	}
+
+	provider := entities.NewLazyTickProvider(spacing, 42, Fetcher(pool, call))
+	next, initialized, err := provider.NextInitializedTickIndex(0, false)
+	assert.NoError(t, err)
+	assert.True(t, initialized)
+	assert.Equal(t, int32(120), next)
+
+	// за последним тиком слова — граница слова, следующее слово подгружается уже со следующего шага
+	next, initialized, err = provider.NextInitializedTickIndex(120, false)
+	assert.NoError(t, err)
+	assert.False(t, initialized)
+	assert.Equal(t, int32(15300), next)
+	next, _, err = provider.NextInitializedTickIndex(next, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(15360), next)
+	assert.Equal(t, []uint64{42, 42}, blocks)
+
+	tick, err := provider.GetTick(-120)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), tick.LiquidityNet.Int64())
+}
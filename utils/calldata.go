@@ -0,0 +1,21 @@
+package utils
+
+import "math/big"
+
+// MethodParameters — параметры вызова контракта, собранные periphery: calldata и сколько эфира (wei) отправить.
+type MethodParameters struct {
+	Calldata []byte
+	Value    *big.Int
+}
+
+// ToHex — i в hex с префиксом 0x и чётным числом цифр (nil и 0 — "0x00").
+func ToHex(i *big.Int) string {
+	if i == nil {
+		return "0x00"
+	}
+	hex := i.Text(16)
+	if len(hex)%2 != 0 {
+		hex = "0" + hex
+	}
+	return "0x" + hex
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToHex(t *testing.T) {
+	assert.Equal(t, "0x00", ToHex(nil))
+	assert.Equal(t, "0x00", ToHex(big.NewInt(0)))
+	assert.Equal(t, "0x01", ToHex(big.NewInt(1)))
+	assert.Equal(t, "0x64", ToHex(big.NewInt(100)))
+	assert.Equal(t, "0x0100", ToHex(big.NewInt(256)))
+}
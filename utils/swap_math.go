@@ -20,6 +20,10 @@ type SwapStepCalculator struct {
 	maxFeeMinusFeePips *uint256.Int
 	feePipsU256        *uint256.Int
 	cachedFeePips      uint64
+
+	// amountOutRemaining — |amountRemaining| для exact output. Отдельное хранилище: при exact input
+	// amountRemainingU указывает на сам amountRemaining вызывающего, и Neg по нему испортил бы его.
+	amountOutRemaining uint256.Int
 }
 
 func NewSwapStepCalculator() *SwapStepCalculator {
@@ -73,6 +77,7 @@ func (c *SwapStepCalculator) ComputeSwapStep(
 			c.sqrtPriceCalculator.GetNextSqrtPriceFromInput(sqrtRatioCurrentX96, liquidity, c.tmpUint256, zeroForOne, sqrtRatioNextX96)
 		}
 	} else {
+		c.amountRemainingU = &c.amountOutRemaining
 		c.amountRemainingU.Neg((*uint256.Int)(amountRemaining))
 
 		if zeroForOne {
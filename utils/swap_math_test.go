@@ -94,3 +94,31 @@ func TestComputeSwapStep(t *testing.T) {
 		})
 	}
 }
+
+func TestComputeSwapStep_ExactOutAfterExactIn(t *testing.T) {
+	c := NewSwapStepCalculator()
+	price := uint256.MustFromDecimal("79228162514264337593543950336")
+	priceTarget := uint256.MustFromDecimal("79623317895830914510639640423")
+	liquidity := uint256.MustFromDecimal("2000000000000000000")
+	var sqrtRatioNextX96 Uint160
+	var amountIn, amountOut, feeAmount Uint256
+
+	// эталон exact output на свежем калькуляторе
+	var wantNext Uint160
+	var wantIn, wantOut, wantFee Uint256
+	NewSwapStepCalculator().ComputeSwapStep(price, priceTarget, liquidity, int256.NewInt(-500), 600,
+		&wantNext, &wantIn, &wantOut, &wantFee, false, false)
+
+	// exact output не должен писать в amountRemaining предыдущего exact input: раньше
+	// amountRemainingU оставался указателем на него, и Neg превращал 1000 в |-500|
+	amountInRemaining := int256.NewInt(1000)
+	c.ComputeSwapStep(price, priceTarget, liquidity, amountInRemaining, 600,
+		&sqrtRatioNextX96, &amountIn, &amountOut, &feeAmount, false, true)
+	c.ComputeSwapStep(price, priceTarget, liquidity, int256.NewInt(-500), 600,
+		&sqrtRatioNextX96, &amountIn, &amountOut, &feeAmount, false, false)
+	assert.Equal(t, "1000", amountInRemaining.Dec())
+	assert.Equal(t, "500", amountOut.Dec())
+	assert.Equal(t, wantNext, sqrtRatioNextX96)
+	assert.Equal(t, wantIn, amountIn)
+	assert.Equal(t, wantFee, feeAmount)
+}